@@ -0,0 +1,72 @@
+package sleepy
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"net/url"
+)
+
+// AddAsyncResource adds a resource whose methods only cause side
+// effects, such as recording an analytics hit, at the given paths. Each
+// request is answered at once with a 202 Accepted and a body of
+//
+//	{"status": "accepted", "request_id": "..."}
+//
+// while the resource's method runs in its own goroutine, and whatever
+// it returns is discarded. The request ID is also sent in the
+// X-Request-ID header; it is taken from the request's own X-Request-ID
+// header when there is one, and is stored in the method's context under
+// ContextKeyRequestID. A panic in the background is logged rather
+// than crashing the program.
+func (api *API) AddAsyncResource(resource interface{}, paths ...string) {
+	for _, path := range paths {
+		api.register(path, resource, api.asyncHandler)
+	}
+}
+
+// asyncHandler returns the handler that serves resource the way
+// AddAsyncResource does.
+func (api *API) asyncHandler(resource interface{}) http.Handler {
+	set := make(methodSet)
+	for _, method := range methods {
+		if handler := methodHandler(resource, method); handler != nil {
+			set[method] = async(method, handler)
+		}
+	}
+	return api.requestHandler(set)
+}
+
+// async returns a handler that starts handler in the background and
+// reports the request as accepted.
+func async(method string, handler handlerFunc) handlerFunc {
+	return func(ctx context.Context, values url.Values, header http.Header) (int, interface{}, http.Header) {
+		id := header.Get("X-Request-ID")
+		if id == "" {
+			id = newRequestID()
+		}
+
+		copied := make(url.Values, len(values))
+		for key, value := range values {
+			copied[key] = append([]string(nil), value...)
+		}
+		go func(ctx context.Context, header http.Header) {
+			defer func() {
+				if recovered := recover(); recovered != nil {
+					logf("sleepy: panic in async %s (request %s): %v", method, id, recovered)
+				}
+			}()
+			handler(ctx, copied, header)
+		}(SetContextValue(context.WithoutCancel(ctx), ContextKeyRequestID, id), header.Clone())
+
+		return http.StatusAccepted, map[string]string{"status": "accepted", "request_id": id}, http.Header{"X-Request-ID": {id}}
+	}
+}
+
+// newRequestID returns a random 128-bit identifier in hex.
+func newRequestID() string {
+	var id [16]byte
+	rand.Read(id[:])
+	return hex.EncodeToString(id[:])
+}
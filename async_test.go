@@ -0,0 +1,83 @@
+package sleepy
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+)
+
+type Hit struct {
+	pages chan string
+}
+
+func (hit Hit) Post(values url.Values, headers http.Header) (int, interface{}, http.Header) {
+	if values.Get("page") == "" {
+		panic("no page")
+	}
+	hit.pages <- values.Get("page")
+	return 200, "ignored", nil
+}
+
+func TestAsyncResource(t *testing.T) {
+	hit := Hit{pages: make(chan string, 1)}
+	api := NewAPI()
+	api.AddAsyncResource(hit, "/hits")
+
+	recorder := httptest.NewRecorder()
+	api.ServeHTTP(recorder, httptest.NewRequest("POST", "/hits?page=home", nil))
+	if recorder.Code != http.StatusAccepted {
+		t.Fatalf("got status %d, want 202", recorder.Code)
+	}
+	var body map[string]string
+	if err := json.Unmarshal(recorder.Body.Bytes(), &body); err != nil {
+		t.Fatal(err)
+	}
+	if body["status"] != "accepted" || body["request_id"] == "" {
+		t.Errorf("got body %v", body)
+	}
+	if id := recorder.Header().Get("X-Request-ID"); id != body["request_id"] {
+		t.Errorf("got X-Request-ID %q, want %q", id, body["request_id"])
+	}
+
+	select {
+	case page := <-hit.pages:
+		if page != "home" {
+			t.Errorf("handler got page %q, want home", page)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("handler was not called")
+	}
+}
+
+func TestAsyncResourcePanic(t *testing.T) {
+	logged := make(chan string, 1)
+	logf = func(format string, args ...interface{}) {
+		logged <- fmt.Sprintf(format, args...)
+	}
+	defer func() { logf = log.Printf }()
+
+	api := NewAPI()
+	api.AddAsyncResource(Hit{}, "/hits")
+
+	request := httptest.NewRequest("POST", "/hits", nil)
+	request.Header.Set("X-Request-ID", "abc")
+	recorder := httptest.NewRecorder()
+	api.ServeHTTP(recorder, request)
+	if recorder.Code != http.StatusAccepted {
+		t.Fatalf("got status %d, want 202", recorder.Code)
+	}
+
+	select {
+	case line := <-logged:
+		if line != "sleepy: panic in async POST (request abc): no page" {
+			t.Errorf("got log line %q", line)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("panic was not logged")
+	}
+}
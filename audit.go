@@ -0,0 +1,34 @@
+package sleepy
+
+import "net/http"
+
+// EnableAuditBody passes the raw body of every POST, PUT, PATCH and
+// DELETE the API serves to sink, along with its method and path, for
+// audit logging. The body is read into memory before the API handles
+// the request, and the resource reads it from there as it would have
+// otherwise; sink is given a copy of it, which it may keep, before the
+// request is handled. A body over the limit set with SetMaxBodyBytes is
+// refused with a 413 Request Entity Too Large without being passed to
+// sink. Pass nil to stop auditing.
+func (api *API) EnableAuditBody(sink func(method, path string, body []byte)) {
+	api.auditBody = sink
+}
+
+func (api *API) auditBodyHandler(next http.Handler) http.Handler {
+	sink := api.auditBody
+	return http.HandlerFunc(func(rw http.ResponseWriter, request *http.Request) {
+		switch request.Method {
+		case POST, PUT, PATCH, DELETE:
+		default:
+			next.ServeHTTP(rw, request)
+			return
+		}
+
+		body, ok := api.bufferBody(rw, request)
+		if !ok {
+			return
+		}
+		sink(request.Method, request.URL.Path, append([]byte(nil), body...))
+		next.ServeHTTP(rw, request)
+	})
+}
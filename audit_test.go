@@ -0,0 +1,49 @@
+package sleepy
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestEnableAuditBody(t *testing.T) {
+	type audited struct {
+		method, path, body string
+	}
+	var entries []audited
+
+	api := NewAPI()
+	api.AddResource(new(Upload), "/uploads")
+	api.SetMaxBodyBytes(16)
+	api.EnableAuditBody(func(method, path string, body []byte) {
+		entries = append(entries, audited{method, path, string(body)})
+	})
+
+	request := httptest.NewRequest("POST", "/uploads", strings.NewReader("name=a.txt"))
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	recorder := httptest.NewRecorder()
+	api.ServeHTTP(recorder, request)
+	if recorder.Code != 201 || recorder.Body.String() != `"a.txt"` {
+		t.Errorf("handler: got %d %q", recorder.Code, recorder.Body.String())
+	}
+	if len(entries) != 1 || entries[0] != (audited{"POST", "/uploads", "name=a.txt"}) {
+		t.Errorf("sink got %+v", entries)
+	}
+
+	request = httptest.NewRequest("GET", "/uploads", nil)
+	api.ServeHTTP(httptest.NewRecorder(), request)
+	if len(entries) != 1 {
+		t.Errorf("GET was audited: %+v", entries)
+	}
+
+	request = httptest.NewRequest("POST", "/uploads", strings.NewReader("name=a-much-longer-name.txt"))
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	recorder = httptest.NewRecorder()
+	api.ServeHTTP(recorder, request)
+	if recorder.Code != 413 {
+		t.Errorf("oversized body: got %d, want 413", recorder.Code)
+	}
+	if len(entries) != 1 {
+		t.Errorf("oversized body was audited: %+v", entries)
+	}
+}
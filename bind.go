@@ -0,0 +1,191 @@
+package sleepy
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Bind fills in the fields of the struct dst points to from values,
+// such as those a resource method is passed, for filter and search
+// endpoints with many parameters. Each field tagged query:"name" is set
+// from the parameter name; fields without the tag, and parameters that
+// are absent, are left alone. A field may be a string, a bool, an
+// integer, a float or a slice of any of them. A slice gets every value
+// of a repeated parameter, as in ?tag=a&tag=b, and with the split
+// option, query:"tag,split", each value is also split at commas, so
+// that ?tag=a,b gives the same. Other fields get the first value.
+//
+//	var filter struct {
+//		Tags  []string `query:"tag,split"`
+//		Limit int      `query:"limit"`
+//	}
+//	if err := sleepy.Bind(values, &filter); err != nil {
+//		return 0, err, nil
+//	}
+//
+// A value that doesn't parse as its field's type is reported as a
+// StatusError with the status 400 Bad Request. Bind panics if dst is
+// not a pointer to a struct or a tagged field has an unsupported type.
+// The same tags work on the Params of a generic endpoint, where they
+// take the place of the JSON names.
+func Bind(values url.Values, dst interface{}) error {
+	v := reflect.ValueOf(dst)
+	if v.Kind() != reflect.Pointer || v.Elem().Kind() != reflect.Struct {
+		panic(fmt.Sprintf("sleepy: Bind needs a pointer to a struct, not %T", dst))
+	}
+	err := bindValues(values, v.Elem(), true)
+	var status *StatusError
+	if err != nil && !errors.As(err, &status) {
+		panic(err.Error())
+	}
+	return err
+}
+
+// bindValues sets the fields of the struct v from values. A field is
+// matched by the name in its query tag, or, unless tagged is set, by its
+// JSON name; with tagged, fields without a query tag are left alone.
+// The tag's split option applies as Bind describes. A value that
+// doesn't parse is reported as a 400 StatusError. Values of kinds other
+// than struct are left alone.
+func bindValues(values url.Values, v reflect.Value, tagged bool) error {
+	if v.Kind() != reflect.Struct {
+		return nil
+	}
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Type().Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name, split, ok := paramName(field, tagged)
+		if !ok {
+			continue
+		}
+		given, ok := values[name]
+		if !ok {
+			continue
+		}
+		if err := bindField(v.Field(i), name, given, split); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// paramName returns the name of the parameter that sets field, and
+// whether its values are split at commas. It reports false if the field
+// is not bound at all.
+func paramName(field reflect.StructField, tagged bool) (string, bool, bool) {
+	if tag, ok := field.Tag.Lookup("query"); ok {
+		name, options, _ := strings.Cut(tag, ",")
+		return name, options == "split", name != "" && name != "-"
+	}
+	if tagged {
+		return "", false, false
+	}
+	name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+	switch name {
+	case "-":
+		return "", false, false
+	case "":
+		name = field.Name
+	}
+	return name, false, true
+}
+
+// bindField sets field from the values given for the parameter name.
+func bindField(field reflect.Value, name string, given []string, split bool) error {
+	if split && field.Kind() == reflect.Slice {
+		var parts []string
+		for _, value := range given {
+			for _, part := range strings.Split(value, ",") {
+				parts = append(parts, strings.TrimSpace(part))
+			}
+		}
+		given = parts
+	}
+	if len(given) == 0 {
+		return nil
+	}
+	err := setValue(field, given)
+	var numErr *strconv.NumError
+	switch {
+	case err == nil:
+		return nil
+	case errors.As(err, &numErr) && errors.Is(err, strconv.ErrRange):
+		return &StatusError{Code: http.StatusBadRequest, Err: fmt.Errorf("parameter %s is %q, out of range", name, numErr.Num)}
+	case errors.As(err, &numErr):
+		return &StatusError{Code: http.StatusBadRequest, Err: fmt.Errorf("parameter %s is %q, not %s", name, numErr.Num, describeKind(field))}
+	}
+	return fmt.Errorf("sleepy: can't set the %s field for parameter %s", field.Type(), name)
+}
+
+// setValue parses given into v, which is a basic type or a slice of
+// one. A single value uses the first of given.
+func setValue(v reflect.Value, given []string) error {
+	if v.Kind() == reflect.Slice && v.Type().Elem().Kind() != reflect.Uint8 {
+		slice := reflect.MakeSlice(v.Type(), len(given), len(given))
+		for i, s := range given {
+			if err := setValue(slice.Index(i), []string{s}); err != nil {
+				return err
+			}
+		}
+		v.Set(slice)
+		return nil
+	}
+
+	s := given[0]
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(s)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(s, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(s, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(s, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetFloat(f)
+	default:
+		return fmt.Errorf("unsupported type %s", v.Type())
+	}
+	return nil
+}
+
+// describeKind says what the values of a field of v's type, or of the
+// elements of a slice, must be.
+func describeKind(v reflect.Value) string {
+	kind := v.Kind()
+	if kind == reflect.Slice {
+		kind = v.Type().Elem().Kind()
+	}
+	switch kind {
+	case reflect.Bool:
+		return "true or false"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return "an integer"
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "a non-negative integer"
+	}
+	return "a number"
+}
@@ -0,0 +1,72 @@
+package sleepy
+
+import (
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type productFilter struct {
+	Tags     []string `query:"tag"`
+	Sizes    []int    `query:"size,split"`
+	Colors   []string `query:"color,split"`
+	InStock  bool     `query:"in_stock"`
+	MaxPrice float64  `query:"max_price"`
+	Page     uint8    `query:"page"`
+	Ignored  string
+}
+
+func TestBind(t *testing.T) {
+	values, _ := url.ParseQuery("tag=a&tag=b,c&size=1,2&size=3&color=red,%20blue&in_stock=true&max_price=9.5&page=2&Ignored=x")
+	var filter productFilter
+	if err := Bind(values, &filter); err != nil {
+		t.Fatal(err)
+	}
+	want := productFilter{
+		Tags:     []string{"a", "b,c"},
+		Sizes:    []int{1, 2, 3},
+		Colors:   []string{"red", "blue"},
+		InStock:  true,
+		MaxPrice: 9.5,
+		Page:     2,
+	}
+	if !reflect.DeepEqual(filter, want) {
+		t.Errorf("got %+v, want %+v", filter, want)
+	}
+
+	for query, message := range map[string]string{
+		"size=1,x":      `parameter size is "x", not an integer`,
+		"page=300":      `parameter page is "300", out of range`,
+		"in_stock=sure": `parameter in_stock is "sure", not true or false`,
+	} {
+		values, _ := url.ParseQuery(query)
+		err := Bind(values, new(productFilter))
+		status, ok := err.(*StatusError)
+		if !ok || status.Code != 400 || status.Error() != message {
+			t.Errorf("%s: got %v, want a 400 saying %q", query, err, message)
+		}
+	}
+}
+
+func TestEndpointQueryTags(t *testing.T) {
+	var got productFilter
+	api := NewAPI()
+	api.AddResource(Get(func(r *Request[productFilter]) (*productFilter, error) {
+		got = r.Params
+		return &r.Params, nil
+	}), "/products")
+
+	recorder := httptest.NewRecorder()
+	api.ServeHTTP(recorder, httptest.NewRequest("GET", "/products?size=1,2&color=red&Ignored=x", nil))
+	if want := (productFilter{Sizes: []int{1, 2}, Colors: []string{"red"}, Ignored: "x"}); recorder.Code != 200 || !reflect.DeepEqual(got, want) {
+		t.Errorf("got %d with %+v, want %+v", recorder.Code, got, want)
+	}
+
+	recorder = httptest.NewRecorder()
+	api.ServeHTTP(recorder, httptest.NewRequest("GET", "/products?size=1,x", nil))
+	if recorder.Code != 400 || !strings.Contains(recorder.Body.String(), `parameter size is \"x\", not an integer`) {
+		t.Errorf("bad size: got %d %s", recorder.Code, recorder.Body.String())
+	}
+}
@@ -0,0 +1,64 @@
+package sleepy
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net/http"
+)
+
+// SetMaxBodyBytes limits request bodies to n bytes. A request whose
+// Content-Length is larger is answered with a 413 Request Entity Too
+// Large before any of its body is read; one that sends more than n
+// bytes without declaring it is cut off at the limit and gets a 413
+// too. A gzipped body is held to the limit both as sent and once
+// decompressed. An n of zero or less removes the limit.
+func (api *API) SetMaxBodyBytes(n int64) {
+	api.maxBodyBytes = n
+}
+
+// limitBody applies the API's body limit to request. It reports
+// whether the request may go ahead; if not, it has already answered it.
+func (api *API) limitBody(rw http.ResponseWriter, request *http.Request) bool {
+	if api.maxBodyBytes <= 0 || request.Body == nil {
+		return true
+	}
+	if request.ContentLength > api.maxBodyBytes {
+		rw.WriteHeader(http.StatusRequestEntityTooLarge)
+		return false
+	}
+	request.Body = http.MaxBytesReader(rw, request.Body, api.maxBodyBytes)
+	return true
+}
+
+// bufferBody reads the whole of request's body into memory, under the
+// API's body limit, and puts a reader of the copy in its place, for
+// handlers that need the body before the resource reads it. It returns
+// the body, which is nil if the request has none, and reports whether
+// the request may go ahead; if not, it has already answered it.
+func (api *API) bufferBody(rw http.ResponseWriter, request *http.Request) ([]byte, bool) {
+	if request.Body == nil || request.Body == http.NoBody {
+		return nil, true
+	}
+	if !api.limitBody(rw, request) {
+		return nil, false
+	}
+	body, err := io.ReadAll(request.Body)
+	if err != nil {
+		rw.WriteHeader(bodyErrorStatus(err))
+		return nil, false
+	}
+	request.Body.Close()
+	request.Body = io.NopCloser(bytes.NewReader(body))
+	return body, true
+}
+
+// bodyErrorStatus returns the status to answer a request with when
+// reading its body failed with err.
+func bodyErrorStatus(err error) int {
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		return http.StatusRequestEntityTooLarge
+	}
+	return http.StatusBadRequest
+}
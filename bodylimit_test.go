@@ -0,0 +1,76 @@
+package sleepy
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// countingReader counts the bytes read from it.
+type countingReader struct {
+	io.Reader
+	read int
+}
+
+func (r *countingReader) Read(p []byte) (int, error) {
+	n, err := r.Reader.Read(p)
+	r.read += n
+	return n, err
+}
+
+func TestSetMaxBodyBytes(t *testing.T) {
+	api := NewAPI()
+	api.AddResource(new(Upload), "/uploads")
+	api.SetMaxBodyBytes(16)
+
+	post := func(body string, contentLength int64) (int, int) {
+		reader := &countingReader{Reader: strings.NewReader(body)}
+		request := httptest.NewRequest("POST", "/uploads", reader)
+		request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		request.ContentLength = contentLength
+		recorder := httptest.NewRecorder()
+		api.ServeHTTP(recorder, request)
+		return recorder.Code, reader.read
+	}
+
+	long := "name=" + strings.Repeat("a", 32)
+	if code, read := post(long, int64(len(long))); code != http.StatusRequestEntityTooLarge || read != 0 {
+		t.Errorf("declared oversized: got %d after reading %d bytes, want 413 after none", code, read)
+	}
+	if code, _ := post(long, -1); code != http.StatusRequestEntityTooLarge {
+		t.Errorf("undeclared oversized: got %d, want 413", code)
+	}
+	if code, _ := post(long, 8); code != http.StatusRequestEntityTooLarge {
+		t.Errorf("understated oversized: got %d, want 413", code)
+	}
+	if code, _ := post("name=a.txt", 10); code != 201 {
+		t.Errorf("within the limit: got %d, want 201", code)
+	}
+}
+
+func TestMaxBodyBytesDecompressed(t *testing.T) {
+	api := NewAPI()
+	api.AddResource(new(Upload), "/uploads")
+	api.SetMaxBodyBytes(4096)
+
+	var compressed bytes.Buffer
+	writer := gzip.NewWriter(&compressed)
+	io.WriteString(writer, "name="+strings.Repeat("a", 1<<20))
+	writer.Close()
+	if compressed.Len() > 4096 {
+		t.Fatalf("compressed body of %d bytes is over the limit", compressed.Len())
+	}
+
+	request := httptest.NewRequest("POST", "/uploads", &compressed)
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	request.Header.Set("Content-Encoding", "gzip")
+	recorder := httptest.NewRecorder()
+	api.ServeHTTP(recorder, request)
+	if recorder.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("got %d, want 413", recorder.Code)
+	}
+}
@@ -0,0 +1,122 @@
+package sleepy
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// defaultMaxLoggedBodyBytes is the largest request body
+// BodyLoggingMiddleware will buffer and log. Larger bodies are passed
+// through untouched.
+const defaultMaxLoggedBodyBytes = 64 << 10
+
+// redactedValue replaces the value of any redacted field in logged bodies.
+const redactedValue = "[REDACTED]"
+
+// BodyLoggingMiddleware returns middleware that logs each request body
+// to logger before passing the request on. The body is buffered and
+// re-attached to the request, so handlers can still read it.
+//
+// JSON and form-encoded bodies have the value of every field named in
+// redact replaced by "[REDACTED]"; field names are matched without
+// regard to case. Bodies larger than 64KB are neither buffered nor
+// logged; BodyLoggingMiddlewareWithLimit sets another limit.
+func BodyLoggingMiddleware(logger *slog.Logger, redact []string) func(http.Handler) http.Handler {
+	return BodyLoggingMiddlewareWithLimit(logger, redact, defaultMaxLoggedBodyBytes)
+}
+
+// BodyLoggingMiddlewareWithLimit behaves like BodyLoggingMiddleware, but
+// buffers and logs bodies of up to maxBytes instead of 64KB. A maxBytes
+// of zero or less means the default.
+func BodyLoggingMiddlewareWithLimit(logger *slog.Logger, redact []string, maxBytes int64) func(http.Handler) http.Handler {
+	if maxBytes <= 0 {
+		maxBytes = defaultMaxLoggedBodyBytes
+	}
+	redacted := make(map[string]bool, len(redact))
+	for _, field := range redact {
+		redacted[strings.ToLower(field)] = true
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(rw http.ResponseWriter, request *http.Request) {
+			if request.Body == nil || request.Body == http.NoBody || request.ContentLength > maxBytes {
+				next.ServeHTTP(rw, request)
+				return
+			}
+
+			body, err := io.ReadAll(io.LimitReader(request.Body, maxBytes+1))
+			if err != nil || int64(len(body)) > maxBytes {
+				// Unread bytes remain in the original body; hand the
+				// handler everything without logging it.
+				request.Body = struct {
+					io.Reader
+					io.Closer
+				}{io.MultiReader(bytes.NewReader(body), request.Body), request.Body}
+				next.ServeHTTP(rw, request)
+				return
+			}
+			request.Body.Close()
+			request.Body = io.NopCloser(bytes.NewReader(body))
+
+			logger.Info("request body",
+				"method", request.Method,
+				"path", request.URL.Path,
+				"body", redactBody(request.Header.Get("Content-Type"), body, redacted))
+			next.ServeHTTP(rw, request)
+		})
+	}
+}
+
+// redactBody returns body as a string with redacted fields masked,
+// according to its content type.
+func redactBody(contentType string, body []byte, redacted map[string]bool) string {
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	switch {
+	case mediaType == "application/x-www-form-urlencoded":
+		values, err := url.ParseQuery(string(body))
+		if err != nil {
+			break
+		}
+		for key := range values {
+			if redacted[strings.ToLower(key)] {
+				values[key] = []string{redactedValue}
+			}
+		}
+		return values.Encode()
+	case mediaType == "application/json" || strings.HasSuffix(mediaType, "+json"):
+		var value interface{}
+		if json.Unmarshal(body, &value) != nil {
+			break
+		}
+		masked, err := json.Marshal(redactJSON(value, redacted))
+		if err != nil {
+			break
+		}
+		return string(masked)
+	}
+	return string(body)
+}
+
+func redactJSON(value interface{}, redacted map[string]bool) interface{} {
+	switch value := value.(type) {
+	case map[string]interface{}:
+		for key, field := range value {
+			if redacted[strings.ToLower(key)] {
+				value[key] = redactedValue
+			} else {
+				value[key] = redactJSON(field, redacted)
+			}
+		}
+	case []interface{}:
+		for i, element := range value {
+			value[i] = redactJSON(element, redacted)
+		}
+	}
+	return value
+}
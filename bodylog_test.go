@@ -0,0 +1,78 @@
+package sleepy
+
+import (
+	"bytes"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestBodyLoggingMiddleware(t *testing.T) {
+	var out bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&out, nil))
+
+	var seen string
+	handler := BodyLoggingMiddleware(logger, []string{"password"})(
+		http.HandlerFunc(func(rw http.ResponseWriter, request *http.Request) {
+			body, _ := io.ReadAll(request.Body)
+			seen = string(body)
+		}))
+
+	body := `{"user":"doug","Password":"hunter2","nested":{"password":"x"}}`
+	request := httptest.NewRequest("POST", "/login", strings.NewReader(body))
+	request.Header.Set("Content-Type", "application/json")
+	handler.ServeHTTP(httptest.NewRecorder(), request)
+
+	if seen != body {
+		t.Errorf("handler saw %q, want the original body", seen)
+	}
+	if strings.Contains(out.String(), "hunter2") || !strings.Contains(out.String(), redactedValue) {
+		t.Errorf("password was not redacted: %s", out.String())
+	}
+	if !strings.Contains(out.String(), "doug") {
+		t.Errorf("unredacted field missing: %s", out.String())
+	}
+}
+
+func TestBodyLoggingMiddlewareLargeBody(t *testing.T) {
+	var out bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&out, nil))
+
+	var seen int
+	handler := BodyLoggingMiddleware(logger, nil)(
+		http.HandlerFunc(func(rw http.ResponseWriter, request *http.Request) {
+			body, _ := io.ReadAll(request.Body)
+			seen = len(body)
+		}))
+
+	body := strings.Repeat("a", defaultMaxLoggedBodyBytes+10)
+	request := httptest.NewRequest("POST", "/upload", strings.NewReader(body))
+	request.ContentLength = -1
+	handler.ServeHTTP(httptest.NewRecorder(), request)
+
+	if seen != len(body) {
+		t.Errorf("handler saw %d bytes, want %d", seen, len(body))
+	}
+	if out.Len() != 0 {
+		t.Errorf("large body was logged: %d bytes of output", out.Len())
+	}
+}
+
+func TestBodyLoggingMiddlewareWithLimit(t *testing.T) {
+	var out bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&out, nil))
+	handler := BodyLoggingMiddlewareWithLimit(logger, nil, 8)(
+		http.HandlerFunc(func(rw http.ResponseWriter, request *http.Request) {}))
+
+	for _, body := range []string{"12345678", "123456789"} {
+		request := httptest.NewRequest("POST", "/upload", strings.NewReader(body))
+		request.ContentLength = -1
+		handler.ServeHTTP(httptest.NewRecorder(), request)
+	}
+	if logged := out.String(); strings.Count(logged, "request body") != 1 || !strings.Contains(logged, "body=12345678\n") {
+		t.Errorf("logged %q, want only the body at the limit", logged)
+	}
+}
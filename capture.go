@@ -0,0 +1,134 @@
+package sleepy
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// A CapturedRequest is a request recorded by CaptureAndReplay, with
+// everything needed to serve it again with API.Replay. It encodes as
+// JSON, with the body in base64, so that it can be written to a file or
+// a log entry.
+type CapturedRequest struct {
+	// Method is the request's method and URL its path and query string,
+	// as in the request line.
+	Method string `json:"method"`
+	URL    string `json:"url"`
+
+	// Header holds the request's headers, less those redacted, with
+	// its host as a Host header.
+	Header http.Header `json:"header,omitempty"`
+	Body   []byte      `json:"body,omitempty"`
+}
+
+// CaptureAndReplay returns middleware that passes to sink a capture of
+// every request answered with a server error, or whose handler panics,
+// so that it can be replayed with API.Replay when debugging the
+// failure. The headers named in redact, such as Authorization and
+// Cookie, are left out of the capture. The body is captured as the
+// handler reads it, so no more of it is held in memory than was read,
+// which SetMaxBodyBytes bounds, and a body the handler never read is
+// left out. sink may be called concurrently. For example, to write each
+// capture as a line of JSON to a file:
+//
+//	var mu sync.Mutex
+//	encoder := json.NewEncoder(file)
+//	api.Use(sleepy.CaptureAndReplay(func(captured sleepy.CapturedRequest) {
+//		mu.Lock()
+//		defer mu.Unlock()
+//		encoder.Encode(captured)
+//	}, []string{"Authorization", "Cookie"}))
+func CaptureAndReplay(sink func(CapturedRequest), redact []string) func(http.Handler) http.Handler {
+	redacted := make(map[string]bool, len(redact))
+	for _, name := range redact {
+		redacted[http.CanonicalHeaderKey(name)] = true
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(rw http.ResponseWriter, request *http.Request) {
+			var body bytes.Buffer
+			if request.Body != nil && request.Body != http.NoBody {
+				request.Body = teeBody{io.TeeReader(request.Body, &body), request.Body}
+			}
+
+			writer := wrapResponseWriter(rw)
+			panicked := true
+			defer func() {
+				if panicked || writer.status >= 500 {
+					var read []byte
+					if body.Len() > 0 {
+						read = body.Bytes()
+					}
+					sink(captureRequest(request, read, redacted))
+				}
+			}()
+			next.ServeHTTP(writer, request)
+			panicked = false
+		})
+	}
+}
+
+// teeBody is a request body that copies what is read from it.
+type teeBody struct {
+	io.Reader
+	io.Closer
+}
+
+// captureRequest returns the capture of request, whose body has been
+// read as body, without the redacted headers.
+func captureRequest(request *http.Request, body []byte, redacted map[string]bool) CapturedRequest {
+	header := make(http.Header, len(request.Header))
+	for name, values := range request.Header {
+		if !redacted[http.CanonicalHeaderKey(name)] {
+			header[name] = append([]string(nil), values...)
+		}
+	}
+	if request.Host != "" && !redacted["Host"] {
+		header.Set("Host", request.Host)
+	}
+	return CapturedRequest{
+		Method: request.Method,
+		URL:    request.URL.RequestURI(),
+		Header: header,
+		Body:   body,
+	}
+}
+
+// Replay serves captured, a request recorded by CaptureAndReplay, again
+// in process, through the API's middleware and hooks as if a client had
+// sent it, and returns the response. Redacted headers are missing from
+// the replay, so a request that needed them, to authenticate say, may
+// have to have them restored first.
+func (api *API) Replay(captured CapturedRequest) (*http.Response, error) {
+	request, err := http.NewRequest(captured.Method, captured.URL, bytes.NewReader(captured.Body))
+	if err != nil {
+		return nil, err
+	}
+	for name, values := range captured.Header {
+		request.Header[name] = append([]string(nil), values...)
+	}
+	if host := request.Header.Get("Host"); host != "" {
+		request.Host = host
+		request.Header.Del("Host")
+	}
+	request.RequestURI = captured.URL
+
+	buffered := newBufferedResponse()
+	api.ServeHTTP(buffered, request)
+	if buffered.status == 0 {
+		buffered.status = http.StatusOK
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", buffered.status, http.StatusText(buffered.status)),
+		StatusCode:    buffered.status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        buffered.header,
+		Body:          io.NopCloser(bytes.NewReader(buffered.body.Bytes())),
+		ContentLength: int64(buffered.body.Len()),
+		Request:       request,
+	}, nil
+}
@@ -0,0 +1,118 @@
+package sleepy
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+type Fragile struct{}
+
+func (fragile Fragile) PostContext(ctx context.Context, values url.Values, headers http.Header) (int, interface{}, http.Header) {
+	body, err := io.ReadAll(RequestFromContext(ctx).Body)
+	if err != nil {
+		return 0, err, nil
+	}
+	if string(body) == "boom" {
+		return 500, map[string]string{"error": "exploded on " + Query(ctx).Get("part")}, nil
+	}
+	return 200, string(body), nil
+}
+
+func TestCaptureAndReplay(t *testing.T) {
+	var captures []CapturedRequest
+	api := NewAPI()
+	api.AddResource(new(Fragile), "/fragile")
+	api.Use(CaptureAndReplay(func(captured CapturedRequest) {
+		captures = append(captures, captured)
+	}, []string{"authorization"}))
+
+	for _, body := range []string{"fine", "boom"} {
+		request := httptest.NewRequest("POST", "/fragile?part=wing", strings.NewReader(body))
+		request.Header.Set("Authorization", "Bearer secret")
+		request.Header.Set("X-Trace", "abc")
+		recorder := httptest.NewRecorder()
+		api.ServeHTTP(recorder, request)
+		if body == "fine" && (recorder.Code != 200 || recorder.Body.String() != `"fine"`) {
+			t.Errorf("handler got %d %q", recorder.Code, recorder.Body.String())
+		}
+	}
+	if len(captures) != 1 {
+		t.Fatalf("got %d captures, want only the failure", len(captures))
+	}
+
+	encoded, err := json.Marshal(captures[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	var captured CapturedRequest
+	if err := json.Unmarshal(encoded, &captured); err != nil {
+		t.Fatal(err)
+	}
+	if captured.Method != "POST" || captured.URL != "/fragile?part=wing" || string(captured.Body) != "boom" {
+		t.Errorf("captured %+v", captured)
+	}
+	if captured.Header.Get("Authorization") != "" || captured.Header.Get("X-Trace") != "abc" {
+		t.Errorf("captured headers %v, want X-Trace without Authorization", captured.Header)
+	}
+
+	response, err := api.Replay(captured)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(response.Body)
+	if response.StatusCode != 500 || !strings.Contains(string(body), "exploded on wing") {
+		t.Errorf("replay got %d %s", response.StatusCode, body)
+	}
+	if len(captures) != 2 {
+		t.Errorf("replay was not captured again")
+	}
+}
+
+func TestCaptureAndReplayPanic(t *testing.T) {
+	var captures []CapturedRequest
+	api := NewAPI()
+	api.AddHandler("/panics", http.HandlerFunc(func(rw http.ResponseWriter, request *http.Request) {
+		panic("oops")
+	}))
+	api.Use(CaptureAndReplay(func(captured CapturedRequest) {
+		captures = append(captures, captured)
+	}, nil))
+
+	recorder := httptest.NewRecorder()
+	api.ServeHTTP(recorder, httptest.NewRequest("GET", "/panics", nil))
+	if recorder.Code != 500 || len(captures) != 1 || captures[0].URL != "/panics" {
+		t.Errorf("got %d with captures %+v", recorder.Code, captures)
+	}
+}
+
+func TestCaptureAndReplayBodyLimit(t *testing.T) {
+	var captures []CapturedRequest
+	api := NewAPI()
+	api.AddResource(new(Fragile), "/fragile")
+	api.SetMaxBodyBytes(16)
+	api.Use(CaptureAndReplay(func(captured CapturedRequest) {
+		captures = append(captures, captured)
+	}, nil))
+
+	reader := &countingReader{Reader: strings.NewReader(strings.Repeat("a", 1<<20))}
+	request := httptest.NewRequest("POST", "/fragile", reader)
+	request.ContentLength = -1
+	api.ServeHTTP(httptest.NewRecorder(), request)
+	if reader.read > 1<<10 {
+		t.Errorf("read %d bytes of an oversized body", reader.read)
+	}
+	if len(captures) != 1 {
+		t.Errorf("got %d captures, want the failed request", len(captures))
+	}
+	for _, captured := range captures {
+		if len(captured.Body) > 17 {
+			t.Errorf("captured %d bytes past the limit", len(captured.Body))
+		}
+	}
+}
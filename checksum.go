@@ -0,0 +1,141 @@
+package sleepy
+
+import (
+	"bytes"
+	"crypto/md5"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// digestAlgorithm is a hash usable in the Digest header of RFC 3230.
+type digestAlgorithm struct {
+	name string // as it appears in the Digest header
+	new  func() hash.Hash
+}
+
+// lookupDigestAlgorithm accepts names like "sha256", "SHA-256" or
+// "sha-256" for each supported algorithm.
+func lookupDigestAlgorithm(algorithm string) (digestAlgorithm, bool) {
+	switch strings.ReplaceAll(strings.ToLower(algorithm), "-", "") {
+	case "md5":
+		return digestAlgorithm{"MD5", md5.New}, true
+	case "sha256":
+		return digestAlgorithm{"SHA-256", sha256.New}, true
+	case "sha512":
+		return digestAlgorithm{"SHA-512", sha512.New}, true
+	}
+	return digestAlgorithm{}, false
+}
+
+// sum returns the base64-encoded digest of body.
+func (a digestAlgorithm) sum(body []byte) string {
+	h := a.new()
+	h.Write(body)
+	return base64.StdEncoding.EncodeToString(h.Sum(nil))
+}
+
+// ChecksumMiddleware returns middleware that verifies request bodies
+// against the checksum the client sent, rejecting mismatches with a 400
+// Bad Request before the handler runs. algorithm is one of "md5",
+// "sha256" or "sha512".
+//
+// The checksum is read from the Digest header (for example
+// "SHA-256=X48E9q...") or, for MD5, from Content-MD5. Requests with
+// neither header are passed through unchecked. The whole body is
+// buffered in memory to be verified and then handed to the handler.
+//
+// ChecksumMiddleware panics if algorithm is not supported.
+func ChecksumMiddleware(algorithm string) func(http.Handler) http.Handler {
+	digest, ok := lookupDigestAlgorithm(algorithm)
+	if !ok {
+		panic(fmt.Sprintf("sleepy: unsupported checksum algorithm %q", algorithm))
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(rw http.ResponseWriter, request *http.Request) {
+			expected, ok := requestChecksum(request, digest)
+			if !ok {
+				next.ServeHTTP(rw, request)
+				return
+			}
+
+			var body []byte
+			if request.Body != nil {
+				var err error
+				body, err = io.ReadAll(request.Body)
+				request.Body.Close()
+				if err != nil {
+					rw.WriteHeader(http.StatusBadRequest)
+					return
+				}
+			}
+			if digest.sum(body) != expected {
+				rw.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			request.Body = io.NopCloser(bytes.NewReader(body))
+			next.ServeHTTP(rw, request)
+		})
+	}
+}
+
+// requestChecksum returns the base64 checksum the client sent for the
+// given algorithm, if any.
+func requestChecksum(request *http.Request, digest digestAlgorithm) (string, bool) {
+	for _, header := range request.Header.Values("Digest") {
+		for _, instance := range strings.Split(header, ",") {
+			name, value, ok := strings.Cut(strings.TrimSpace(instance), "=")
+			if ok && strings.EqualFold(name, digest.name) {
+				return value, true
+			}
+		}
+	}
+	if digest.name == "MD5" {
+		if value := request.Header.Get("Content-MD5"); value != "" {
+			return value, true
+		}
+	}
+	return "", false
+}
+
+// AddBodyDigest adds a Digest header to rw holding the checksum of
+// body, the response body about to be written, so that clients can
+// verify it arrived intact. algorithm is one of those ChecksumMiddleware
+// accepts, and AddBodyDigest likewise panics if it is not supported. It
+// must be called before the response's WriteHeader.
+func AddBodyDigest(rw http.ResponseWriter, body []byte, algorithm string) {
+	digest, ok := lookupDigestAlgorithm(algorithm)
+	if !ok {
+		panic(fmt.Sprintf("sleepy: unsupported checksum algorithm %q", algorithm))
+	}
+	digest.addHeader(rw, body)
+}
+
+// addHeader adds the Digest header for body to rw.
+func (a digestAlgorithm) addHeader(rw http.ResponseWriter, body []byte) {
+	rw.Header().Add("Digest", a.name+"="+a.sum(body))
+}
+
+// SetResponseDigest makes the API send a Digest header with every
+// response body it encodes, as AddBodyDigest does, computed with
+// algorithm over the body as sent, after any compression. Streamed
+// responses have no digest, since it can't be known before they start.
+// Pass "" to stop sending digests. SetResponseDigest panics if
+// algorithm is not supported.
+func (api *API) SetResponseDigest(algorithm string) {
+	if algorithm == "" {
+		api.responseDigest = nil
+		return
+	}
+	digest, ok := lookupDigestAlgorithm(algorithm)
+	if !ok {
+		panic(fmt.Sprintf("sleepy: unsupported checksum algorithm %q", algorithm))
+	}
+	api.responseDigest = &digest
+}
@@ -0,0 +1,88 @@
+package sleepy
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestChecksumMiddleware(t *testing.T) {
+	const body = `{"name":"widget"}`
+	md5sum, _ := lookupDigestAlgorithm("md5")
+	sha256sum, _ := lookupDigestAlgorithm("sha256")
+	sha512sum, _ := lookupDigestAlgorithm("sha512")
+
+	cases := []struct {
+		algorithm, header, value string
+		code                     int
+	}{
+		{"md5", "Content-MD5", md5sum.sum([]byte(body)), http.StatusOK},
+		{"md5", "Digest", "MD5=" + md5sum.sum([]byte(body)), http.StatusOK},
+		{"sha256", "Digest", "SHA-256=" + sha256sum.sum([]byte(body)), http.StatusOK},
+		{"sha512", "Digest", "sha-256=abc, SHA-512=" + sha512sum.sum([]byte(body)), http.StatusOK},
+		{"sha256", "Digest", "SHA-256=" + sha256sum.sum([]byte("tampered")), http.StatusBadRequest},
+		{"md5", "Content-MD5", "bogus", http.StatusBadRequest},
+		{"sha256", "", "", http.StatusOK},
+	}
+	for _, c := range cases {
+		var seen string
+		handler := ChecksumMiddleware(c.algorithm)(http.HandlerFunc(func(rw http.ResponseWriter, request *http.Request) {
+			b, _ := io.ReadAll(request.Body)
+			seen = string(b)
+		}))
+
+		request := httptest.NewRequest("POST", "/upload", strings.NewReader(body))
+		if c.header != "" {
+			request.Header.Set(c.header, c.value)
+		}
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, request)
+
+		if recorder.Code != c.code {
+			t.Errorf("%s %s: got %d, want %d", c.algorithm, c.value, recorder.Code, c.code)
+		}
+		if c.code == http.StatusOK && seen != body {
+			t.Errorf("%s %s: handler saw %q", c.algorithm, c.value, seen)
+		}
+	}
+}
+
+func TestAddBodyDigest(t *testing.T) {
+	recorder := httptest.NewRecorder()
+	AddBodyDigest(recorder, []byte("hello"), "SHA-256")
+	if digest := recorder.Header().Get("Digest"); digest != "SHA-256=LPJNul+wow4m6DsqxbninhsWHlwfp0JecwQzYpOLmCQ=" {
+		t.Errorf("Digest = %q", digest)
+	}
+}
+
+func TestSetResponseDigest(t *testing.T) {
+	sha256sum, _ := lookupDigestAlgorithm("sha256")
+	api := NewAPI()
+	api.SetResponseDigest("sha256")
+	api.EnableGzip(1)
+	api.AddResource(new(Item), "/items")
+
+	for _, encoding := range []string{"", "gzip"} {
+		request := httptest.NewRequest("GET", "/items", nil)
+		if encoding != "" {
+			request.Header.Set("Accept-Encoding", encoding)
+		}
+		recorder := httptest.NewRecorder()
+		api.ServeHTTP(recorder, request)
+		if ce := recorder.Header().Get("Content-Encoding"); ce != encoding {
+			t.Errorf("%q: Content-Encoding = %q", encoding, ce)
+		}
+		if digest, want := recorder.Header().Get("Digest"), "SHA-256="+sha256sum.sum(recorder.Body.Bytes()); digest != want {
+			t.Errorf("%q: Digest = %q, want %q", encoding, digest, want)
+		}
+	}
+
+	api.SetResponseDigest("")
+	recorder := httptest.NewRecorder()
+	api.ServeHTTP(recorder, httptest.NewRequest("GET", "/items", nil))
+	if digest := recorder.Header().Get("Digest"); digest != "" {
+		t.Errorf("Digest sent after being turned off: %q", digest)
+	}
+}
@@ -0,0 +1,163 @@
+package sleepy
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// StreamingSupported is the interface that provides the GetStream
+// method a resource implements, in place of Get, to write a large
+// response, such as CSV rows or log lines, a piece at a time instead of
+// returning it whole. GetStream writes to w and calls w.Flush whenever
+// what it has written should reach the client.
+//
+// If GetStream returns an error before writing anything, the error is
+// answered as if a method had returned (0, err, nil). Once the response
+// has begun, an error can only end it early.
+type StreamingSupported interface {
+	GetStream(ctx context.Context, values url.Values, header http.Header, w *ChunkedWriter) error
+}
+
+// A ChunkedWriter is the response a StreamingSupported resource writes
+// to. The status code and headers are sent with the first Write or
+// Flush; until then they can be changed with WriteHeader and Header.
+// The status defaults to 200.
+type ChunkedWriter struct {
+	rw      http.ResponseWriter
+	w       io.Writer
+	head    bool
+	code    int
+	started bool
+
+	// mu serializes writes with the pings of a keep-alive, which are
+	// sent every keepAlive of quiet after lastWrite until pinging stops.
+	mu        sync.Mutex
+	keepAlive time.Duration
+	lastWrite time.Time
+	pinging   sync.WaitGroup
+	stop      chan struct{}
+}
+
+// Header returns the headers that will be sent with the response.
+func (c *ChunkedWriter) Header() http.Header {
+	return c.rw.Header()
+}
+
+// WriteHeader sets the status code of the response. It has no effect
+// once the response has begun.
+func (c *ChunkedWriter) WriteHeader(code int) {
+	if !c.started {
+		c.code = code
+	}
+}
+
+// Write writes p to the response, beginning it if need be. Writes are
+// buffered until the next Flush.
+func (c *ChunkedWriter) Write(p []byte) (int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.start()
+	c.lastWrite = time.Now()
+	if c.head {
+		return len(p), nil
+	}
+	return c.w.Write(p)
+}
+
+// Flush sends what has been written so far to the client.
+func (c *ChunkedWriter) Flush() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.start()
+	return http.NewResponseController(c.rw).Flush()
+}
+
+// SetWriteDeadline sets the time by which the rest of the response must
+// be written, in place of the server's write timeout, so that a stream
+// can push its deadline back as it goes. The zero time means no
+// deadline. It returns an error if the connection doesn't support
+// deadlines.
+func (c *ChunkedWriter) SetWriteDeadline(deadline time.Time) error {
+	return http.NewResponseController(c.rw).SetWriteDeadline(deadline)
+}
+
+// start sends the status code and headers, if they have not been sent,
+// and begins the keep-alive pings of an event stream. c.mu must be
+// held.
+func (c *ChunkedWriter) start() {
+	if c.started {
+		return
+	}
+	c.started = true
+	c.rw.WriteHeader(c.code)
+	if c.keepAlive > 0 && !c.head && isEventStream(c.rw.Header().Get("Content-Type")) {
+		c.lastWrite = time.Now()
+		c.stop = make(chan struct{})
+		c.pinging.Add(1)
+		go c.ping(c.stop)
+	}
+}
+
+// finish stops the keep-alive pings, if any, and waits until none can
+// be written.
+func (c *ChunkedWriter) finish() {
+	c.mu.Lock()
+	stop := c.stop
+	c.stop = nil
+	c.mu.Unlock()
+	if stop != nil {
+		close(stop)
+		c.pinging.Wait()
+	}
+}
+
+// chunkedStream is response data that writes itself to a ChunkedWriter.
+type chunkedStream func(*ChunkedWriter) error
+
+// streamingHandler returns the handler for the GetStream method of
+// resource, or nil if resource has none or method is not GET.
+func streamingHandler(resource interface{}, method string) handlerFunc {
+	streamer, ok := resource.(StreamingSupported)
+	if !ok || method != GET {
+		return nil
+	}
+	return func(ctx context.Context, values url.Values, header http.Header) (int, interface{}, http.Header) {
+		return http.StatusOK, chunkedStream(func(w *ChunkedWriter) error {
+			return streamer.GetStream(ctx, values, header, w)
+		}), nil
+	}
+}
+
+// streamChunked runs stream against rw, sending header and code with
+// the first piece of the response.
+func (api *API) streamChunked(rw http.ResponseWriter, request *http.Request, code int, stream chunkedStream, header http.Header) {
+	addHeaders(rw, header)
+	writer := &ChunkedWriter{rw: rw, w: rw, head: request.Method == HEAD, code: code}
+	if api.maxResponseBytes > 0 {
+		writer.w = &cappedWriter{w: rw, limit: api.maxResponseBytes}
+	}
+	if interval, ok := GetContextValue(request.Context(), keepAliveKey).(time.Duration); ok {
+		writer.keepAlive = interval
+	}
+	err := stream(writer)
+	writer.finish()
+	switch {
+	case err != nil && !writer.started:
+		rw.Header().Del("Content-Type")
+		api.respond(rw, request, 0, err, nil)
+	case errors.Is(err, errResponseTooLarge):
+		logf("sleepy: stream cut off at the response limit of %d bytes", api.maxResponseBytes)
+	case err != nil:
+		logf("sleepy: %s %s: stream ended early: %v", request.Method, request.URL.Path, err)
+	default:
+		writer.mu.Lock()
+		writer.start()
+		writer.mu.Unlock()
+		writer.finish()
+	}
+}
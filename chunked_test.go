@@ -0,0 +1,82 @@
+package sleepy
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+type TableExport struct {
+	// next is received from before each row after the first is written.
+	next chan struct{}
+}
+
+func (table TableExport) GetStream(ctx context.Context, values url.Values, header http.Header, w *ChunkedWriter) error {
+	if values.Get("table") == "" {
+		return &StatusError{Code: 400, Err: errors.New("table is required")}
+	}
+	w.Header().Set("Content-Type", "text/csv")
+	fmt.Fprintln(w, "id,name")
+	for i := 1; i <= 3; i++ {
+		if i > 1 && table.next != nil {
+			<-table.next
+		}
+		fmt.Fprintf(w, "%d,row%d\n", i, i)
+		if err := w.Flush(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func TestChunkedStream(t *testing.T) {
+	table := TableExport{next: make(chan struct{})}
+	api := NewAPI()
+	api.AddResource(table, "/table")
+	server := httptest.NewServer(api)
+	defer server.Close()
+
+	response, err := http.Get(server.URL + "/table?table=users")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer response.Body.Close()
+	if response.StatusCode != 200 || response.Header.Get("Content-Type") != "text/csv" || response.ContentLength != -1 {
+		t.Errorf("got %d %q with length %d", response.StatusCode, response.Header.Get("Content-Type"), response.ContentLength)
+	}
+
+	// Each row is read before the next is written, so it must have been
+	// flushed.
+	reader := bufio.NewReader(response.Body)
+	for i, expected := range []string{"id,name\n", "1,row1\n", "2,row2\n", "3,row3\n"} {
+		if i > 1 {
+			table.next <- struct{}{}
+		}
+		if line, err := reader.ReadString('\n'); line != expected {
+			t.Fatalf("line %d: got %q, %v, want %q", i, line, err, expected)
+		}
+	}
+}
+
+func TestChunkedStreamError(t *testing.T) {
+	api := NewAPI()
+	api.SetJSONOptions("", true)
+	api.AddResource(TableExport{}, "/table")
+
+	recorder := httptest.NewRecorder()
+	api.ServeHTTP(recorder, httptest.NewRequest("GET", "/table", nil))
+	if recorder.Code != 400 || recorder.Body.String() != `{"error":"table is required"}` {
+		t.Errorf("got %d %q", recorder.Code, recorder.Body.String())
+	}
+
+	recorder = httptest.NewRecorder()
+	api.ServeHTTP(recorder, httptest.NewRequest("HEAD", "/table?table=users", nil))
+	if recorder.Code != 200 || recorder.Body.Len() != 0 || recorder.Header().Get("Content-Type") != "text/csv" {
+		t.Errorf("HEAD: got %d %q", recorder.Code, recorder.Body.String())
+	}
+}
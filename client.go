@@ -0,0 +1,54 @@
+package sleepy
+
+import (
+	"context"
+	"io"
+	"net/http"
+)
+
+// ClientFromContext returns an HTTP client for calls a resource makes to
+// other services on behalf of the request ctx belongs to, such as the
+// context passed to a context-aware method. Each request the client
+// makes is cancelled when ctx is, including when ctx's deadline passes,
+// so a request that times out or whose client goes away doesn't leave
+// calls running downstream. A request's own context still applies too.
+func ClientFromContext(ctx context.Context) *http.Client {
+	return &http.Client{Transport: contextTransport{ctx: ctx, next: http.DefaultTransport}}
+}
+
+// contextTransport cancels the requests it sends when ctx is done.
+type contextTransport struct {
+	ctx  context.Context
+	next http.RoundTripper
+}
+
+func (t contextTransport) RoundTrip(request *http.Request) (*http.Response, error) {
+	ctx, cancel := context.WithCancel(request.Context())
+	stop := context.AfterFunc(t.ctx, cancel)
+	release := func() {
+		stop()
+		cancel()
+	}
+
+	response, err := t.next.RoundTrip(request.WithContext(ctx))
+	if err != nil {
+		release()
+		return nil, err
+	}
+	// The body is still read under ctx, so the cancellation can only be
+	// released once it is closed.
+	response.Body = &releasingBody{ReadCloser: response.Body, release: release}
+	return response, nil
+}
+
+// releasingBody calls release once the body is closed.
+type releasingBody struct {
+	io.ReadCloser
+	release func()
+}
+
+func (body *releasingBody) Close() error {
+	err := body.ReadCloser.Close()
+	body.release()
+	return err
+}
@@ -0,0 +1,103 @@
+package sleepy
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+)
+
+// Fanout calls the downstream service at its URL and relays what it
+// says.
+type Fanout struct {
+	URL string
+}
+
+func (fanout Fanout) GetContext(ctx context.Context, values url.Values, headers http.Header) (int, interface{}, http.Header) {
+	request, _ := http.NewRequest("GET", fanout.URL, nil)
+	response, err := ClientFromContext(ctx).Do(request)
+	if err != nil {
+		return http.StatusBadGateway, map[string]string{"error": err.Error()}, nil
+	}
+	defer response.Body.Close()
+	body, err := io.ReadAll(response.Body)
+	if err != nil {
+		return http.StatusBadGateway, map[string]string{"error": err.Error()}, nil
+	}
+	return response.StatusCode, string(body), nil
+}
+
+func TestClientFromContextCancelled(t *testing.T) {
+	cancelled := make(chan struct{})
+	downstream := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, request *http.Request) {
+		select {
+		case <-request.Context().Done():
+			close(cancelled)
+		case <-time.After(5 * time.Second):
+		}
+	}))
+	defer downstream.Close()
+
+	api := NewAPI()
+	api.AddResource(Fanout{URL: downstream.URL}, "/fanout")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan *httptest.ResponseRecorder)
+	go func() {
+		recorder := httptest.NewRecorder()
+		api.ServeHTTP(recorder, httptest.NewRequest("GET", "/fanout", nil).WithContext(ctx))
+		done <- recorder
+	}()
+	time.Sleep(50 * time.Millisecond)
+	cancel()
+
+	select {
+	case <-cancelled:
+	case <-time.After(2 * time.Second):
+		t.Fatal("the downstream call was not cancelled")
+	}
+	// The client has gone, so the 502 is not written.
+	if recorder := <-done; recorder.Body.Len() != 0 || recorder.Header().Get("Content-Type") != "" {
+		t.Errorf("wrote %q to a disconnected client", recorder.Body.String())
+	}
+}
+
+func TestClientFromContextDeadline(t *testing.T) {
+	downstream := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, request *http.Request) {
+		select {
+		case <-request.Context().Done():
+		case <-time.After(5 * time.Second):
+		}
+	}))
+	defer downstream.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	request, _ := http.NewRequest("GET", downstream.URL, nil)
+	start := time.Now()
+	if _, err := ClientFromContext(ctx).Do(request); err == nil {
+		t.Fatal("the call outlived the deadline")
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("the call took %v", elapsed)
+	}
+}
+
+func TestClientFromContextBody(t *testing.T) {
+	downstream := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, request *http.Request) {
+		rw.Write([]byte("hello"))
+	}))
+	defer downstream.Close()
+
+	api := NewAPI()
+	api.AddResource(Fanout{URL: downstream.URL}, "/fanout")
+	recorder := httptest.NewRecorder()
+	api.ServeHTTP(recorder, httptest.NewRequest("GET", "/fanout", nil))
+	// The body outlives the round trip, so it must still be readable.
+	if recorder.Code != 200 || recorder.Body.String() != `"hello"` {
+		t.Errorf("got %d %s, want 200 \"hello\"", recorder.Code, recorder.Body.String())
+	}
+}
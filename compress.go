@@ -0,0 +1,115 @@
+package sleepy
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// EnableGzip makes the API gzip response bodies of at least minSize
+// bytes, measured after encoding, for clients that accept gzip. Smaller
+// bodies are sent as they are, since compressing them costs more than
+// it saves. A minSize of zero or less disables compression.
+func (api *API) EnableGzip(minSize int) {
+	api.gzipMinSize = minSize
+}
+
+// SetBrotliCompressor lets the API compress responses with Brotli, using
+// writers made by newWriter, which the standard library cannot provide.
+// Once it is set, a body that EnableGzip would compress is sent as br
+// instead of gzip to clients that accept br at least as much; a client
+// that weights gzip higher still gets gzip. With the
+// github.com/andybalholm/brotli package:
+//
+//	api.SetBrotliCompressor(func(w io.Writer) io.WriteCloser { return brotli.NewWriter(w) })
+func (api *API) SetBrotliCompressor(newWriter func(io.Writer) io.WriteCloser) {
+	api.brotli = newWriter
+}
+
+// compress returns the body to send for content, compressing it and
+// setting Content-Encoding when the API and the client allow it.
+func (api *API) compress(rw http.ResponseWriter, request *http.Request, content []byte) []byte {
+	if api.gzipMinSize <= 0 {
+		return content
+	}
+	rw.Header().Add("Vary", "Accept-Encoding")
+	if len(content) < api.gzipMinSize {
+		return content
+	}
+
+	coding, q := "gzip", encodingQuality(request, "gzip")
+	newWriter := func(w io.Writer) io.WriteCloser { return gzip.NewWriter(w) }
+	if api.brotli != nil {
+		if brQ := encodingQuality(request, "br"); brQ > 0 && brQ >= q {
+			coding, q, newWriter = "br", brQ, api.brotli
+		}
+	}
+	if q <= 0 {
+		return content
+	}
+
+	var buf bytes.Buffer
+	writer := newWriter(&buf)
+	if _, err := writer.Write(content); err != nil {
+		return content
+	}
+	if err := writer.Close(); err != nil {
+		return content
+	}
+	rw.Header().Set("Content-Encoding", coding)
+	return buf.Bytes()
+}
+
+// encodingQuality returns the weight the request's Accept-Encoding
+// header gives the content coding, which is zero if it is not
+// acceptable.
+func encodingQuality(request *http.Request, coding string) float64 {
+	q, wildcard := -1.0, -1.0
+	for _, value := range parseQualityList(strings.Join(request.Header.Values("Accept-Encoding"), ",")) {
+		switch value.value {
+		case coding:
+			q = value.q
+		case "*":
+			wildcard = value.q
+		}
+	}
+	if q < 0 {
+		q = wildcard
+	}
+	if q < 0 {
+		return 0
+	}
+	return q
+}
+
+// gzipBody is a request body decompressed on the fly. Closing it closes
+// the original body too.
+type gzipBody struct {
+	*gzip.Reader
+	body io.ReadCloser
+}
+
+func (b gzipBody) Close() error {
+	b.Reader.Close()
+	return b.body.Close()
+}
+
+// decompressBody replaces a gzipped request body with its decompressed
+// form, so that it can be parsed like any other. It returns an error if
+// the body claims to be gzipped but is not.
+func decompressBody(request *http.Request) error {
+	coding := strings.ToLower(strings.TrimSpace(request.Header.Get("Content-Encoding")))
+	if coding != "gzip" && coding != "x-gzip" {
+		return nil
+	}
+	reader, err := gzip.NewReader(request.Body)
+	if err != nil {
+		return err
+	}
+	request.Body = gzipBody{reader, request.Body}
+	request.Header.Del("Content-Encoding")
+	request.ContentLength = -1
+	return nil
+}
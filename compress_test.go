@@ -0,0 +1,225 @@
+package sleepy
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// Blob returns a JSON string whose encoded length is the size query
+// parameter.
+type Blob struct{}
+
+func (blob Blob) Get(values url.Values, headers http.Header) (int, interface{}, http.Header) {
+	size, _ := strconv.Atoi(values.Get("size"))
+	return 200, strings.Repeat("a", size-2), nil
+}
+
+func TestGzipThreshold(t *testing.T) {
+	api := NewAPI()
+	api.AddResource(new(Blob), "/blob")
+	api.EnableGzip(1024)
+
+	get := func(size, acceptEncoding string) *httptest.ResponseRecorder {
+		request := httptest.NewRequest("GET", "/blob?size="+size, nil)
+		request.Header.Set("Accept-Encoding", acceptEncoding)
+		recorder := httptest.NewRecorder()
+		api.ServeHTTP(recorder, request)
+		return recorder
+	}
+
+	below := get("1023", "gzip")
+	if below.Header().Get("Content-Encoding") != "" || below.Body.Len() != 1023 {
+		t.Errorf("below threshold: Content-Encoding %q, %d bytes", below.Header().Get("Content-Encoding"), below.Body.Len())
+	}
+
+	above := get("1024", "gzip")
+	if above.Header().Get("Content-Encoding") != "gzip" {
+		t.Fatalf("above threshold: Content-Encoding %q", above.Header().Get("Content-Encoding"))
+	}
+	reader, err := gzip.NewReader(above.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(reader)
+	if len(body) != 1024 {
+		t.Errorf("above threshold: decompressed to %d bytes", len(body))
+	}
+
+	for _, acceptEncoding := range []string{"", "identity", "gzip;q=0", "*;q=0"} {
+		if got := get("2048", acceptEncoding).Header().Get("Content-Encoding"); got != "" {
+			t.Errorf("Accept-Encoding %q: Content-Encoding %q", acceptEncoding, got)
+		}
+	}
+	if got := get("2048", "br, *").Header().Get("Content-Encoding"); got != "gzip" {
+		t.Errorf("wildcard: Content-Encoding %q", got)
+	}
+}
+
+func TestGzippedRequestBody(t *testing.T) {
+	order := new(Order)
+	api := NewAPI()
+	api.AddResource(order, "/orders")
+	api.AddResource(new(Upload), "/uploads")
+
+	post := func(path, contentType string, body io.Reader) (int, string) {
+		request := httptest.NewRequest("POST", path, body)
+		request.Header.Set("Content-Type", contentType)
+		request.Header.Set("Content-Encoding", "gzip")
+		recorder := httptest.NewRecorder()
+		api.ServeHTTP(recorder, request)
+		return recorder.Code, recorder.Body.String()
+	}
+	gzipped := func(s string) io.Reader {
+		var buf strings.Builder
+		writer := gzip.NewWriter(&buf)
+		io.WriteString(writer, s)
+		writer.Close()
+		return strings.NewReader(buf.String())
+	}
+
+	if code, _ := post("/orders", "application/json", gzipped(`{"id": 7}`)); code != 200 || order.decoded["id"] != 7.0 {
+		t.Errorf("JSON: got %d with %v", code, order.decoded)
+	}
+	if code, body := post("/uploads", "application/x-www-form-urlencoded", gzipped("name=a.txt")); code != 201 || body != `"a.txt"` {
+		t.Errorf("form: got %d %q", code, body)
+	}
+	if code, _ := post("/orders", "application/json", strings.NewReader(`{"id": 7}`)); code != 400 {
+		t.Errorf("malformed gzip: got %d, want 400", code)
+	}
+}
+
+func TestHeadContentLength(t *testing.T) {
+	api := NewAPI()
+	api.AddResource(new(Blob), "/blob")
+	api.EnableGzip(1024)
+	server := httptest.NewServer(api)
+	defer server.Close()
+
+	for _, test := range []struct {
+		size, acceptEncoding, contentEncoding string
+	}{
+		{"100", "gzip", ""},
+		{"5000", "identity", ""},
+		{"5000", "gzip", "gzip"},
+	} {
+		lengths := map[string]string{}
+		for _, method := range []string{"GET", "HEAD"} {
+			request, _ := http.NewRequest(method, server.URL+"/blob?size="+test.size, nil)
+			request.Header.Set("Accept-Encoding", test.acceptEncoding)
+			response, err := http.DefaultTransport.RoundTrip(request)
+			if err != nil {
+				t.Fatal(err)
+			}
+			body, _ := io.ReadAll(response.Body)
+			response.Body.Close()
+			if encoding := response.Header.Get("Content-Encoding"); encoding != test.contentEncoding {
+				t.Errorf("%s of %s bytes: Content-Encoding %q, want %q", method, test.size, encoding, test.contentEncoding)
+			}
+			if length := response.Header.Get("Content-Length"); method == "GET" && length != "" && length != strconv.Itoa(len(body)) {
+				t.Errorf("GET of %s bytes: Content-Length %q for a body of %d", test.size, length, len(body))
+			}
+			if method == "HEAD" && len(body) != 0 {
+				t.Errorf("HEAD of %s bytes: got a body of %d", test.size, len(body))
+			}
+			lengths[method] = strconv.Itoa(len(body))
+			if method == "HEAD" {
+				lengths[method] = response.Header.Get("Content-Length")
+			}
+		}
+		if lengths["HEAD"] != lengths["GET"] {
+			t.Errorf("%s bytes, Accept-Encoding %q: HEAD Content-Length %q, GET body of %s", test.size, test.acceptEncoding, lengths["HEAD"], lengths["GET"])
+		}
+	}
+}
+
+// gzipResponseWriter compresses what is written to it, the way a
+// typical gzip wrapper from outside the package does.
+type gzipResponseWriter struct {
+	http.ResponseWriter
+	writer *gzip.Writer
+}
+
+func (rw gzipResponseWriter) Write(p []byte) (int, error) {
+	return rw.writer.Write(p)
+}
+
+func TestWrapperReencodesBody(t *testing.T) {
+	api := NewAPI()
+	api.AddResourceWithWrapper(new(Blob), func(handler http.HandlerFunc) http.HandlerFunc {
+		return func(rw http.ResponseWriter, request *http.Request) {
+			rw.Header().Set("Content-Encoding", "gzip")
+			writer := gzip.NewWriter(rw)
+			defer writer.Close()
+			handler(gzipResponseWriter{rw, writer}, request)
+		}
+	}, "/blob")
+	server := httptest.NewServer(api)
+	defer server.Close()
+
+	response, err := http.Get(server.URL + "/blob?size=5000")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer response.Body.Close()
+	body, err := io.ReadAll(response.Body)
+	if err != nil || response.StatusCode != 200 || len(body) != 5000 {
+		t.Errorf("got %d with %d bytes and %v, want 200 with 5000 bytes", response.StatusCode, len(body), err)
+	}
+}
+
+// fakeBrotli stands in for a Brotli writer, marking what it is given.
+type fakeBrotli struct {
+	io.Writer
+}
+
+func (b fakeBrotli) Write(p []byte) (int, error) {
+	return b.Writer.Write(append([]byte("br:"), p...))
+}
+
+func (b fakeBrotli) Close() error {
+	return nil
+}
+
+func TestBrotli(t *testing.T) {
+	api := NewAPI()
+	api.AddResource(new(Blob), "/blob")
+	api.EnableGzip(1024)
+
+	get := func(acceptEncoding string) *httptest.ResponseRecorder {
+		request := httptest.NewRequest("GET", "/blob?size=2048", nil)
+		request.Header.Set("Accept-Encoding", acceptEncoding)
+		recorder := httptest.NewRecorder()
+		api.ServeHTTP(recorder, request)
+		return recorder
+	}
+	if got := get("br, gzip").Header().Get("Content-Encoding"); got != "gzip" {
+		t.Errorf("no Brotli compressor: Content-Encoding %q, want gzip", got)
+	}
+
+	api.SetBrotliCompressor(func(w io.Writer) io.WriteCloser { return fakeBrotli{w} })
+	for _, test := range []struct {
+		acceptEncoding, contentEncoding string
+	}{
+		{"br, gzip", "br"},
+		{"gzip, br", "br"},
+		{"gzip", "gzip"},
+		{"br;q=0.5, gzip", "gzip"},
+		{"br, gzip;q=0.5", "br"},
+		{"*", "br"},
+		{"identity", ""},
+	} {
+		recorder := get(test.acceptEncoding)
+		if got := recorder.Header().Get("Content-Encoding"); got != test.contentEncoding {
+			t.Errorf("Accept-Encoding %q: Content-Encoding %q, want %q", test.acceptEncoding, got, test.contentEncoding)
+		}
+		if test.contentEncoding == "br" && !strings.HasPrefix(recorder.Body.String(), "br:") {
+			t.Errorf("Accept-Encoding %q: body was not Brotli compressed", test.acceptEncoding)
+		}
+	}
+}
@@ -0,0 +1,36 @@
+package sleepy
+
+import (
+	"net/http"
+	"time"
+)
+
+// concurrencyRetryAfter is how long clients turned away by the
+// concurrency limit are told to wait.
+const concurrencyRetryAfter = time.Second
+
+// SetMaxConcurrentRequests limits the API to serving n requests at
+// once. Requests beyond the limit are not queued but answered at once
+// with a 503 Service Unavailable and a Retry-After header. An n of zero
+// or less removes the limit.
+func (api *API) SetMaxConcurrentRequests(n int) {
+	if n <= 0 {
+		api.concurrency = nil
+		return
+	}
+	api.concurrency = make(chan struct{}, n)
+}
+
+func (api *API) concurrencyHandler(next http.Handler) http.Handler {
+	slots := api.concurrency
+	return http.HandlerFunc(func(rw http.ResponseWriter, request *http.Request) {
+		select {
+		case slots <- struct{}{}:
+		default:
+			WriteUnavailable(rw, concurrencyRetryAfter)
+			return
+		}
+		defer func() { <-slots }()
+		next.ServeHTTP(rw, request)
+	})
+}
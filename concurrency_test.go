@@ -0,0 +1,59 @@
+package sleepy
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestSetMaxConcurrentRequests(t *testing.T) {
+	slow := Slow{started: make(chan struct{}), release: make(chan struct{})}
+	api := NewAPI()
+	api.AddResource(slow, "/slow")
+	api.AddResource(new(Item), "/items")
+	api.SetMaxConcurrentRequests(2)
+
+	finished := make(chan int)
+	for i := 0; i < 2; i++ {
+		go func() {
+			recorder := httptest.NewRecorder()
+			api.ServeHTTP(recorder, httptest.NewRequest("GET", "/slow", nil))
+			finished <- recorder.Code
+		}()
+		<-slow.started
+	}
+
+	recorder := httptest.NewRecorder()
+	api.ServeHTTP(recorder, httptest.NewRequest("GET", "/items", nil))
+	if recorder.Code != http.StatusServiceUnavailable || recorder.Header().Get("Retry-After") == "" {
+		t.Errorf("over the limit: got %d with Retry-After %q", recorder.Code, recorder.Header().Get("Retry-After"))
+	}
+
+	close(slow.release)
+	for i := 0; i < 2; i++ {
+		if code := <-finished; code != 200 {
+			t.Errorf("request within the limit: got %d", code)
+		}
+	}
+
+	recorder = httptest.NewRecorder()
+	api.ServeHTTP(recorder, httptest.NewRequest("GET", "/items", nil))
+	if recorder.Code != 200 {
+		t.Errorf("after the slots were released: got %d", recorder.Code)
+	}
+}
+
+func TestConcurrencySlotReleasedOnPanic(t *testing.T) {
+	api := NewAPI()
+	api.AddResource(new(Broken), "/broken")
+	api.AddResource(new(Item), "/items")
+	api.SetMaxConcurrentRequests(1)
+
+	api.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/broken", nil))
+
+	recorder := httptest.NewRecorder()
+	api.ServeHTTP(recorder, httptest.NewRequest("GET", "/items", nil))
+	if recorder.Code != 200 {
+		t.Errorf("after a panic: got %d", recorder.Code)
+	}
+}
@@ -0,0 +1,46 @@
+package sleepy
+
+import (
+	"net/http"
+	"strings"
+)
+
+// ConditionalMiddleware returns middleware that runs mw only for the
+// requests predicate accepts, and passes the others straight to the
+// next handler. For example, to require authentication for the admin
+// pages alone:
+//
+//	api.Use(sleepy.ConditionalMiddleware(sleepy.PathHasPrefix("/admin"), auth))
+func ConditionalMiddleware(predicate func(*http.Request) bool, mw func(http.Handler) http.Handler) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		wrapped := mw(next)
+		return http.HandlerFunc(func(rw http.ResponseWriter, request *http.Request) {
+			if predicate(request) {
+				wrapped.ServeHTTP(rw, request)
+				return
+			}
+			next.ServeHTTP(rw, request)
+		})
+	}
+}
+
+// PathHasPrefix returns a predicate for ConditionalMiddleware that
+// accepts requests whose URL path starts with prefix.
+func PathHasPrefix(prefix string) func(*http.Request) bool {
+	return func(request *http.Request) bool {
+		return strings.HasPrefix(request.URL.Path, prefix)
+	}
+}
+
+// MethodIs returns a predicate for ConditionalMiddleware that accepts
+// requests made with any of the given HTTP methods.
+func MethodIs(methods ...string) func(*http.Request) bool {
+	return func(request *http.Request) bool {
+		for _, method := range methods {
+			if request.Method == method {
+				return true
+			}
+		}
+		return false
+	}
+}
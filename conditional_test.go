@@ -0,0 +1,39 @@
+package sleepy
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestConditionalMiddleware(t *testing.T) {
+	deny := func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(rw http.ResponseWriter, request *http.Request) {
+			rw.WriteHeader(http.StatusForbidden)
+		})
+	}
+
+	api := NewAPI()
+	api.AddResource(new(Item), "/admin/items", "/items")
+	api.AddResource(new(Upload), "/uploads")
+	api.Use(
+		ConditionalMiddleware(PathHasPrefix("/admin"), deny),
+		ConditionalMiddleware(MethodIs(POST, PUT), deny),
+	)
+
+	for _, test := range []struct {
+		method, path string
+		code         int
+	}{
+		{"GET", "/admin/items", 403},
+		{"GET", "/items", 200},
+		{"POST", "/uploads", 403},
+		{"DELETE", "/uploads", 405},
+	} {
+		recorder := httptest.NewRecorder()
+		api.ServeHTTP(recorder, httptest.NewRequest(test.method, test.path, nil))
+		if recorder.Code != test.code {
+			t.Errorf("%s %s: got %d, want %d", test.method, test.path, recorder.Code, test.code)
+		}
+	}
+}
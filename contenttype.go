@@ -0,0 +1,66 @@
+package sleepy
+
+import (
+	"context"
+	"mime"
+	"net/http"
+	"net/url"
+)
+
+// maxMultipartMemory is how much of a multipart body is held in memory
+// before the files it carries are stored on disk.
+const maxMultipartMemory = 32 << 20
+
+// PostJSONSupported is the interface that provides the PostJSON method
+// a resource implements to handle HTTP POSTs whose body is JSON
+// differently from others. The body is left for it to read, with
+// DecodeJSON for example.
+type PostJSONSupported interface {
+	PostJSON(context.Context, url.Values, http.Header) (int, interface{}, http.Header)
+}
+
+// PostMultipartSupported is the interface that provides the
+// PostMultipart method a resource implements to handle HTTP POSTs whose
+// body is multipart/form-data. The form's fields are among the values
+// it is passed, and its files are in the MultipartForm of the request
+// RequestFromContext returns.
+type PostMultipartSupported interface {
+	PostMultipart(context.Context, url.Values, http.Header) (int, interface{}, http.Header)
+}
+
+// PostByContentType is implemented by resources that handle both JSON
+// and multipart POSTs with methods of their own. A resource may
+// implement either variant alone. A POST whose content type matches
+// neither reaches the resource's Post or PostContext, or gets a 415
+// Unsupported Media Type if it has neither.
+type PostByContentType interface {
+	PostJSONSupported
+	PostMultipartSupported
+}
+
+// byContentType returns a handler that dispatches POSTs to resource's
+// variant for their content type, falling back to handler, or nil if
+// resource has no variants.
+func byContentType(resource interface{}, handler handlerFunc) handlerFunc {
+	postJSON, hasJSON := resource.(PostJSONSupported)
+	postMultipart, hasMultipart := resource.(PostMultipartSupported)
+	if !hasJSON && !hasMultipart {
+		return nil
+	}
+	return func(ctx context.Context, values url.Values, header http.Header) (int, interface{}, http.Header) {
+		mediaType, _, _ := mime.ParseMediaType(header.Get("Content-Type"))
+		switch {
+		case hasJSON && mediaType == jsonContentType:
+			return postJSON.PostJSON(ctx, values, header)
+		case hasMultipart && mediaType == "multipart/form-data":
+			request := RequestFromContext(ctx)
+			if err := request.ParseMultipartForm(maxMultipartMemory); err != nil {
+				return BadRequest(err)
+			}
+			return postMultipart.PostMultipart(ctx, request.Form, header)
+		case handler != nil:
+			return handler(ctx, values, header)
+		}
+		return http.StatusUnsupportedMediaType, map[string]string{"error": "unsupported media type"}, nil
+	}
+}
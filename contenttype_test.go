@@ -0,0 +1,81 @@
+package sleepy
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+type Attachment struct{}
+
+func (attachment Attachment) PostJSON(ctx context.Context, values url.Values, headers http.Header) (int, interface{}, http.Header) {
+	var body map[string]string
+	if err := DecodeJSON(ctx, &body); err != nil {
+		return BadRequest(err)
+	}
+	return 200, "json " + body["name"], nil
+}
+
+func (attachment Attachment) PostMultipart(ctx context.Context, values url.Values, headers http.Header) (int, interface{}, http.Header) {
+	file, _, err := RequestFromContext(ctx).FormFile("file")
+	if err != nil {
+		return BadRequest(err)
+	}
+	defer file.Close()
+	content, _ := io.ReadAll(file)
+	return 200, "multipart " + values.Get("name") + " " + string(content), nil
+}
+
+func (attachment Attachment) Post(values url.Values, headers http.Header) (int, interface{}, http.Header) {
+	return 200, "form " + values.Get("name"), nil
+}
+
+type JSONOnly struct{}
+
+func (only JSONOnly) PostJSON(ctx context.Context, values url.Values, headers http.Header) (int, interface{}, http.Header) {
+	return 200, "json", nil
+}
+
+func TestPostByContentType(t *testing.T) {
+	api := NewAPI()
+	api.SetJSONOptions("", true)
+	api.AddResource(new(Attachment), "/attachments")
+	api.AddResource(new(JSONOnly), "/json")
+
+	var multipartBody bytes.Buffer
+	writer := multipart.NewWriter(&multipartBody)
+	writer.WriteField("name", "notes")
+	part, _ := writer.CreateFormFile("file", "notes.txt")
+	part.Write([]byte("hello"))
+	writer.Close()
+
+	for _, test := range []struct {
+		path, contentType, body string
+		code                    int
+		response                string
+	}{
+		{"/attachments", "application/json", `{"name":"notes"}`, 200, `"json notes"`},
+		{"/attachments", writer.FormDataContentType(), multipartBody.String(), 200, `"multipart notes hello"`},
+		{"/attachments", "application/x-www-form-urlencoded", "name=notes", 200, `"form notes"`},
+		{"/json", "application/json; charset=utf-8", `{}`, 200, `"json"`},
+		{"/json", "text/plain", "notes", 415, `{"error":"unsupported media type"}`},
+	} {
+		request := httptest.NewRequest("POST", test.path, strings.NewReader(test.body))
+		request.Header.Set("Content-Type", test.contentType)
+		recorder := httptest.NewRecorder()
+		api.ServeHTTP(recorder, request)
+		if recorder.Code != test.code || recorder.Body.String() != test.response {
+			t.Errorf("%s as %s: got %d %s, want %d %s", test.path, test.contentType, recorder.Code, recorder.Body.String(), test.code, test.response)
+		}
+	}
+
+	if methods := api.Routes()[1].Methods; len(methods) != 1 || methods[0] != POST {
+		t.Errorf("/json methods = %v, want [POST]", methods)
+	}
+}
@@ -0,0 +1,209 @@
+package sleepy
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// contextKey is the type of the keys this package stores in request
+// contexts. Keys of an unexported type cannot collide with keys from
+// other packages, and each key's name makes it readable when printed.
+type contextKey struct {
+	name string
+}
+
+func (key *contextKey) String() string {
+	return "sleepy context key " + key.name
+}
+
+// Keys for values that middleware commonly attaches to a request, for
+// use with SetContextValue and GetContextValue. Sharing them lets
+// independent middleware agree on where to find, say, the
+// authenticated user.
+var (
+	ContextKeyRequestID = &contextKey{"request-id"}
+	ContextKeyUser      = &contextKey{"user"}
+	ContextKeyClaims    = &contextKey{"claims"}
+)
+
+// Keys for values the API itself attaches to a request.
+var (
+	requestKey     = &contextKey{"request"}
+	subpathKey     = &contextKey{"subpath"}
+	realIPKey      = &contextKey{"real-ip"}
+	logFieldsKey   = &contextKey{"log-fields"}
+	apiKey         = &contextKey{"api"}
+	traceKey       = &contextKey{"trace"}
+	traceParentKey = &contextKey{"traceparent"}
+	trailersKey    = &contextKey{"trailers"}
+	timingsKey     = &contextKey{"server-timing"}
+	keepAliveKey   = &contextKey{"keep-alive"}
+	marshallerKey  = &contextKey{"marshaller"}
+	tenantKey      = &contextKey{"tenant"}
+	producesKey    = &contextKey{"produces"}
+	replayKey      = &contextKey{"body-replay"}
+	schemaKey      = &contextKey{"schema"}
+	quietKey       = &contextKey{"quiet"}
+)
+
+// SetContextValue returns a copy of ctx in which key is associated with
+// value.
+func SetContextValue(ctx context.Context, key *contextKey, value interface{}) context.Context {
+	return context.WithValue(ctx, key, value)
+}
+
+// GetContextValue returns the value associated with key in ctx, or nil
+// if there is none.
+func GetContextValue(ctx context.Context, key *contextKey) interface{} {
+	return ctx.Value(key)
+}
+
+// GetContextSupported is the interface that provides the GetContext
+// method a resource implements, in place of Get, to receive HTTP GETs
+// along with the request's context.
+type GetContextSupported interface {
+	GetContext(context.Context, url.Values, http.Header) (int, interface{}, http.Header)
+}
+
+// PostContextSupported is the interface that provides the PostContext
+// method a resource implements, in place of Post, to receive HTTP POSTs
+// along with the request's context.
+type PostContextSupported interface {
+	PostContext(context.Context, url.Values, http.Header) (int, interface{}, http.Header)
+}
+
+// PutContextSupported is the interface that provides the PutContext
+// method a resource implements, in place of Put, to receive HTTP PUTs
+// along with the request's context.
+type PutContextSupported interface {
+	PutContext(context.Context, url.Values, http.Header) (int, interface{}, http.Header)
+}
+
+// DeleteContextSupported is the interface that provides the
+// DeleteContext method a resource implements, in place of Delete, to
+// receive HTTP DELETEs along with the request's context.
+type DeleteContextSupported interface {
+	DeleteContext(context.Context, url.Values, http.Header) (int, interface{}, http.Header)
+}
+
+// HeadContextSupported is the interface that provides the HeadContext
+// method a resource implements, in place of Head, to receive HTTP HEADs
+// along with the request's context.
+type HeadContextSupported interface {
+	HeadContext(context.Context, url.Values, http.Header) (int, interface{}, http.Header)
+}
+
+// PatchContextSupported is the interface that provides the
+// PatchContext method a resource implements, in place of Patch, to
+// receive HTTP PATCHs along with the request's context.
+type PatchContextSupported interface {
+	PatchContext(context.Context, url.Values, http.Header) (int, interface{}, http.Header)
+}
+
+// contextMethodHandler returns the context-aware handler resource
+// provides for the given HTTP method, or nil if it has none.
+func contextMethodHandler(resource interface{}, method string) handlerFunc {
+	switch method {
+	case GET:
+		if resource, ok := resource.(GetContextSupported); ok {
+			return resource.GetContext
+		}
+	case POST:
+		if resource, ok := resource.(PostContextSupported); ok {
+			return resource.PostContext
+		}
+	case PUT:
+		if resource, ok := resource.(PutContextSupported); ok {
+			return resource.PutContext
+		}
+	case DELETE:
+		if resource, ok := resource.(DeleteContextSupported); ok {
+			return resource.DeleteContext
+		}
+	case HEAD:
+		if resource, ok := resource.(HeadContextSupported); ok {
+			return resource.HeadContext
+		}
+	case PATCH:
+		if resource, ok := resource.(PatchContextSupported); ok {
+			return resource.PatchContext
+		}
+	}
+	return nil
+}
+
+// RequestFromContext returns the request being served, from the context
+// passed to a context-aware resource method. It returns nil for any
+// other context.
+func RequestFromContext(ctx context.Context) *http.Request {
+	request, _ := GetContextValue(ctx, requestKey).(*http.Request)
+	return request
+}
+
+// AddDefaultResource adds resource as the default for every path under
+// prefix that no more specific registration matches. For example, with
+// the prefix "/api/", a request for "/api/foo" reaches resource unless
+// "/api/foo" itself has been registered. The part of the path after the
+// prefix is available to context-aware methods through Subpath, which
+// lets the resource answer with a structured 404 naming what was asked
+// for.
+func (api *API) AddDefaultResource(resource interface{}, prefix string) {
+	if !strings.HasSuffix(prefix, "/") {
+		prefix += "/"
+	}
+	api.register(prefix, resource, func(resource interface{}) http.Handler {
+		handler := api.requestHandler(resource)
+		return http.HandlerFunc(func(rw http.ResponseWriter, request *http.Request) {
+			subpath := strings.TrimPrefix(request.URL.Path, prefix)
+			handler.ServeHTTP(rw, request.WithContext(SetContextValue(request.Context(), subpathKey, subpath)))
+		})
+	})
+}
+
+// SetFallback makes resource the catch-all for requests whose path no
+// registration matches, in place of the standard library's plain-text
+// 404 page, for custom not-found responses, proxying or routing of its
+// own. It is AddDefaultResource with the prefix "/", so Subpath gives
+// the requested path without its leading slash. Calling SetFallback
+// again replaces the fallback.
+func (api *API) SetFallback(resource interface{}) {
+	if api.hasFallback {
+		api.ReplaceResource("/", resource)
+		return
+	}
+	api.hasFallback = true
+	api.AddDefaultResource(resource, "/")
+}
+
+// Subpath returns the part of the request path below the prefix of a
+// resource added with AddDefaultResource, from the context passed to
+// one of its context-aware methods.
+func Subpath(ctx context.Context) string {
+	subpath, _ := GetContextValue(ctx, subpathKey).(string)
+	return subpath
+}
+
+// Query returns the parameters from the URL's query string of the
+// request being served, from the context passed to a context-aware
+// resource method. Unlike the values the method is passed, which merge
+// the query with the form in the body, it holds nothing from the body.
+func Query(ctx context.Context) url.Values {
+	request := RequestFromContext(ctx)
+	if request == nil {
+		return nil
+	}
+	return request.URL.Query()
+}
+
+// PostForm returns the form fields from the body of the request being
+// served, without any from the URL's query string, from the context
+// passed to a context-aware resource method.
+func PostForm(ctx context.Context) url.Values {
+	request := RequestFromContext(ctx)
+	if request == nil {
+		return nil
+	}
+	return request.PostForm
+}
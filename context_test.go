@@ -0,0 +1,135 @@
+package sleepy
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+type Fallback struct{}
+
+func (fallback Fallback) GetContext(ctx context.Context, values url.Values, headers http.Header) (int, interface{}, http.Header) {
+	return 404, map[string]string{"error": "not found", "path": Subpath(ctx)}, nil
+}
+
+func TestAddDefaultResource(t *testing.T) {
+	api := NewAPI()
+	api.AddResource(new(Item), "/api/items")
+	api.AddDefaultResource(new(Fallback), "/api")
+
+	recorder := httptest.NewRecorder()
+	api.ServeHTTP(recorder, httptest.NewRequest("GET", "/api/foo", nil))
+	if recorder.Code != 404 || recorder.Body.String() != "{\n  \"error\": \"not found\",\n  \"path\": \"foo\"\n}" {
+		t.Errorf("default resource: got %d %q", recorder.Code, recorder.Body.String())
+	}
+
+	recorder = httptest.NewRecorder()
+	api.ServeHTTP(recorder, httptest.NewRequest("GET", "/api/items", nil))
+	if recorder.Code != 200 {
+		t.Errorf("specific resource: got %d", recorder.Code)
+	}
+
+	recorder = httptest.NewRecorder()
+	api.ServeHTTP(recorder, httptest.NewRequest("GET", "/elsewhere", nil))
+	if recorder.Code != 404 || recorder.Body.String() == "" {
+		t.Errorf("outside the prefix: got %d %q", recorder.Code, recorder.Body.String())
+	}
+}
+
+type Router struct{ name string }
+
+func (router Router) GetContext(ctx context.Context, values url.Values, headers http.Header) (int, interface{}, http.Header) {
+	return 404, map[string]string{"router": router.name, "path": Subpath(ctx)}, nil
+}
+
+func TestSetFallback(t *testing.T) {
+	api := NewAPI()
+	api.SetJSONOptions("", true)
+	api.AddResource(new(Item), "/items")
+	api.SetFallback(Router{"first"})
+
+	get := func(path string) (int, string) {
+		recorder := httptest.NewRecorder()
+		api.ServeHTTP(recorder, httptest.NewRequest("GET", path, nil))
+		return recorder.Code, recorder.Body.String()
+	}
+	if code, body := get("/missing/page"); code != 404 || body != `{"path":"missing/page","router":"first"}` {
+		t.Errorf("unmatched path: got %d %q", code, body)
+	}
+	if code, _ := get("/items"); code != 200 {
+		t.Errorf("registered path: got %d", code)
+	}
+
+	api.SetFallback(Router{"second"})
+	if code, body := get("/"); code != 404 || body != `{"path":"","router":"second"}` {
+		t.Errorf("replaced fallback: got %d %q", code, body)
+	}
+}
+
+type Echo struct{}
+
+func (echo Echo) GetContext(ctx context.Context, values url.Values, headers http.Header) (int, interface{}, http.Header) {
+	return 200, RequestFromContext(ctx).URL.Path, nil
+}
+
+func (echo Echo) Get(values url.Values, headers http.Header) (int, interface{}, http.Header) {
+	return 500, "the context-aware method should win", nil
+}
+
+func TestContextMethodPreferred(t *testing.T) {
+	api := NewAPI()
+	api.AddResource(new(Echo), "/echo")
+
+	recorder := httptest.NewRecorder()
+	api.ServeHTTP(recorder, httptest.NewRequest("GET", "/echo", nil))
+	if recorder.Code != 200 || recorder.Body.String() != `"/echo"` {
+		t.Errorf("got %d %q", recorder.Code, recorder.Body.String())
+	}
+}
+
+func TestContextValues(t *testing.T) {
+	ctx := SetContextValue(context.Background(), ContextKeyUser, "doug")
+	ctx = SetContextValue(ctx, ContextKeyRequestID, "abc")
+	ctx = context.WithValue(ctx, "user", "someone else")
+
+	if user := GetContextValue(ctx, ContextKeyUser); user != "doug" {
+		t.Errorf("user = %v", user)
+	}
+	if id := GetContextValue(ctx, ContextKeyRequestID); id != "abc" {
+		t.Errorf("request ID = %v", id)
+	}
+	if claims := GetContextValue(ctx, ContextKeyClaims); claims != nil {
+		t.Errorf("claims = %v, want nil", claims)
+	}
+}
+
+type Search struct{}
+
+func (search Search) PostContext(ctx context.Context, values url.Values, headers http.Header) (int, interface{}, http.Header) {
+	return 200, map[string][]string{
+		"merged": values["x"],
+		"query":  Query(ctx)["x"],
+		"form":   PostForm(ctx)["x"],
+	}, nil
+}
+
+func TestQueryAndPostForm(t *testing.T) {
+	api := NewAPI()
+	api.SetJSONOptions("", true)
+	api.AddResource(new(Search), "/search")
+
+	request := httptest.NewRequest("POST", "/search?x=1", strings.NewReader("x=2"))
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	recorder := httptest.NewRecorder()
+	api.ServeHTTP(recorder, request)
+
+	if body := recorder.Body.String(); body != `{"form":["2"],"merged":["2","1"],"query":["1"]}` {
+		t.Errorf("got %s", body)
+	}
+	if Query(context.Background()) != nil || PostForm(context.Background()) != nil {
+		t.Error("values outside a request")
+	}
+}
@@ -0,0 +1,30 @@
+package sleepy
+
+import "net/http"
+
+// A MultiCookieResponse is data a resource returns to set cookies along
+// with the response, such as the session and CSRF cookies of a login.
+// The response body is Data, encoded as usual, and each of Cookies is
+// sent in a Set-Cookie header of its own, as http.SetCookie would send
+// it; an invalid cookie is dropped.
+//
+//	return 200, sleepy.MultiCookieResponse{Data: user, Cookies: []http.Cookie{session, csrf}}, nil
+type MultiCookieResponse struct {
+	Data    interface{}
+	Cookies []http.Cookie
+}
+
+// headers returns header with a Set-Cookie header added for each of
+// the response's cookies.
+func (response MultiCookieResponse) headers(header http.Header) http.Header {
+	header = header.Clone()
+	if header == nil {
+		header = make(http.Header)
+	}
+	for i := range response.Cookies {
+		if cookie := response.Cookies[i].String(); cookie != "" {
+			header.Add("Set-Cookie", cookie)
+		}
+	}
+	return header
+}
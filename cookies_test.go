@@ -0,0 +1,37 @@
+package sleepy
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+type SignIn struct{}
+
+func (signIn SignIn) Post(values url.Values, headers http.Header) (int, interface{}, http.Header) {
+	return 200, MultiCookieResponse{
+		Data: map[string]string{"user": "doug"},
+		Cookies: []http.Cookie{
+			{Name: "session", Value: "abc", HttpOnly: true},
+			{Name: "csrf", Value: "xyz"},
+			{Name: "bad name", Value: "dropped"},
+		},
+	}, http.Header{"Set-Cookie": {"theme=dark"}}
+}
+
+func TestMultiCookieResponse(t *testing.T) {
+	api := NewAPI()
+	api.SetJSONOptions("", true)
+	api.AddResource(new(SignIn), "/sign-in")
+
+	recorder := httptest.NewRecorder()
+	api.ServeHTTP(recorder, httptest.NewRequest("POST", "/sign-in", nil))
+	if body := recorder.Body.String(); body != `{"user":"doug"}` {
+		t.Errorf("body = %q", body)
+	}
+	cookies := recorder.Result().Cookies()
+	if len(cookies) != 3 || cookies[0].Name != "theme" || cookies[1].Name != "session" || !cookies[1].HttpOnly || cookies[2].Value != "xyz" {
+		t.Errorf("cookies = %v", cookies)
+	}
+}
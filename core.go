@@ -1,11 +1,17 @@
 package sleepy
 
 import (
-	"encoding/json"
+	"context"
 	"errors"
 	"fmt"
+	"io"
 	"net/http"
 	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 )
 
 const (
@@ -53,6 +59,49 @@ type PatchSupported interface {
 	Patch(url.Values, http.Header) (int, interface{}, http.Header)
 }
 
+// RawHandler is the interface a resource implements to take full
+// control of a request, for example to upgrade the connection to a
+// websocket. ServeHTTP is called before any other dispatch; if it
+// returns true the resource has written the response itself and the
+// API does nothing further with the request.
+type RawHandler interface {
+	ServeHTTP(http.ResponseWriter, *http.Request) bool
+}
+
+// MethodNotAllowedSupported is the interface a resource implements to
+// choose its own response to a request for a method it does not
+// support, instead of the default empty 405. The Allow header is set
+// before MethodNotAllowed is called.
+type MethodNotAllowedSupported interface {
+	MethodNotAllowed(method string) (int, interface{})
+}
+
+// MethodNotAllowedHandlerSupported is the interface a resource
+// implements in place of MethodNotAllowedSupported when its response to
+// an unsupported method depends on the request, for example to point a
+// client at the path that now accepts it. The Allow header is set
+// before MethodNotAllowedHandler is called.
+type MethodNotAllowedHandlerSupported interface {
+	MethodNotAllowedHandler(method string, r *http.Request) (int, interface{})
+}
+
+// AnyMethodSupported is the interface a resource implements to handle
+// methods it has no specific interface for, including nonstandard ones
+// such as PURGE. Any is called with the request's method only when the
+// resource does not implement that method's own interface.
+type AnyMethodSupported interface {
+	Any(method string, values url.Values) (int, interface{})
+}
+
+// NotImplementedSupported is the marker interface a resource implements
+// to answer requests for methods it does not support yet with a 501 Not
+// Implemented, whose body is {"error": "not implemented"}, instead of a
+// 405 Method Not Allowed, telling clients the method is planned rather
+// than refused. NotImplemented is never called.
+type NotImplementedSupported interface {
+	NotImplemented()
+}
+
 // An API manages a group of resources by routing requests
 // to the correct method on a matching resource and marshalling
 // the returned data to JSON for the HTTP response.
@@ -60,71 +109,444 @@ type PatchSupported interface {
 // You can instantiate multiple APIs on separate ports. Each API
 // will manage its own set of resources.
 type API struct {
-	mux     *http.ServeMux
+	mux            *http.ServeMux
 	muxInitialized bool
+
+	routes        []route
+	hasFallback   bool
+	middleware    []func(http.Handler) http.Handler
+	serverOptions []ServerOption
+	server        *http.Server
+	serverMu      sync.Mutex
+	running       []*http.Server
+	serveErrors   chan error
+	mirror        http.Handler
+	mirrorSlots   chan struct{}
+	mirrorTimeout time.Duration
+	mirrorDropped atomic.Int64
+	auditBody     func(method, path string, body []byte)
+	probes        map[string]http.Handler
+
+	defaultTimeout    time.Duration
+	maxBodyBytes      int64
+	maxResponseBytes  int64
+	unsupportedStatus int
+	noAutoHEAD        bool
+	validateStatus    bool
+	readOnly          atomic.Bool
+	rateLimiter       *tokenBucket
+	concurrency       chan struct{}
+	idempotency       IdempotencyStore
+	idempotencyLocks  keyedMutex
+	idempotencyScope  func(*http.Request) string
+
+	jsonIndent     string
+	jsonEscapeHTML bool
+	jsonUseNumber  bool
+	fieldNames     FieldNamePolicy
+	omitEmpty      bool
+	transform      func(int, interface{}) interface{}
+	encoders       map[string]Encoder
+	encoderTypes   []string
+	defaultType    string
+	suffixEncoders map[string]Encoder
+	errorEncoder   ErrorEncoder
+	errorType      string
+	problemJSON    bool
+	deadLetter     func(*http.Request, int, interface{}, error)
+	gzipMinSize    int
+	responseDigest *digestAlgorithm
+	brotli         func(io.Writer) io.WriteCloser
+	bufferPooling  bool
+	protoMarshal   func(ProtoMessage) ([]byte, error)
+	protoUnmarshal func([]byte, ProtoMessage) error
+	errors         []registeredError
+	debug          bool
+
+	redactedHeaders map[string]bool
+
+	commonLog   io.Writer
+	commonLogMu sync.Mutex
+	logHook     func(LogEntry)
+	slowLog     func(LogEntry)
+	slowAfter   time.Duration
+	quietPaths  map[string]bool
+	onPanic     func(*http.Request, interface{}, []byte)
+
+	drainMu  sync.Mutex
+	draining bool
+	inFlight int
+	drained  chan struct{}
 }
 
 // NewAPI allocates and returns a new API.
 func NewAPI() *API {
-	return &API{}
+	return &API{
+		jsonIndent:     "  ",
+		jsonEscapeHTML: true,
+		validateStatus: true,
+	}
+}
+
+// SetUnsupportedMethodStatus sets the status of the response to a
+// request for a method the resource does not support, which must be
+// 405 Method Not Allowed, the default, or 501 Not Implemented. It
+// panics for any other code. Resources that implement
+// MethodNotAllowedSupported or NotImplementedSupported still choose
+// their own response.
+func (api *API) SetUnsupportedMethodStatus(code int) {
+	if code != http.StatusMethodNotAllowed && code != http.StatusNotImplemented {
+		panic(fmt.Sprintf("sleepy: unsupported method status must be 405 or 501, not %d", code))
+	}
+	api.unsupportedStatus = code
+}
+
+// handlerFunc is the signature shared by every resource method once
+// it has been resolved for a request.
+type handlerFunc func(context.Context, url.Values, http.Header) (int, interface{}, http.Header)
+
+// methodSet maps HTTP methods directly to handlers. It stands in for a
+// resource when the handlers are not found through the method
+// interfaces, as with AddRPCResource.
+type methodSet map[string]handlerFunc
+
+// methodHandler returns the handler resource provides for the given
+// HTTP method, or nil if it does not support that method. A context-
+// aware method is preferred to its plain counterpart, and either to
+// Any. POSTs go to a variant for their content type if the resource
+// has one.
+func methodHandler(resource interface{}, method string) handlerFunc {
+	handler := interfaceHandler(resource, method)
+	if method == POST {
+		if dispatch := byContentType(resource, handler); dispatch != nil {
+			return dispatch
+		}
+	}
+	return handler
+}
+
+// interfaceHandler returns the handler resource provides for method
+// through the method interfaces, a methodSet or an Endpoint.
+func interfaceHandler(resource interface{}, method string) handlerFunc {
+	if endpoint, ok := resource.(Endpoint); ok {
+		resource = endpoint.set
+	}
+	if set, ok := resource.(methodSet); ok {
+		return set[method]
+	}
+	if handler := contextMethodHandler(resource, method); handler != nil {
+		return handler
+	}
+	if handler := streamingHandler(resource, method); handler != nil {
+		return handler
+	}
+
+	var handler func(url.Values, http.Header) (int, interface{}, http.Header)
+	switch method {
+	case GET:
+		if resource, ok := resource.(GetSupported); ok {
+			handler = resource.Get
+		}
+	case POST:
+		if resource, ok := resource.(PostSupported); ok {
+			handler = resource.Post
+		}
+	case PUT:
+		if resource, ok := resource.(PutSupported); ok {
+			handler = resource.Put
+		}
+	case DELETE:
+		if resource, ok := resource.(DeleteSupported); ok {
+			handler = resource.Delete
+		}
+	case HEAD:
+		if resource, ok := resource.(HeadSupported); ok {
+			handler = resource.Head
+		}
+	case PATCH:
+		if resource, ok := resource.(PatchSupported); ok {
+			handler = resource.Patch
+		}
+	}
+	if handler != nil {
+		return withoutContext(handler)
+	}
+	if resource, ok := resource.(AnyMethodSupported); ok {
+		return func(ctx context.Context, values url.Values, header http.Header) (int, interface{}, http.Header) {
+			code, data := resource.Any(method, values)
+			return code, data, nil
+		}
+	}
+	return nil
+}
+
+// withoutContext adapts a method that doesn't take a context.
+func withoutContext(handler func(url.Values, http.Header) (int, interface{}, http.Header)) handlerFunc {
+	return func(ctx context.Context, values url.Values, header http.Header) (int, interface{}, http.Header) {
+		return handler(values, header)
+	}
+}
+
+// resolvedMethods caches the handlers a resource provides, found on the
+// first request it serves, so that later requests skip the interface
+// checks.
+type resolvedMethods struct {
+	once     sync.Once
+	handlers map[string]handlerFunc
+	allow    string
+}
+
+// resolve finds resource's handlers, if that hasn't been done yet.
+func (resolved *resolvedMethods) resolve(resource interface{}) {
+	resolved.once.Do(func() {
+		resolved.handlers = make(map[string]handlerFunc, len(methods))
+		for _, method := range methods {
+			resolved.handlers[method] = methodHandler(resource, method)
+		}
+		resolved.allow = strings.Join(supportedMethods(resource), ", ")
+	})
+}
+
+// handler returns the handler resource provides for method, as
+// methodHandler does. A methodSet is looked up afresh each time, since
+// AddResourceFunc may still add to it.
+func (resolved *resolvedMethods) handler(resource interface{}, method string) handlerFunc {
+	if _, ok := resource.(methodSet); ok {
+		return methodHandler(resource, method)
+	}
+	resolved.resolve(resource)
+	if handler, ok := resolved.handlers[method]; ok {
+		return handler
+	}
+	return methodHandler(resource, method)
+}
+
+// allowed returns the value of the Allow header for resource.
+func (resolved *resolvedMethods) allowed(resource interface{}) string {
+	if _, ok := resource.(methodSet); ok {
+		return strings.Join(supportedMethods(resource), ", ")
+	}
+	resolved.resolve(resource)
+	return resolved.allow
 }
 
 func (api *API) requestHandler(resource interface{}) http.HandlerFunc {
+	resolved := new(resolvedMethods)
 	return func(rw http.ResponseWriter, request *http.Request) {
 
-		if request.ParseForm() != nil {
-			rw.WriteHeader(http.StatusBadRequest)
+		if raw, ok := resource.(RawHandler); ok && raw.ServeHTTP(rw, request) {
 			return
 		}
 
-		var handler func(url.Values, http.Header) (int, interface{}, http.Header)
-
-		switch request.Method {
-		case GET:
-			if resource, ok := resource.(GetSupported); ok {
-				handler = resource.Get
-			}
-		case POST:
-			if resource, ok := resource.(PostSupported); ok {
-				handler = resource.Post
-			}
-		case PUT:
-			if resource, ok := resource.(PutSupported); ok {
-				handler = resource.Put
+		handler := resolved.handler(resource, request.Method)
+		if handler == nil && request.Method == HEAD && api.autoHEAD(resource) {
+			// Answer as GET would, without the body.
+			handler = resolved.handler(resource, GET)
+		}
+		if handler == nil {
+			rw.Header().Set("Allow", resolved.allowed(resource))
+			if _, ok := resource.(NotImplementedSupported); ok {
+				api.respond(rw, request, http.StatusNotImplemented, map[string]string{"error": "not implemented"}, nil)
+				return
 			}
-		case DELETE:
-			if resource, ok := resource.(DeleteSupported); ok {
-				handler = resource.Delete
+			if resource, ok := resource.(MethodNotAllowedHandlerSupported); ok {
+				code, data := resource.MethodNotAllowedHandler(request.Method, request)
+				api.respond(rw, request, code, data, nil)
+				return
 			}
-		case HEAD:
-			if resource, ok := resource.(HeadSupported); ok {
-				handler = resource.Head
+			if resource, ok := resource.(MethodNotAllowedSupported); ok {
+				code, data := resource.MethodNotAllowed(request.Method)
+				api.respond(rw, request, code, data, nil)
+				return
 			}
-		case PATCH:
-			if resource, ok := resource.(PatchSupported); ok {
-				handler = resource.Patch
+			if api.unsupportedStatus != 0 {
+				rw.WriteHeader(api.unsupportedStatus)
+				return
 			}
+			rw.WriteHeader(http.StatusMethodNotAllowed)
+			return
 		}
 
-		if handler == nil {
-			rw.WriteHeader(http.StatusMethodNotAllowed)
+		request, ok := api.acceptable(rw, request, resource)
+		if !ok {
 			return
 		}
 
-		code, data, header := handler(request.Form, request.Header)
+		// The body is only read once a handler is known to exist, so a
+		// client that sent "Expect: 100-continue" is told to go ahead
+		// only when its upload will actually be used.
+		if !api.limitBody(rw, request) {
+			return
+		}
+		if decompressBody(request) != nil {
+			rw.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		// The limit applies again to the decompressed body, so that a
+		// small gzipped body cannot inflate without bound.
+		if !api.limitBody(rw, request) {
+			return
+		}
+		if !checkSchemas(rw, request) {
+			return
+		}
+		if err := bufferReplay(request); err != nil {
+			rw.WriteHeader(bodyErrorStatus(err))
+			return
+		}
+		if err := request.ParseForm(); err != nil {
+			rw.WriteHeader(bodyErrorStatus(err))
+			return
+		}
+		if body, ok := request.Body.(replayBody); ok {
+			body.rewind()
+		}
 
-		content, err := json.MarshalIndent(data, "", "  ")
-		if err != nil {
-			rw.WriteHeader(http.StatusInternalServerError)
+		ctx := SetContextValue(request.Context(), requestKey, request)
+		ctx = SetContextValue(ctx, apiKey, api)
+		trailers := new(trailers)
+		ctx = SetContextValue(ctx, trailersKey, trailers)
+		timings := new(serverTimings)
+		ctx = SetContextValue(ctx, timingsKey, timings)
+		code, data, header := handler(ctx, request.Form, request.Header)
+		if clientGone(request) {
 			return
 		}
-		for name, values := range header {
-			for _, value := range values {
-				rw.Header().Add(name, value)
-			}
+		if marshaller, ok := resource.(CustomMarshaller); ok {
+			request = request.WithContext(SetContextValue(request.Context(), marshallerKey, marshaller.Marshaller()))
+		}
+		timings.write(rw)
+		trailers.declare(rw)
+		api.respond(rw, request, code, data, header)
+		trailers.write(rw)
+	}
+}
+
+// respond writes a resource's return values to rw: the status code,
+// any headers, and data encoded for the content type negotiated with
+// the client. A channel of values is streamed as newline-delimited JSON
+// and a channel of SSEEvents as Server-Sent Events. An error with a
+// code of 0 is resolved by errorResponse, and nil data, or any data for
+// a status that cannot have a body, is sent as no body and no
+// Content-Type. Other data passes through the response transformer, if
+// there is one, before it is encoded. The response to a HEAD has the
+// headers a GET would, with the Content-Length of the body it leaves
+// out.
+func (api *API) respond(rw http.ResponseWriter, request *http.Request, code int, data interface{}, header http.Header) {
+	if err, ok := data.(error); ok {
+		if code == 0 {
+			code, data = api.errorResponse(err)
+		} else if code >= 400 && api.problemJSON {
+			data = errorProblem{Title: err.Error(), Status: code}
 		}
+	}
+	if retry, ok := data.(RetryAfter); ok && retry.Code != 0 {
+		code = retry.Code
+	}
+	if cookies, ok := data.(MultiCookieResponse); ok {
+		data, header = cookies.Data, cookies.headers(header)
+	}
+	if page, ok := data.(Page); ok {
+		data, header = page.Items, page.headers(header)
+	}
+	if !api.checkStatus(rw, request, code) {
+		return
+	}
+
+	switch stream := data.(type) {
+	case <-chan interface{}:
+		api.streamNDJSON(rw, code, stream, header)
+		return
+	case chan interface{}:
+		api.streamNDJSON(rw, code, stream, header)
+		return
+	case <-chan SSEEvent:
+		api.streamSSE(rw, request, code, stream, header)
+		return
+	case chan SSEEvent:
+		api.streamSSE(rw, request, code, stream, header)
+		return
+	case chunkedStream:
+		api.streamChunked(rw, request, code, stream, header)
+		return
+	case RetryAfter:
+		addHeaders(rw, header)
+		writeRetryAfter(rw, code, stream.After)
+		return
+	}
+
+	if !bodyAllowed(code) {
+		addHeaders(rw, header)
+		rw.WriteHeader(code)
+		return
+	}
+	// Nil data means no body at all, rather than a JSON null, unless an
+	// error encoder has something to say about the status.
+	if data == nil && (code < 400 || api.errorEncoder == nil) {
+		addHeaders(rw, header)
+		rw.Header().Set("Content-Length", "0")
 		rw.WriteHeader(code)
-		rw.Write(content)
+		return
+	}
+
+	if _, ok := data.(plainText); !ok && api.transform != nil {
+		data = api.transform(code, data)
+	}
+	content := api.getBuffer()
+	defer api.putBuffer(content)
+	contentType, err := api.encode(content, request, code, data)
+	if err != nil {
+		if api.deadLetter != nil {
+			api.deadLetter(request, code, data, err)
+		}
+		rw.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	if api.maxResponseBytes > 0 && int64(content.Len()) > api.maxResponseBytes {
+		logf("sleepy: %s %s: response body of %d bytes exceeds the limit of %d", request.Method, request.URL.Path, content.Len(), api.maxResponseBytes)
+		rw.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	addHeaders(rw, header)
+	if rw.Header().Get("Content-Type") == "" {
+		rw.Header().Set("Content-Type", contentType)
+	}
+	if len(api.offeredTypes(data)) > 1 || api.suffixEncoder(contentType) != nil || resourceProduces(request.Context()) != nil {
+		rw.Header().Add("Vary", "Accept")
+	}
+	body := api.compress(rw, request, content.Bytes())
+	if api.responseDigest != nil {
+		api.responseDigest.addHeader(rw, body)
+	}
+	// Only a HEAD needs to be told the length, since it has no body to
+	// measure. Otherwise net/http works it out from what is written,
+	// which may not be body if a wrapper re-encodes the response. A
+	// response with trailers must be chunked, so it has no length.
+	if request.Method == HEAD {
+		if len(rw.Header().Values("Trailer")) == 0 {
+			rw.Header().Set("Content-Length", strconv.Itoa(len(body)))
+		}
+		rw.WriteHeader(code)
+		return
+	}
+	rw.WriteHeader(code)
+	rw.Write(body)
+}
+
+// bodyAllowed reports whether a response with the given status code
+// may have a body.
+func bodyAllowed(code int) bool {
+	return code != http.StatusNoContent && code != http.StatusNotModified && (code < 100 || code >= 200)
+}
+
+// addHeaders adds every value in header to the response headers. They
+// are added rather than set, so that headers which may repeat, such as
+// Set-Cookie, keep every value.
+func addHeaders(rw http.ResponseWriter, header http.Header) {
+	for name, values := range header {
+		for _, value := range values {
+			rw.Header().Add(name, value)
+		}
 	}
 }
 
@@ -142,11 +564,38 @@ func (api *API) Mux() *http.ServeMux {
 
 // AddResource adds a new resource to an API. The API will route
 // requests that match one of the given paths to the matching HTTP
-// method on the resource.
+// method on the resource. A resource that implements Initializer is
+// initialized first; if Init fails, AddResource panics, the way a path
+// registered twice does. Use AddResourceErr for resources whose setup
+// may fail at run time.
 func (api *API) AddResource(resource interface{}, paths ...string) {
+	if err := api.addResource(resource, paths...); err != nil {
+		panic(err)
+	}
+}
+
+// addResource initializes resource and adds it at paths, or returns the
+// error from its Init method.
+func (api *API) addResource(resource interface{}, paths ...string) error {
 	for _, path := range paths {
-		api.Mux().HandleFunc(path, api.requestHandler(resource))
+		if err := api.registerRoute(route{path: path, resource: resource}, api.resourceHandler); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// AddResourceErr calls factory to construct a resource and adds the
+// result at path. If factory or the resource's Init method fails,
+// nothing is registered and the error is returned, so resources whose
+// setup can fail are caught at startup rather than on their first
+// request.
+func (api *API) AddResourceErr(factory func() (interface{}, error), path string) error {
+	resource, err := factory()
+	if err != nil {
+		return err
 	}
+	return api.addResource(resource, path)
 }
 
 // AddResourceWithWrapper behaves exactly like AddResource but wraps
@@ -154,15 +603,144 @@ func (api *API) AddResource(resource interface{}, paths ...string) {
 // to hook in Gzip support and similar.
 func (api *API) AddResourceWithWrapper(resource interface{}, wrapper func(handler http.HandlerFunc) http.HandlerFunc, paths ...string) {
 	for _, path := range paths {
-		api.Mux().HandleFunc(path, wrapper(api.requestHandler(resource)))
+		api.register(path, resource, func(resource interface{}) http.Handler {
+			return wrapper(api.requestHandler(resource))
+		})
+	}
+}
+
+// AddHandler routes requests for path to handler, an ordinary
+// http.Handler, so that existing handlers can be served alongside
+// resources. Requests to it pass through the API's middleware and
+// hooks like any other.
+func (api *API) AddHandler(path string, handler http.Handler) {
+	api.register(path, handler, func(resource interface{}) http.Handler {
+		if handler, ok := resource.(http.Handler); ok {
+			return handler
+		}
+		return api.requestHandler(resource)
+	})
+}
+
+// resourceHandler returns the handler that serves resource the way
+// AddResource does.
+func (api *API) resourceHandler(resource interface{}) http.Handler {
+	return api.requestHandler(resource)
+}
+
+// register routes path to the handler build makes for resource and
+// records that resource is served there. It panics if the resource's
+// Init method fails.
+func (api *API) register(path string, resource interface{}, build func(interface{}) http.Handler) {
+	api.mustRegisterRoute(route{path: path, resource: resource}, build)
+}
+
+// mustRegisterRoute is registerRoute for the registration methods that
+// do not return an error: it panics if the resource's Init method fails.
+func (api *API) mustRegisterRoute(r route, build func(interface{}) http.Handler) {
+	if err := api.registerRoute(r, build); err != nil {
+		panic(err)
+	}
+}
+
+// registerRoute routes r.path to the handler build makes for
+// r.resource, wrapped with the per-route behaviour r asks for, and
+// records r. build is kept so that ReplaceResource can serve a new
+// resource the same way. Every resource is initialized here, before it
+// is first served, so that each one teardown closes has been through
+// Init; if Init fails, nothing is registered and the error is returned.
+func (api *API) registerRoute(r route, build func(interface{}) http.Handler) error {
+	if err := api.initResource(r.resource); err != nil {
+		return fmt.Errorf("sleepy: initializing the resource at %s: %w", r.path, err)
+	}
+	r.build = build
+	r.target = new(atomic.Pointer[routeTarget])
+	r.target.Store(&routeTarget{resource: r.resource, handler: build(r.resource)})
+	handler := http.HandlerFunc(func(rw http.ResponseWriter, request *http.Request) {
+		if r.quiet || api.quietPaths[r.path] {
+			markQuiet(request)
+		}
+		r.target.Load().handler.ServeHTTP(rw, request)
+	})
+	api.Mux().Handle(r.path, api.timeoutHandler(withRouteMiddleware(r, handler), r.timeout))
+	api.routes = append(api.routes, r)
+	return nil
+}
+
+// ServeHTTP dispatches the request to the resource registered for
+// its path, applying any API-wide hooks such as access logging.
+func (api *API) ServeHTTP(rw http.ResponseWriter, request *http.Request) {
+	if probe, ok := api.probes[request.URL.Path]; ok {
+		probe.ServeHTTP(rw, request)
+		return
+	}
+
+	rw = wrapResponseWriter(rw)
+	request = request.WithContext(SetContextValue(request.Context(), apiKey, api))
+	if _, ok := GetContextValue(request.Context(), quietKey).(*atomic.Bool); !ok {
+		// A mounted API shares the flag of the API it is mounted on.
+		request = request.WithContext(SetContextValue(request.Context(), quietKey, new(atomic.Bool)))
+	}
+	handler := api.recoverHandler(chain(api.Mux(), api.middleware))
+	if api.idempotency != nil {
+		handler = api.idempotencyHandler(handler)
+	}
+	if api.concurrency != nil {
+		handler = api.concurrencyHandler(handler)
+	}
+	if api.rateLimiter != nil {
+		handler = api.rateLimitHandler(handler)
+	}
+	handler = api.readOnlyHandler(handler)
+	handler = api.drainHandler(handler)
+	if api.mirror != nil {
+		handler = api.mirrorHandler(handler)
+	}
+	if api.auditBody != nil {
+		handler = api.auditBodyHandler(handler)
 	}
+	if api.commonLog != nil {
+		handler = api.commonLogHandler(handler)
+	}
+	if api.logHook != nil || api.slowLog != nil {
+		handler = api.logHookHandler(handler)
+	}
+	if api.debug {
+		handler = api.headerLogHandler(handler)
+	}
+	handler.ServeHTTP(rw, request)
+}
+
+// Use appends middleware to the chain that wraps every request the
+// API serves, including requests for resources added later. The first
+// middleware given is the outermost.
+func (api *API) Use(middleware ...func(http.Handler) http.Handler) {
+	api.middleware = append(api.middleware, middleware...)
 }
 
-// Start causes the API to begin serving requests on the given port.
+// Start causes the API to begin serving requests on the given port. If
+// the port cannot be listened on, the error says so and wraps the
+// underlying *net.OpError.
 func (api *API) Start(port int) error {
+	return api.StartOn(fmt.Sprintf(":%d", port))
+}
+
+// StartOn causes the API to begin serving requests on addr, which has
+// the form "host:port" as for net.Listen. Resources that implement
+// Startable are started first; if one fails, its error is returned and
+// the API does not listen at all.
+func (api *API) StartOn(addr string) error {
 	if !api.muxInitialized {
 		return errors.New("You must add at least one resource to this API.")
 	}
-	portString := fmt.Sprintf(":%d", port)
-	return http.ListenAndServe(portString, api.Mux())
+	if err := api.startResources(context.Background()); err != nil {
+		return err
+	}
+	server := api.newServer(addr)
+	api.track(server)
+	listener, err := listen(server.Addr)
+	if err != nil {
+		return api.serveError(err)
+	}
+	return api.serveError(server.Serve(listener))
 }
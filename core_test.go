@@ -1,10 +1,19 @@
 package sleepy
 
 import (
+	"bufio"
+	"encoding/json"
+	"errors"
 	"io/ioutil"
+	"net"
 	"net/http"
+	"net/http/httptest"
 	"net/url"
+	"runtime"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 )
 
 type Item struct{}
@@ -22,6 +31,7 @@ func TestBasicGet(t *testing.T) {
 	var api = NewAPI()
 	api.AddResource(item, "/items", "/bar", "/baz")
 	go api.Start(3000)
+	waitForListener(t, "localhost:3000")
 	resp, err := http.Get("http://localhost:3000/items")
 	if err != nil {
 		t.Error(err)
@@ -31,3 +41,494 @@ func TestBasicGet(t *testing.T) {
 		t.Error("Not equal.")
 	}
 }
+
+type Socket struct{ Item }
+
+func (socket Socket) ServeHTTP(rw http.ResponseWriter, request *http.Request) bool {
+	if request.Header.Get("Upgrade") != "websocket" {
+		return false
+	}
+	rw.WriteHeader(http.StatusSwitchingProtocols)
+	rw.Write([]byte("upgraded"))
+	return true
+}
+
+func TestRawHandler(t *testing.T) {
+	api := NewAPI()
+	api.AddResource(new(Socket), "/socket")
+
+	request := httptest.NewRequest("GET", "/socket", nil)
+	request.Header.Set("Upgrade", "websocket")
+	recorder := httptest.NewRecorder()
+	api.ServeHTTP(recorder, request)
+	if recorder.Code != http.StatusSwitchingProtocols || recorder.Body.String() != "upgraded" {
+		t.Errorf("raw handler: got %d %q", recorder.Code, recorder.Body.String())
+	}
+
+	recorder = httptest.NewRecorder()
+	api.ServeHTTP(recorder, httptest.NewRequest("GET", "/socket", nil))
+	if recorder.Code != http.StatusOK || recorder.Body.String() == "upgraded" {
+		t.Errorf("fallthrough: got %d %q", recorder.Code, recorder.Body.String())
+	}
+}
+
+// waitForListener blocks until something is accepting connections on
+// addr, failing the test if nothing does within a second.
+func waitForListener(t *testing.T, addr string) {
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		conn, err := net.Dial("tcp", addr)
+		if err == nil {
+			conn.Close()
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("nothing listening on %s", addr)
+}
+
+type Upload struct{}
+
+func (upload Upload) Post(values url.Values, headers http.Header) (int, interface{}, http.Header) {
+	return 201, values.Get("name"), nil
+}
+
+func TestExpectContinue(t *testing.T) {
+	api := NewAPI()
+	api.AddResource(new(Upload), "/uploads")
+	api.AddResource(new(Item), "/items")
+	server := httptest.NewServer(api)
+	defer server.Close()
+
+	transport := &http.Transport{ExpectContinueTimeout: 5 * time.Second}
+	defer transport.CloseIdleConnections()
+	client := &http.Client{Transport: transport}
+
+	post := func(path string) *http.Response {
+		body := strings.NewReader("name=" + strings.Repeat("x", 1<<16))
+		request, _ := http.NewRequest("POST", server.URL+path, body)
+		request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		request.Header.Set("Expect", "100-continue")
+		resp, err := client.Do(request)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp
+	}
+
+	resp := post("/uploads")
+	body, _ := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode != 201 || len(body) != 1<<16+2 {
+		t.Errorf("upload: got %d with %d bytes", resp.StatusCode, len(body))
+	}
+
+	resp = post("/items")
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusMethodNotAllowed {
+		t.Errorf("unsupported method: got %d", resp.StatusCode)
+	}
+}
+
+type Markup struct{}
+
+func (markup Markup) Get(values url.Values, headers http.Header) (int, interface{}, http.Header) {
+	return 200, map[string]string{"html": "<b>&</b>"}, nil
+}
+
+func TestSetJSONOptions(t *testing.T) {
+	api := NewAPI()
+	api.AddResource(new(Markup), "/markup")
+
+	get := func() string {
+		recorder := httptest.NewRecorder()
+		api.ServeHTTP(recorder, httptest.NewRequest("GET", "/markup", nil))
+		return recorder.Body.String()
+	}
+
+	if body := get(); body != "{\n  \"html\": \"\\u003cb\\u003e\\u0026\\u003c/b\\u003e\"\n}" {
+		t.Errorf("default options: got %q", body)
+	}
+
+	api.SetJSONOptions("", false)
+	if body := get(); body != `{"html":"<b>&</b>"}` {
+		t.Errorf("compact, unescaped: got %q", body)
+	}
+}
+
+type Cached struct {
+	raw json.RawMessage
+}
+
+func (cached Cached) Get(values url.Values, headers http.Header) (int, interface{}, http.Header) {
+	return 200, cached.raw, nil
+}
+
+func TestRawMessage(t *testing.T) {
+	raw := json.RawMessage(`{ "id": 7,  "tags": ["a","b"] }`)
+	api := NewAPI()
+	api.SetFieldNamePolicy(SnakeCase)
+	api.AddResource(Cached{raw}, "/cached")
+	api.AddResource(Cached{json.RawMessage(`{"id":`)}, "/corrupt")
+
+	recorder := httptest.NewRecorder()
+	api.ServeHTTP(recorder, httptest.NewRequest("GET", "/cached", nil))
+	if body := recorder.Body.String(); body != string(raw) {
+		t.Errorf("body = %q, want the raw message as it is", body)
+	}
+	if ct := recorder.Header().Get("Content-Type"); ct != "application/json" {
+		t.Errorf("Content-Type = %q", ct)
+	}
+
+	recorder = httptest.NewRecorder()
+	api.ServeHTTP(recorder, httptest.NewRequest("GET", "/corrupt", nil))
+	if recorder.Code != http.StatusInternalServerError {
+		t.Errorf("invalid raw message: got %d, want 500", recorder.Code)
+	}
+}
+
+func BenchmarkRawMessage(b *testing.B) {
+	decoded := map[string][]int{"values": make([]int, 1000)}
+	raw, _ := json.Marshal(decoded)
+	for _, data := range []struct {
+		name string
+		data interface{}
+	}{
+		{"raw", json.RawMessage(raw)},
+		{"encoded", decoded},
+	} {
+		b.Run(data.name, func(b *testing.B) {
+			api := NewAPI()
+			request := httptest.NewRequest("GET", "/", nil)
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				api.respond(httptest.NewRecorder(), request, 200, data.data, nil)
+			}
+		})
+	}
+}
+
+type Legacy struct{ Item }
+
+func (legacy Legacy) MethodNotAllowed(method string) (int, interface{}) {
+	return http.StatusMethodNotAllowed, map[string]string{"error": method + " moved to /v2/items"}
+}
+
+func TestMethodNotAllowed(t *testing.T) {
+	api := NewAPI()
+	api.AddResource(new(Item), "/items")
+	api.AddResource(new(Legacy), "/legacy")
+
+	recorder := httptest.NewRecorder()
+	api.ServeHTTP(recorder, httptest.NewRequest("POST", "/items", nil))
+	if recorder.Code != 405 || recorder.Body.Len() != 0 || recorder.Header().Get("Allow") != "GET" {
+		t.Errorf("default: got %d %q Allow=%q", recorder.Code, recorder.Body.String(), recorder.Header().Get("Allow"))
+	}
+
+	recorder = httptest.NewRecorder()
+	api.ServeHTTP(recorder, httptest.NewRequest("DELETE", "/legacy", nil))
+	if recorder.Code != 405 || recorder.Header().Get("Allow") != "GET" {
+		t.Errorf("custom: got %d Allow=%q", recorder.Code, recorder.Header().Get("Allow"))
+	}
+	if body := recorder.Body.String(); body != "{\n  \"error\": \"DELETE moved to /v2/items\"\n}" {
+		t.Errorf("custom: got body %q", body)
+	}
+}
+
+type Relocated struct{ Item }
+
+func (relocated Relocated) MethodNotAllowedHandler(method string, r *http.Request) (int, interface{}) {
+	return http.StatusMethodNotAllowed, map[string]string{"error": method + " " + r.URL.Path + " is not supported; use /v2" + r.URL.Path}
+}
+
+func TestMethodNotAllowedHandler(t *testing.T) {
+	api := NewAPI()
+	api.SetJSONOptions("", true)
+	api.AddResource(new(Relocated), "/orders")
+
+	recorder := httptest.NewRecorder()
+	api.ServeHTTP(recorder, httptest.NewRequest("PUT", "/orders", nil))
+	if recorder.Code != 405 || recorder.Header().Get("Allow") != "GET" {
+		t.Errorf("got %d Allow=%q", recorder.Code, recorder.Header().Get("Allow"))
+	}
+	if body := recorder.Body.String(); body != `{"error":"PUT /orders is not supported; use /v2/orders"}` {
+		t.Errorf("got body %q", body)
+	}
+}
+
+func TestAddResourceErr(t *testing.T) {
+	api := NewAPI()
+
+	failure := errors.New("cannot open items.db")
+	err := api.AddResourceErr(func() (interface{}, error) { return nil, failure }, "/broken")
+	if err != failure {
+		t.Errorf("got error %v, want %v", err, failure)
+	}
+	if len(api.Routes()) != 0 {
+		t.Errorf("failed factory registered %v", api.Routes())
+	}
+
+	err = api.AddResourceErr(func() (interface{}, error) { return new(Item), nil }, "/items")
+	if err != nil {
+		t.Fatal(err)
+	}
+	recorder := httptest.NewRecorder()
+	api.ServeHTTP(recorder, httptest.NewRequest("GET", "/items", nil))
+	if recorder.Code != 200 {
+		t.Errorf("got %d", recorder.Code)
+	}
+}
+
+type Gateway struct{}
+
+func (gateway Gateway) Get(values url.Values, headers http.Header) (int, interface{}, http.Header) {
+	return 200, "get", nil
+}
+
+func (gateway Gateway) Any(method string, values url.Values) (int, interface{}) {
+	return 200, method
+}
+
+func TestAnyMethod(t *testing.T) {
+	api := NewAPI()
+	api.AddResource(new(Gateway), "/cache")
+
+	for method, expected := range map[string]string{"PURGE": `"PURGE"`, "DELETE": `"DELETE"`, "GET": `"get"`} {
+		recorder := httptest.NewRecorder()
+		api.ServeHTTP(recorder, httptest.NewRequest(method, "/cache", nil))
+		if recorder.Code != 200 || recorder.Body.String() != expected {
+			t.Errorf("%s: got %d %q, want 200 %s", method, recorder.Code, recorder.Body.String(), expected)
+		}
+	}
+}
+
+type Draft struct{}
+
+func (draft Draft) Get(values url.Values, headers http.Header) (int, interface{}, http.Header) {
+	return 200, "draft", nil
+}
+
+func (draft Draft) NotImplemented() {}
+
+func TestNotImplemented(t *testing.T) {
+	api := NewAPI()
+	api.SetJSONOptions("", true)
+	api.AddResource(new(Draft), "/drafts")
+
+	recorder := httptest.NewRecorder()
+	api.ServeHTTP(recorder, httptest.NewRequest("POST", "/drafts", nil))
+	if recorder.Code != http.StatusNotImplemented || recorder.Header().Get("Allow") != "GET" {
+		t.Errorf("got %d with Allow %q, want 501 with GET", recorder.Code, recorder.Header().Get("Allow"))
+	}
+	if body := recorder.Body.String(); body != `{"error":"not implemented"}` {
+		t.Errorf("body = %q", body)
+	}
+
+	recorder = httptest.NewRecorder()
+	api.ServeHTTP(recorder, httptest.NewRequest("GET", "/drafts", nil))
+	if recorder.Code != 200 {
+		t.Errorf("GET: got %d", recorder.Code)
+	}
+}
+
+func TestSetUnsupportedMethodStatus(t *testing.T) {
+	api := NewAPI()
+	api.AddResource(new(Item), "/items")
+	api.SetUnsupportedMethodStatus(http.StatusNotImplemented)
+
+	recorder := httptest.NewRecorder()
+	api.ServeHTTP(recorder, httptest.NewRequest("DELETE", "/items", nil))
+	if recorder.Code != http.StatusNotImplemented || recorder.Header().Get("Allow") != "GET" {
+		t.Errorf("got %d with Allow %q, want 501 with GET", recorder.Code, recorder.Header().Get("Allow"))
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("SetUnsupportedMethodStatus(404) did not panic")
+		}
+	}()
+	api.SetUnsupportedMethodStatus(http.StatusNotFound)
+}
+
+type Login struct{}
+
+func (login Login) Post(values url.Values, headers http.Header) (int, interface{}, http.Header) {
+	header := http.Header{}
+	header.Add("Set-Cookie", (&http.Cookie{Name: "session", Value: "abc"}).String())
+	header.Add("Set-Cookie", (&http.Cookie{Name: "theme", Value: "dark"}).String())
+	return 200, "welcome", header
+}
+
+func TestMultipleSetCookie(t *testing.T) {
+	api := NewAPI()
+	api.AddResource(new(Login), "/login")
+	api.AddResourceWithTimeout(new(Login), time.Second, "/login/slow")
+	api.EnableIdempotency(NewMemoryIdempotencyStore(time.Minute))
+
+	for _, target := range []string{"/login", "/login/slow"} {
+		request := httptest.NewRequest("POST", target, nil)
+		request.Header.Set("Idempotency-Key", target)
+		for _, attempt := range []string{"first", "replayed"} {
+			recorder := httptest.NewRecorder()
+			api.ServeHTTP(recorder, request)
+			cookies := recorder.Result().Cookies()
+			if len(cookies) != 2 || cookies[0].Name != "session" || cookies[1].Name != "theme" {
+				t.Errorf("%s, %s: Set-Cookie = %q", target, attempt, recorder.Header().Values("Set-Cookie"))
+			}
+		}
+	}
+}
+
+func TestAddHandler(t *testing.T) {
+	api := NewAPI()
+	api.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(rw http.ResponseWriter, request *http.Request) {
+			rw.Header().Set("X-Middleware", "yes")
+			next.ServeHTTP(rw, request)
+		})
+	})
+	api.AddHandler("/legacy", http.HandlerFunc(func(rw http.ResponseWriter, request *http.Request) {
+		rw.Write([]byte("legacy " + request.Method))
+	}))
+
+	recorder := httptest.NewRecorder()
+	api.ServeHTTP(recorder, httptest.NewRequest("DELETE", "/legacy", nil))
+	if recorder.Code != 200 || recorder.Body.String() != "legacy DELETE" {
+		t.Errorf("got %d %q", recorder.Code, recorder.Body.String())
+	}
+	if recorder.Header().Get("X-Middleware") != "yes" {
+		t.Error("the middleware did not wrap the handler")
+	}
+}
+
+func TestResolvedMethodsConcurrent(t *testing.T) {
+	api := NewAPI()
+	api.AddResource(new(Item), "/items")
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for _, method := range []string{"GET", "POST"} {
+				recorder := httptest.NewRecorder()
+				api.ServeHTTP(recorder, httptest.NewRequest(method, "/items", nil))
+				if method == "GET" && recorder.Code != 200 {
+					t.Errorf("GET: got %d", recorder.Code)
+				}
+				if method == "POST" && (recorder.Code != 405 || recorder.Header().Get("Allow") != "GET") {
+					t.Errorf("POST: got %d Allow=%q", recorder.Code, recorder.Header().Get("Allow"))
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func BenchmarkResourceGet(b *testing.B) {
+	api := NewAPI()
+	api.AddResource(new(Item), "/items")
+	request := httptest.NewRequest("GET", "/items", nil)
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		api.ServeHTTP(httptest.NewRecorder(), request)
+	}
+}
+
+// Widget supports every method the benchmarks exercise, and fails any
+// of them with a 400 when asked to.
+type Widget struct{}
+
+func (widget Widget) respond(values url.Values, code int) (int, interface{}, http.Header) {
+	if values.Get("fail") != "" {
+		return 400, map[string]string{"error": "widget refused"}, nil
+	}
+	return code, map[string]interface{}{"id": 7, "name": "sprocket", "tags": []string{"a", "b"}}, nil
+}
+
+func (widget Widget) Get(values url.Values, headers http.Header) (int, interface{}, http.Header) {
+	return widget.respond(values, 200)
+}
+
+func (widget Widget) Post(values url.Values, headers http.Header) (int, interface{}, http.Header) {
+	return widget.respond(values, 201)
+}
+
+func (widget Widget) Put(values url.Values, headers http.Header) (int, interface{}, http.Header) {
+	return widget.respond(values, 200)
+}
+
+func (widget Widget) Delete(values url.Values, headers http.Header) (int, interface{}, http.Header) {
+	return widget.respond(values, 200)
+}
+
+// BenchmarkRequestHandler measures a request's cost through ServeHTTP
+// for each method, on the success and error paths. Besides time and
+// allocations it reports throughput and how many goroutines a request
+// leaves behind. The baseline is kept in testdata/benchmarks.txt.
+func BenchmarkRequestHandler(b *testing.B) {
+	api := NewAPI()
+	api.AddResource(new(Widget), "/widgets")
+
+	for _, method := range []string{GET, POST, PUT, DELETE} {
+		for _, path := range []struct{ name, target string }{
+			{"JSON", "/widgets"},
+			{"Error", "/widgets?fail=1"},
+		} {
+			b.Run(method+"/"+path.name, func(b *testing.B) {
+				request := httptest.NewRequest(method, path.target, nil)
+				goroutines := runtime.NumGoroutine()
+
+				b.ReportAllocs()
+				b.ResetTimer()
+				for i := 0; i < b.N; i++ {
+					api.ServeHTTP(httptest.NewRecorder(), request)
+				}
+				b.StopTimer()
+
+				b.ReportMetric(float64(b.N)/b.Elapsed().Seconds(), "req/s")
+				b.ReportMetric(float64(runtime.NumGoroutine()-goroutines), "goroutines")
+			})
+		}
+	}
+}
+
+// FuzzRequestHandler feeds raw HTTP requests to an API with resources
+// that parse forms, decode JSON bodies and encode responses, and fails
+// if any of them panics or yields an invalid status.
+func FuzzRequestHandler(f *testing.F) {
+	for _, seed := range []string{
+		"GET /items HTTP/1.1\r\nHost: example.com\r\n\r\n",
+		"GET /items?id=1&id=2&name=%E2%9C%93 HTTP/1.1\r\nHost: example.com\r\nAccept: application/json\r\n\r\n",
+		"POST /user HTTP/1.1\r\nHost: example.com\r\nContent-Type: application/x-www-form-urlencoded\r\nContent-Length: 8\r\n\r\nname=ann",
+		"POST /orders HTTP/1.1\r\nHost: example.com\r\nContent-Type: application/json\r\nContent-Length: 11\r\n\r\n{\"id\": 1.5}",
+		"POST /attachments HTTP/1.1\r\nHost: example.com\r\nContent-Type: multipart/form-data; boundary=x\r\nContent-Length: 47\r\n\r\n--x\r\nContent-Disposition: form-data; name=a\r\n\r\n1\r\n--x--",
+		"DELETE /user HTTP/1.1\r\nHost: example.com\r\nContent-Encoding: gzip\r\nContent-Length: 3\r\n\r\nabc",
+		"PATCH /users?limit=-1&tag= HTTP/1.1\r\nHost: example.com\r\n\r\n",
+	} {
+		f.Add(seed)
+	}
+
+	f.Fuzz(func(t *testing.T, raw string) {
+		request, err := http.ReadRequest(bufio.NewReader(strings.NewReader(raw)))
+		if err != nil {
+			return
+		}
+		api := NewAPI()
+		api.SetPanicHandler(func(r *http.Request, recovered interface{}, stack []byte) {
+			t.Errorf("panic serving %q: %v\n%s", raw, recovered, stack)
+		})
+		api.AddResource(new(Item), "/items")
+		api.AddResource(new(User), "/user")
+		api.AddResource(new(Order), "/orders")
+		api.AddResource(new(Attachment), "/attachments")
+		api.AddResource(users(), "/users")
+
+		recorder := httptest.NewRecorder()
+		api.ServeHTTP(recorder, request)
+		if recorder.Code < 100 || recorder.Code > 599 {
+			t.Errorf("%q: status %d", raw, recorder.Code)
+		}
+	})
+}
@@ -0,0 +1,33 @@
+package sleepy
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+func TestCSPBuilder(t *testing.T) {
+	policy := NewCSPBuilder().
+		DefaultSrc("'self'").
+		ScriptSrc("'self'", "https://cdn.example.com").
+		DefaultSrc("https://static.example.com").
+		ObjectSrc("'none'").
+		String()
+
+	expected := "default-src 'self' https://static.example.com; script-src 'self' https://cdn.example.com; object-src 'none'"
+	if policy != expected {
+		t.Errorf("policy = %q, want %q", policy, expected)
+	}
+}
+
+func TestCSPMiddleware(t *testing.T) {
+	api := NewAPI()
+	api.AddResource(new(Item), "/items")
+	api.Use(CSPMiddleware("default-src 'self'"))
+
+	recorder := httptest.NewRecorder()
+	api.ServeHTTP(recorder, httptest.NewRequest("GET", "/items", nil))
+
+	if got := recorder.Header().Get("Content-Security-Policy"); got != "default-src 'self'" {
+		t.Errorf("Content-Security-Policy = %q", got)
+	}
+}
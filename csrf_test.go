@@ -0,0 +1,51 @@
+package sleepy
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestCSRFMiddleware(t *testing.T) {
+	store := NewHMACTokenStore([]byte("secret"))
+	handler := CSRFMiddleware(store, "csrf")(http.HandlerFunc(func(rw http.ResponseWriter, request *http.Request) {}))
+
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest("GET", "/items", nil))
+	cookies := recorder.Result().Cookies()
+	if len(cookies) != 1 || cookies[0].Name != "csrf" || !store.Valid(cookies[0].Value) {
+		t.Fatalf("GET did not issue a valid token: %v", cookies)
+	}
+	token := cookies[0].Value
+
+	post := func(cookie, header string) int {
+		request := httptest.NewRequest("POST", "/items", nil)
+		if cookie != "" {
+			request.AddCookie(&http.Cookie{Name: "csrf", Value: cookie})
+		}
+		if header != "" {
+			request.Header.Set("X-CSRF-Token", header)
+		}
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, request)
+		return recorder.Code
+	}
+
+	forged, _ := NewHMACTokenStore([]byte("other")).New()
+	cases := []struct {
+		name           string
+		cookie, header string
+		code           int
+	}{
+		{"matching", token, token, http.StatusOK},
+		{"no cookie", "", token, http.StatusForbidden},
+		{"no header", token, "", http.StatusForbidden},
+		{"mismatch", token, forged, http.StatusForbidden},
+		{"forged pair", forged, forged, http.StatusForbidden},
+	}
+	for _, c := range cases {
+		if code := post(c.cookie, c.header); code != c.code {
+			t.Errorf("%s: got %d, want %d", c.name, code, c.code)
+		}
+	}
+}
@@ -0,0 +1,13 @@
+package sleepy
+
+import "net/http"
+
+// SetDeadLetterHandler installs handler to be called whenever the data
+// a resource returned cannot be encoded, before the client is sent a
+// 500 Internal Server Error in its place. handler gets the request, the
+// status code and data the resource returned, and the encoding error,
+// so the response can be recorded or retried rather than lost. Pass
+// nil to remove it.
+func (api *API) SetDeadLetterHandler(handler func(r *http.Request, code int, data interface{}, err error)) {
+	api.deadLetter = handler
+}
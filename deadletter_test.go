@@ -0,0 +1,45 @@
+package sleepy
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+type Payment struct{}
+
+func (payment Payment) Post(values url.Values, headers http.Header) (int, interface{}, http.Header) {
+	return 201, map[string]interface{}{"amount": 10, "callback": func() {}}, nil
+}
+
+func TestDeadLetterHandler(t *testing.T) {
+	var (
+		calls int
+		code  int
+		data  interface{}
+		err   error
+	)
+
+	api := NewAPI()
+	api.AddResource(new(Payment), "/payments")
+	api.SetDeadLetterHandler(func(r *http.Request, c int, d interface{}, e error) {
+		if r.URL.Path != "/payments" {
+			t.Errorf("dead letter for %s", r.URL.Path)
+		}
+		calls++
+		code, data, err = c, d, e
+	})
+
+	recorder := httptest.NewRecorder()
+	api.ServeHTTP(recorder, httptest.NewRequest("POST", "/payments", nil))
+	if recorder.Code != 500 {
+		t.Errorf("got %d, want 500", recorder.Code)
+	}
+	if calls != 1 || code != 201 || err == nil {
+		t.Fatalf("dead letter handler called %d times with code %d and error %v", calls, code, err)
+	}
+	if data.(map[string]interface{})["amount"] != 10 {
+		t.Errorf("dead letter data = %v", data)
+	}
+}
@@ -0,0 +1,83 @@
+package sleepy
+
+import (
+	"log/slog"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// defaultRedactedHeaders are the headers whose values debug logging
+// always hides.
+var defaultRedactedHeaders = []string{"Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie"}
+
+// SetRedactedHeaders adds names to the headers whose values are logged
+// as "[REDACTED]" in debug mode. Authorization, Proxy-Authorization,
+// Cookie and Set-Cookie are always redacted, whatever names are given.
+// Names are matched without regard to case, and each call replaces the
+// names given before. In debug mode, turned on with SetDebug, the
+// headers of every request the API serves are logged with log/slog's
+// default logger at INFO level as it arrives, and those of its response
+// once it has been answered.
+func (api *API) SetRedactedHeaders(names []string) {
+	redacted := make(map[string]bool, len(defaultRedactedHeaders)+len(names))
+	for _, name := range defaultRedactedHeaders {
+		redacted[name] = true
+	}
+	for _, name := range names {
+		redacted[http.CanonicalHeaderKey(name)] = true
+	}
+	api.redactedHeaders = redacted
+}
+
+func (api *API) headerLogHandler(next http.Handler) http.Handler {
+	redacted := api.redactedHeaders
+	if redacted == nil {
+		redacted = make(map[string]bool, len(defaultRedactedHeaders))
+		for _, name := range defaultRedactedHeaders {
+			redacted[name] = true
+		}
+	}
+	return http.HandlerFunc(func(rw http.ResponseWriter, request *http.Request) {
+		// The request is logged once it has been answered, when it is
+		// known whether its route is quiet.
+		header := request.Header.Clone()
+		writer := wrapResponseWriter(rw)
+		next.ServeHTTP(writer, request)
+		if isQuiet(request) {
+			return
+		}
+		slog.Info("sleepy: request headers",
+			"method", request.Method,
+			"path", request.URL.Path,
+			headerGroup(header, redacted))
+		status := writer.status
+		if status == 0 {
+			status = http.StatusOK
+		}
+		slog.Info("sleepy: response headers",
+			"method", request.Method,
+			"path", request.URL.Path,
+			"status", status,
+			headerGroup(rw.Header(), redacted))
+	})
+}
+
+// headerGroup returns header as a log attribute, sorted by name, with
+// the values of redacted headers replaced.
+func headerGroup(header http.Header, redacted map[string]bool) slog.Attr {
+	names := make([]string, 0, len(header))
+	for name := range header {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	attrs := make([]interface{}, 0, len(names))
+	for _, name := range names {
+		value := strings.Join(header[name], ", ")
+		if redacted[http.CanonicalHeaderKey(name)] {
+			value = redactedValue
+		}
+		attrs = append(attrs, slog.String(name, value))
+	}
+	return slog.Group("headers", attrs...)
+}
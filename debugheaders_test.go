@@ -0,0 +1,58 @@
+package sleepy
+
+import (
+	"bytes"
+	"log/slog"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestDebugHeaderLogging(t *testing.T) {
+	var out bytes.Buffer
+	defer slog.SetDefault(slog.Default())
+	slog.SetDefault(slog.New(slog.NewTextHandler(&out, nil)))
+
+	api := NewAPI()
+	api.AddResource(new(Item), "/items")
+	request := httptest.NewRequest("GET", "/items", nil)
+	request.Header.Set("Authorization", "Bearer secret")
+	request.Header.Set("X-Api-Key", "key")
+	request.Header.Set("X-Client", "dashboard")
+
+	api.ServeHTTP(httptest.NewRecorder(), request)
+	if out.Len() != 0 {
+		t.Errorf("logged outside debug mode: %s", out.String())
+	}
+
+	api.SetDebug(true)
+	api.ServeHTTP(httptest.NewRecorder(), request)
+	logged := out.String()
+	for _, want := range []string{
+		`msg="sleepy: request headers"`,
+		"headers.Authorization=[REDACTED]",
+		"headers.X-Api-Key=key",
+		"headers.X-Client=dashboard",
+		`msg="sleepy: response headers"`,
+		"status=200",
+		"headers.Content-Type=application/json",
+	} {
+		if !strings.Contains(logged, want) {
+			t.Errorf("log %q lacks %q", logged, want)
+		}
+	}
+	if strings.Contains(logged, "secret") {
+		t.Errorf("log reveals the Authorization header: %s", logged)
+	}
+
+	out.Reset()
+	api.SetRedactedHeaders([]string{"x-api-key"})
+	api.ServeHTTP(httptest.NewRecorder(), request)
+	logged = out.String()
+	if !strings.Contains(logged, "headers.X-Api-Key=[REDACTED]") || !strings.Contains(logged, "headers.Authorization=[REDACTED]") {
+		t.Errorf("with X-Api-Key redacted, got %s", logged)
+	}
+	if strings.Contains(logged, "secret") || !strings.Contains(logged, "headers.X-Client=dashboard") {
+		t.Errorf("with X-Api-Key redacted, got %s", logged)
+	}
+}
@@ -0,0 +1,30 @@
+package sleepy
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+)
+
+// SetUseJSONNumber controls how DecodeJSON treats numbers decoded into
+// an interface{}. With it on they become json.Number, which keeps large
+// integer IDs intact, rather than float64. It is off by default.
+func (api *API) SetUseJSONNumber(useNumber bool) {
+	api.jsonUseNumber = useNumber
+}
+
+// DecodeJSON decodes the JSON body of the request being served into v,
+// from the context passed to a context-aware resource method. Numbers
+// are decoded as json.Number if the API was told to with
+// SetUseJSONNumber.
+func DecodeJSON(ctx context.Context, v interface{}) error {
+	request := RequestFromContext(ctx)
+	if request == nil {
+		return errors.New("sleepy: no request in context")
+	}
+	decoder := json.NewDecoder(request.Body)
+	if api, ok := GetContextValue(ctx, apiKey).(*API); ok && api.jsonUseNumber {
+		decoder.UseNumber()
+	}
+	return decoder.Decode(v)
+}
@@ -0,0 +1,44 @@
+package sleepy
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+type Order struct {
+	decoded map[string]interface{}
+}
+
+func (order *Order) PostContext(ctx context.Context, values url.Values, headers http.Header) (int, interface{}, http.Header) {
+	if err := DecodeJSON(ctx, &order.decoded); err != nil {
+		return 400, map[string]string{"error": err.Error()}, nil
+	}
+	return 200, "ok", nil
+}
+
+func TestUseJSONNumber(t *testing.T) {
+	for _, useNumber := range []bool{false, true} {
+		order := new(Order)
+		api := NewAPI()
+		api.AddResource(order, "/orders")
+		api.SetUseJSONNumber(useNumber)
+
+		request := httptest.NewRequest("POST", "/orders", strings.NewReader(`{"id": 9007199254740993}`))
+		request.Header.Set("Content-Type", "application/json")
+		recorder := httptest.NewRecorder()
+		api.ServeHTTP(recorder, request)
+		if recorder.Code != 200 {
+			t.Fatalf("got %d %q", recorder.Code, recorder.Body.String())
+		}
+
+		id := order.decoded["id"]
+		if number, ok := id.(json.Number); ok != useNumber || (ok && number.String() != "9007199254740993") {
+			t.Errorf("with UseNumber %v the id decoded as %T %v", useNumber, id, id)
+		}
+	}
+}
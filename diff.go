@@ -0,0 +1,86 @@
+package sleepy
+
+import "reflect"
+
+// A ChangeType says how a path or method differs between two APIs.
+type ChangeType string
+
+const (
+	// Added is a path or method the new API serves and the old doesn't.
+	Added ChangeType = "added"
+
+	// Removed is a path or method the old API serves and the new doesn't.
+	Removed ChangeType = "removed"
+
+	// Modified is a path both APIs serve with the same methods, but with
+	// resources of different types.
+	Modified ChangeType = "modified"
+)
+
+// A Change is a difference between two APIs, as found by DiffAPIs.
+// Method is empty for a change to a path as a whole.
+type Change struct {
+	Type   ChangeType `json:"type"`
+	Path   string     `json:"path"`
+	Method string     `json:"method,omitempty"`
+}
+
+// DiffAPIs compares the resources registered with before and after,
+// such as those of two releases, and returns the differences, for
+// generating an API changelog. A path only one of them serves gives a
+// change for each of its methods, and a path both serve gives one for
+// each method only one of them supports. Changes for the paths of after
+// come first, in registration order, followed by the paths it no longer
+// serves. It returns nil if the APIs serve the same paths and methods
+// with resources of the same types.
+func DiffAPIs(before, after *API) []Change {
+	previous := make(map[string]ResourceInfo)
+	for _, info := range before.Resources() {
+		previous[info.Path] = info
+	}
+	served := make(map[string]bool)
+
+	var changes []Change
+	for _, info := range after.Resources() {
+		served[info.Path] = true
+		old, ok := previous[info.Path]
+		if !ok {
+			changes = append(changes, pathChanges(Added, info)...)
+			continue
+		}
+		added := methodChanges(Added, info.Path, info.SupportedMethods, old.SupportedMethods)
+		removed := methodChanges(Removed, info.Path, old.SupportedMethods, info.SupportedMethods)
+		changes = append(append(changes, added...), removed...)
+		if len(added) == 0 && len(removed) == 0 && reflect.TypeOf(old.Resource) != reflect.TypeOf(info.Resource) {
+			changes = append(changes, Change{Type: Modified, Path: info.Path})
+		}
+	}
+	for _, info := range before.Resources() {
+		if !served[info.Path] {
+			changes = append(changes, pathChanges(Removed, info)...)
+		}
+	}
+	return changes
+}
+
+// pathChanges returns a change of type kind for each method of the
+// resource described by info, or a single change for its path if it
+// supports none.
+func pathChanges(kind ChangeType, info ResourceInfo) []Change {
+	if len(info.SupportedMethods) == 0 {
+		return []Change{{Type: kind, Path: info.Path}}
+	}
+	return methodChanges(kind, info.Path, info.SupportedMethods, nil)
+}
+
+// methodChanges returns a change of type kind at path for each of
+// methods that is not in except.
+func methodChanges(kind ChangeType, path string, methods, except []string) []Change {
+	var changes []Change
+	for _, method := range methods {
+		if !containsType(except, method) {
+			changes = append(changes, Change{Type: kind, Path: path, Method: method})
+		}
+	}
+	return changes
+}
@@ -0,0 +1,51 @@
+package sleepy
+
+import (
+	"net/http"
+	"net/url"
+	"reflect"
+	"testing"
+)
+
+type Journal struct{}
+
+func (journal Journal) Get(values url.Values, headers http.Header) (int, interface{}, http.Header) {
+	return 200, "journal", nil
+}
+
+type WritableJournal struct{ Journal }
+
+func (journal WritableJournal) Post(values url.Values, headers http.Header) (int, interface{}, http.Header) {
+	return 201, "journal", nil
+}
+
+type JournalV2 struct{ Journal }
+
+func TestDiffAPIs(t *testing.T) {
+	before := NewAPI()
+	before.AddResource(new(Journal), "/accounts")
+	before.AddResource(new(WritableJournal), "/entries")
+	before.AddResource(new(Journal), "/balances")
+	before.AddResource(new(Journal), "/legacy")
+
+	after := NewAPI()
+	after.AddResource(new(WritableJournal), "/accounts")
+	after.AddResource(new(Journal), "/entries")
+	after.AddResource(new(JournalV2), "/balances")
+	after.AddResource(new(WritableJournal), "/transfers")
+
+	want := []Change{
+		{Added, "/accounts", POST},
+		{Removed, "/entries", POST},
+		{Modified, "/balances", ""},
+		{Added, "/transfers", GET},
+		{Added, "/transfers", POST},
+		{Removed, "/legacy", GET},
+	}
+	if got := DiffAPIs(before, after); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+	if got := DiffAPIs(before, before); got != nil {
+		t.Errorf("an API differs from itself: %v", got)
+	}
+}
@@ -0,0 +1,20 @@
+package sleepy
+
+import (
+	"context"
+	"errors"
+	"net/http"
+)
+
+// StatusClientClosedRequest is the status logged for a request whose
+// client disconnected before it was answered. It is never sent, as
+// there is no one left to send it to; the number is the one nginx uses
+// for the same purpose.
+const StatusClientClosedRequest = 499
+
+// clientGone reports whether the client that made request has already
+// disconnected. A context-aware resource sees the same thing as its
+// context being canceled, and can stop work that no one will see.
+func clientGone(request *http.Request) bool {
+	return errors.Is(request.Context().Err(), context.Canceled)
+}
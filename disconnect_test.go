@@ -0,0 +1,58 @@
+package sleepy
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+)
+
+type Report struct {
+	started  chan struct{}
+	canceled chan error
+}
+
+func (report *Report) GetContext(ctx context.Context, values url.Values, headers http.Header) (int, interface{}, http.Header) {
+	close(report.started)
+	select {
+	case <-ctx.Done():
+		report.canceled <- ctx.Err()
+	case <-time.After(5 * time.Second):
+		report.canceled <- nil
+	}
+	return 200, "report", nil
+}
+
+func TestClientDisconnect(t *testing.T) {
+	report := &Report{started: make(chan struct{}), canceled: make(chan error, 1)}
+	entries := make(chan LogEntry, 1)
+	api := NewAPI()
+	api.SetLogHook(func(entry LogEntry) { entries <- entry })
+	api.AddResource(report, "/report")
+	server := httptest.NewServer(api)
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	request, _ := http.NewRequestWithContext(ctx, "GET", server.URL+"/report", nil)
+	go func() {
+		<-report.started
+		cancel()
+	}()
+	if _, err := http.DefaultClient.Do(request); err == nil {
+		t.Error("request succeeded after it was canceled")
+	}
+
+	if err := <-report.canceled; err != context.Canceled {
+		t.Errorf("handler saw %v, want context.Canceled", err)
+	}
+	select {
+	case entry := <-entries:
+		if entry.Status != StatusClientClosedRequest || entry.Bytes != 0 {
+			t.Errorf("logged status %d and %d bytes, want %d and none", entry.Status, entry.Bytes, StatusClientClosedRequest)
+		}
+	case <-time.After(time.Second):
+		t.Error("the request was not logged")
+	}
+}
@@ -0,0 +1,18 @@
+// The package needs the Go 1.22 ServeMux, which matches method-qualified
+// patterns such as "GET /items" and wildcards such as "/items/{id}".
+// Without a go.mod declaring go 1.22 or later, builds fall back to the
+// Go 1.21 ServeMux, which would treat both literally; this turns the
+// fallback off for the tests. They rely on it in:
+//
+//   - params_test.go: TestParams
+//   - mount_test.go: TestMountSubAPI
+//   - grpcgateway_test.go: TestGRPCGatewayResource and
+//     TestGRPCGatewayMarshalJSON
+//   - log_test.go: TestQuietPaths
+//   - urlfor_test.go: TestURLFor
+//   - validate_test.go: TestValidateDuplicatePath
+//
+// Only one file of a package may set it.
+//go:debug httpmuxgo121=0
+
+package sleepy
@@ -0,0 +1,76 @@
+package sleepy
+
+import (
+	"context"
+	"errors"
+	"net/http"
+)
+
+// Drain stops the API from accepting new requests: from now on they
+// are answered with a 503 Service Unavailable and a Retry-After header,
+// while requests already in progress run to completion. Use
+// WaitForDrain to wait for them.
+func (api *API) Drain() {
+	api.drainMu.Lock()
+	defer api.drainMu.Unlock()
+	if api.draining {
+		return
+	}
+	api.draining = true
+	api.drained = make(chan struct{})
+	if api.inFlight == 0 {
+		close(api.drained)
+	}
+}
+
+// WaitForDrain blocks until every request that was in progress when
+// Drain was called has finished, or until ctx is done, in which case it
+// returns the context's error. It returns an error immediately if Drain
+// has not been called.
+func (api *API) WaitForDrain(ctx context.Context) error {
+	api.drainMu.Lock()
+	drained := api.drained
+	api.drainMu.Unlock()
+	if drained == nil {
+		return errors.New("sleepy: WaitForDrain called before Drain")
+	}
+
+	select {
+	case <-drained:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// isDraining reports whether Drain has been called.
+func (api *API) isDraining() bool {
+	api.drainMu.Lock()
+	defer api.drainMu.Unlock()
+	return api.draining
+}
+
+// drainHandler counts requests in flight and rejects new ones once the
+// API is draining.
+func (api *API) drainHandler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, request *http.Request) {
+		api.drainMu.Lock()
+		if api.draining {
+			api.drainMu.Unlock()
+			WriteUnavailable(rw, drainRetryAfter)
+			return
+		}
+		api.inFlight++
+		api.drainMu.Unlock()
+
+		defer func() {
+			api.drainMu.Lock()
+			api.inFlight--
+			if api.draining && api.inFlight == 0 {
+				close(api.drained)
+			}
+			api.drainMu.Unlock()
+		}()
+		next.ServeHTTP(rw, request)
+	})
+}
@@ -0,0 +1,62 @@
+package sleepy
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+)
+
+type Slow struct {
+	started, release chan struct{}
+}
+
+func (slow Slow) Get(values url.Values, headers http.Header) (int, interface{}, http.Header) {
+	slow.started <- struct{}{}
+	<-slow.release
+	return 200, "done", nil
+}
+
+func TestDrain(t *testing.T) {
+	slow := Slow{started: make(chan struct{}), release: make(chan struct{})}
+	api := NewAPI()
+	api.AddResource(slow, "/slow")
+	api.AddResource(new(Item), "/items")
+
+	if err := api.WaitForDrain(context.Background()); err == nil {
+		t.Error("WaitForDrain before Drain returned nil")
+	}
+
+	inFlight := httptest.NewRecorder()
+	finished := make(chan struct{})
+	go func() {
+		api.ServeHTTP(inFlight, httptest.NewRequest("GET", "/slow", nil))
+		close(finished)
+	}()
+	<-slow.started
+
+	api.Drain()
+
+	recorder := httptest.NewRecorder()
+	api.ServeHTTP(recorder, httptest.NewRequest("GET", "/items", nil))
+	if recorder.Code != http.StatusServiceUnavailable || recorder.Header().Get("Retry-After") != "5" {
+		t.Errorf("new request while draining: got %d with Retry-After %q", recorder.Code, recorder.Header().Get("Retry-After"))
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := api.WaitForDrain(ctx); err != context.DeadlineExceeded {
+		t.Errorf("WaitForDrain with a request in flight: got %v", err)
+	}
+
+	close(slow.release)
+	if err := api.WaitForDrain(context.Background()); err != nil {
+		t.Errorf("WaitForDrain: %v", err)
+	}
+	<-finished
+	if inFlight.Code != 200 {
+		t.Errorf("in-flight request: got %d", inFlight.Code)
+	}
+}
@@ -0,0 +1,275 @@
+package sleepy
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// jsonContentType is the content type of responses encoded as JSON,
+// which is what the API produces unless the client asks otherwise.
+const jsonContentType = "application/json"
+
+// formContentType is the content type of form-encoded responses, which
+// the API offers for data that is a flat map[string]string.
+const formContentType = "application/x-www-form-urlencoded"
+
+// An Encoder serializes response data for one content type.
+type Encoder interface {
+	Encode(w io.Writer, data interface{}) error
+}
+
+// EncoderFunc adapts an ordinary function to the Encoder interface.
+type EncoderFunc func(w io.Writer, data interface{}) error
+
+// Encode calls f(w, data).
+func (f EncoderFunc) Encode(w io.Writer, data interface{}) error {
+	return f(w, data)
+}
+
+// XMLEncoder encodes response data with encoding/xml. Register it to
+// let clients ask for XML:
+//
+//	api.RegisterEncoder("application/xml", sleepy.XMLEncoder)
+var XMLEncoder Encoder = EncoderFunc(func(w io.Writer, data interface{}) error {
+	return xml.NewEncoder(w).Encode(data)
+})
+
+// RegisterEncoder makes the API able to respond with the given content
+// type, encoded by encoder. Which encoder a request gets is negotiated
+// from its Accept header; JSON is offered first and used when the
+// client accepts nothing registered. Registering "application/json"
+// replaces the built-in JSON encoder.
+func (api *API) RegisterEncoder(contentType string, encoder Encoder) {
+	if api.encoders == nil {
+		api.encoders = make(map[string]Encoder)
+	}
+	if _, ok := api.encoders[contentType]; !ok && contentType != jsonContentType {
+		api.encoderTypes = append(api.encoderTypes, contentType)
+	}
+	api.encoders[contentType] = encoder
+}
+
+// RegisterSuffixEncoder makes the API able to respond with any vendor
+// media type ending in suffix, such as "+json" in
+// "application/vnd.myapp.v2+json", that the client asks for and that
+// has no encoder registered for it exactly. The response is encoded by
+// encoder and labelled with the type the client asked for. Suffixes
+// "+json" and "+xml" are understood from the start, encoded as JSON and
+// with XMLEncoder; registering them replaces those encoders.
+func (api *API) RegisterSuffixEncoder(suffix string, encoder Encoder) {
+	if !strings.HasPrefix(suffix, "+") {
+		suffix = "+" + suffix
+	}
+	if api.suffixEncoders == nil {
+		api.suffixEncoders = make(map[string]Encoder)
+	}
+	api.suffixEncoders[strings.ToLower(suffix)] = encoder
+}
+
+// suffixEncoder returns the encoder for the structured syntax suffix of
+// contentType, or nil if it has none the API knows.
+func (api *API) suffixEncoder(contentType string) Encoder {
+	i := strings.LastIndex(contentType, "+")
+	if i < 0 {
+		return nil
+	}
+	suffix := strings.ToLower(contentType[i:])
+	if encoder, ok := api.suffixEncoders[suffix]; ok {
+		return encoder
+	}
+	switch suffix {
+	case "+json":
+		return jsonEncoder{api}
+	case "+xml":
+		return XMLEncoder
+	}
+	return nil
+}
+
+// acceptedSuffixTypes returns the media types in request's Accept
+// header that are not already among offered but that a suffix encoder
+// can produce.
+func (api *API) acceptedSuffixTypes(request *http.Request, offered []string) []string {
+	var types []string
+	for _, accepted := range parseQualityList(strings.Join(request.Header.Values("Accept"), ",")) {
+		if accepted.q == 0 || strings.Contains(accepted.value, "*") || containsType(offered, accepted.value) {
+			continue
+		}
+		if api.suffixEncoder(accepted.value) != nil {
+			types = append(types, accepted.value)
+		}
+	}
+	return types
+}
+
+// containsType reports whether contentType is one of types, ignoring
+// case.
+func containsType(types []string, contentType string) bool {
+	for _, t := range types {
+		if strings.EqualFold(t, contentType) {
+			return true
+		}
+	}
+	return false
+}
+
+// SetDefaultContentType sets the content type used for requests that
+// carry no Accept header, in place of JSON, and for those that accept
+// it as readily as any other type, such as with */*. It takes
+// effect only while an encoder is registered for contentType; otherwise
+// such requests still get JSON.
+func (api *API) SetDefaultContentType(contentType string) {
+	api.defaultType = contentType
+}
+
+// encode writes data to w in the format chosen for a response to
+// request with the given status code, and returns its content type.
+func (api *API) encode(w io.Writer, request *http.Request, code int, data interface{}) (string, error) {
+	if _, ok := data.(plainText); ok {
+		return textContentType, textEncoder.Encode(w, data)
+	}
+	if _, ok := data.(errorProblem); ok {
+		return problemContentType, api.encodeJSON(w, data)
+	}
+	if code >= 400 && api.errorEncoder != nil {
+		return api.errorType, api.errorEncoder.EncodeError(w, code, data)
+	}
+	contentType, encoder := api.negotiateEncoder(request, data)
+	return contentType, encoder.Encode(w, data)
+}
+
+// offeredTypes returns the content types the API can encode data as,
+// JSON first.
+func (api *API) offeredTypes(data interface{}) []string {
+	if _, ok := data.(plainText); ok {
+		return []string{textContentType}
+	}
+	if _, ok := data.(errorProblem); ok {
+		return []string{problemContentType}
+	}
+	offered := append([]string{jsonContentType}, api.encoderTypes...)
+	if _, ok := data.(map[string]string); ok {
+		if _, registered := api.encoders[formContentType]; !registered {
+			offered = append(offered, formContentType)
+		}
+	}
+	if _, ok := data.(ProtoMessage); ok && api.protoMarshal != nil {
+		if _, registered := api.encoders[protobufContentType]; !registered {
+			offered = append(offered, protobufContentType)
+		}
+	}
+	return offered
+}
+
+// negotiateEncoder picks the content type and encoder for the response
+// to request carrying data.
+func (api *API) negotiateEncoder(request *http.Request, data interface{}) (string, Encoder) {
+	contentType := jsonContentType
+	offered := api.offeredTypes(data)
+	offered = append(offered, api.acceptedSuffixTypes(request, offered)...)
+	if produces := resourceProduces(request.Context()); produces != nil {
+		offered = onlyProduced(offered, produces)
+	}
+	if len(offered) > 1 {
+		if api.defaultType != "" {
+			offered = preferType(offered, api.defaultType)
+		}
+		if negotiated := NegotiateContentType(request, offered); negotiated != "" {
+			contentType = negotiated
+		}
+	}
+	if contentType == jsonContentType || strings.HasSuffix(contentType, "+json") {
+		if encoder := resourceMarshaller(request.Context()); encoder != nil {
+			return contentType, encoder
+		}
+	}
+	if encoder, ok := api.encoders[contentType]; ok {
+		return contentType, encoder
+	}
+	switch contentType {
+	case formContentType:
+		return contentType, formEncoder
+	case protobufContentType:
+		return contentType, protoEncoder{api}
+	default:
+		if encoder := api.suffixEncoder(contentType); encoder != nil {
+			return contentType, encoder
+		}
+	}
+	return contentType, jsonEncoder{api}
+}
+
+// preferType returns offered with contentType moved to the front, if it
+// is offered at all.
+func preferType(offered []string, contentType string) []string {
+	for i, t := range offered {
+		if t == contentType {
+			preferred := append([]string{t}, offered[:i]...)
+			return append(preferred, offered[i+1:]...)
+		}
+	}
+	return offered
+}
+
+// formEncoder encodes a map[string]string as a URL-encoded form, with
+// the keys sorted.
+var formEncoder Encoder = EncoderFunc(func(w io.Writer, data interface{}) error {
+	values := make(url.Values)
+	for key, value := range data.(map[string]string) {
+		values.Set(key, value)
+	}
+	_, err := io.WriteString(w, values.Encode())
+	return err
+})
+
+// errInvalidRawJSON is returned when a resource responds with a
+// json.RawMessage that is not valid JSON.
+var errInvalidRawJSON = errors.New("sleepy: json.RawMessage is not valid JSON")
+
+// jsonEncoder encodes with the API's JSON options.
+type jsonEncoder struct {
+	api *API
+}
+
+func (e jsonEncoder) Encode(w io.Writer, data interface{}) error {
+	return e.api.encodeJSON(w, data)
+}
+
+// SetJSONOptions controls how response data is encoded. indent is
+// repeated once per nesting level, and an empty indent produces compact
+// output; the default is two spaces. escapeHTML controls whether <, >
+// and & are escaped inside JSON strings, which they are by default.
+func (api *API) SetJSONOptions(indent string, escapeHTML bool) {
+	api.jsonIndent = indent
+	api.jsonEscapeHTML = escapeHTML
+}
+
+// encodeJSON writes data to w, encoded with the API's JSON options and
+// field name policy. A json.RawMessage is already encoded, so it is
+// written exactly as it is once it has been checked to be valid JSON.
+func (api *API) encodeJSON(w io.Writer, data interface{}) error {
+	if raw, ok := data.(json.RawMessage); ok {
+		if !json.Valid(raw) {
+			return errInvalidRawJSON
+		}
+		_, err := w.Write(raw)
+		return err
+	}
+	if rewrite, ok := api.jsonRewrite(); ok {
+		data = applyFieldNames(data, rewrite)
+	}
+	encoder := api.getEncoder()
+	defer api.putEncoder(encoder)
+	if err := encoder.Encode(data); err != nil {
+		return err
+	}
+	// Encode terminates its output with a newline that Marshal doesn't.
+	_, err := w.Write(bytes.TrimSuffix(encoder.buf.Bytes(), []byte("\n")))
+	return err
+}
@@ -0,0 +1,58 @@
+package sleepy
+
+import (
+	"errors"
+	"net/http"
+)
+
+// registeredError is an error given to RegisterError.
+type registeredError struct {
+	err     error
+	code    int
+	message string
+}
+
+// RegisterError maps errors matching sentinel, as errors.Is decides, to
+// a response with the given status code and a body of
+// {"error": message}, or problem details with SetProblemJSON. A
+// resource method can then report one by returning a code of 0 with the
+// error as its data,
+//
+//	return 0, ErrNotFound, nil
+//
+// and a HandlerFunc by returning the error. The first registration an
+// error matches is used. Errors returned this way that match no
+// registration are sent as a 500 Internal Server Error whose message is
+// only shown in debug mode.
+func (api *API) RegisterError(sentinel error, code int, message string) {
+	api.errors = append(api.errors, registeredError{err: sentinel, code: code, message: message})
+}
+
+// errorResponse returns the status and data of the response reporting
+// err.
+func (api *API) errorResponse(err error) (int, interface{}) {
+	for _, registered := range api.errors {
+		if errors.Is(err, registered.err) {
+			return registered.code, api.errorBody(registered.code, registered.message)
+		}
+	}
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.Code, api.errorBody(statusErr.Code, statusErr.Error())
+	}
+	message := "internal server error"
+	if api.debug {
+		message = err.Error()
+	}
+	return http.StatusInternalServerError, api.errorBody(http.StatusInternalServerError, message)
+}
+
+// errorBody returns the data of an error response with the given code
+// and message: {"error": message}, or problem details if SetProblemJSON
+// is on.
+func (api *API) errorBody(code int, message string) interface{} {
+	if api.problemJSON {
+		return errorProblem{Title: message, Status: code}
+	}
+	return map[string]string{"error": message}
+}
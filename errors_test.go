@@ -0,0 +1,69 @@
+package sleepy
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+var (
+	errAccountMissing = errors.New("account missing")
+	errAccountFrozen  = errors.New("account frozen")
+)
+
+type Ledger struct{}
+
+func (ledger Ledger) Get(values url.Values, headers http.Header) (int, interface{}, http.Header) {
+	switch values.Get("account") {
+	case "missing":
+		return 0, fmt.Errorf("looking up the ledger: %w", errAccountMissing), nil
+	case "frozen":
+		return 0, errAccountFrozen, nil
+	case "broken":
+		return 0, errors.New("disk on fire"), nil
+	}
+	return 200, "balanced", nil
+}
+
+func TestRegisterError(t *testing.T) {
+	api := NewAPI()
+	api.SetJSONOptions("", true)
+	api.RegisterError(errAccountMissing, http.StatusNotFound, "no such account")
+	api.RegisterError(errAccountFrozen, http.StatusConflict, "the account is frozen")
+	api.AddResource(new(Ledger), "/ledger")
+
+	for _, test := range []struct {
+		account string
+		code    int
+		body    string
+	}{
+		{"ok", 200, `"balanced"`},
+		{"missing", 404, `{"error":"no such account"}`},
+		{"frozen", 409, `{"error":"the account is frozen"}`},
+		{"broken", 500, `{"error":"internal server error"}`},
+	} {
+		recorder := httptest.NewRecorder()
+		api.ServeHTTP(recorder, httptest.NewRequest("GET", "/ledger?account="+test.account, nil))
+		if recorder.Code != test.code || recorder.Body.String() != test.body {
+			t.Errorf("%s: got %d %s, want %d %s", test.account, recorder.Code, recorder.Body.String(), test.code, test.body)
+		}
+	}
+}
+
+func TestRegisterErrorEndpoint(t *testing.T) {
+	api := NewAPI()
+	api.SetJSONOptions("", true)
+	api.RegisterError(errAccountMissing, http.StatusNotFound, "no such account")
+	api.AddResource(Get(func(r *Request[struct{}]) (*struct{}, error) {
+		return nil, errAccountMissing
+	}), "/account")
+
+	recorder := httptest.NewRecorder()
+	api.ServeHTTP(recorder, httptest.NewRequest("GET", "/account", nil))
+	if recorder.Code != 404 || recorder.Body.String() != `{"error":"no such account"}` {
+		t.Errorf("got %d %s", recorder.Code, recorder.Body.String())
+	}
+}
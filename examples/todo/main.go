@@ -0,0 +1,170 @@
+// Command todo serves a small TODO list API built with sleepy:
+//
+//	GET    /todos         lists the todos
+//	POST   /todos         creates one from a JSON body like {"title": "..."}
+//	GET    /todos/{id}    returns one
+//	PUT    /todos/{id}    replaces one from a JSON body
+//	DELETE /todos/{id}    deletes one
+//
+// Run it with
+//
+//	go run ./examples/todo
+//
+// and try
+//
+//	curl -d '{"title": "write docs"}' localhost:3000/todos
+//	curl localhost:3000/todos/1
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"sync"
+
+	"github.com/dougblack/sleepy"
+)
+
+// A Todo is one item on the list.
+type Todo struct {
+	ID    int    `json:"id"`
+	Title string `json:"title"`
+	Done  bool   `json:"done"`
+}
+
+// Errors the resources return, which newAPI maps to responses.
+var (
+	ErrNotFound     = errors.New("todo not found")
+	ErrTitleMissing = errors.New("title is required")
+	ErrBadBody      = errors.New("body must be a JSON todo")
+)
+
+// Todos is the collection of todos, served at /todos.
+type Todos struct {
+	store sleepy.MemoryStore[int, Todo]
+
+	mu     sync.Mutex
+	lastID int
+}
+
+// Get lists the todos in the order they were created.
+func (todos *Todos) Get(values url.Values, headers http.Header) (int, interface{}, http.Header) {
+	list := todos.store.List()
+	sort.Slice(list, func(i, j int) bool { return list[i].ID < list[j].ID })
+	return sleepy.OK(list)
+}
+
+// PostContext creates a todo from the JSON body.
+func (todos *Todos) PostContext(ctx context.Context, values url.Values, headers http.Header) (int, interface{}, http.Header) {
+	todo, err := decodeTodo(ctx)
+	if err != nil {
+		return 0, err, nil
+	}
+
+	todos.mu.Lock()
+	todos.lastID++
+	todo.ID = todos.lastID
+	todos.mu.Unlock()
+	todos.store.Set(todo.ID, todo)
+
+	return http.StatusCreated, todo, http.Header{"Location": {fmt.Sprintf("/todos/%d", todo.ID)}}
+}
+
+// TodoItem is a single todo, served at /todos/{id}.
+type TodoItem struct {
+	todos *Todos
+}
+
+// GetContext returns the todo.
+func (item TodoItem) GetContext(ctx context.Context, values url.Values, headers http.Header) (int, interface{}, http.Header) {
+	todo, err := item.find(ctx)
+	if err != nil {
+		return 0, err, nil
+	}
+	return sleepy.OK(todo)
+}
+
+// PutContext replaces the todo with the JSON body.
+func (item TodoItem) PutContext(ctx context.Context, values url.Values, headers http.Header) (int, interface{}, http.Header) {
+	existing, err := item.find(ctx)
+	if err != nil {
+		return 0, err, nil
+	}
+	todo, err := decodeTodo(ctx)
+	if err != nil {
+		return 0, err, nil
+	}
+	todo.ID = existing.ID
+	item.todos.store.Set(todo.ID, todo)
+	return sleepy.OK(todo)
+}
+
+// DeleteContext deletes the todo.
+func (item TodoItem) DeleteContext(ctx context.Context, values url.Values, headers http.Header) (int, interface{}, http.Header) {
+	todo, err := item.find(ctx)
+	if err != nil {
+		return 0, err, nil
+	}
+	item.todos.store.Delete(todo.ID)
+	return sleepy.NoContent()
+}
+
+// find returns the todo whose ID is the rest of the request path.
+func (item TodoItem) find(ctx context.Context) (Todo, error) {
+	id, err := strconv.Atoi(sleepy.Subpath(ctx))
+	if err != nil {
+		return Todo{}, &sleepy.StatusError{Code: http.StatusBadRequest, Err: fmt.Errorf("invalid todo ID %q", sleepy.Subpath(ctx))}
+	}
+	todo, ok := item.todos.store.Get(id)
+	if !ok {
+		return Todo{}, ErrNotFound
+	}
+	return todo, nil
+}
+
+// decodeTodo decodes and checks the todo in the request body.
+func decodeTodo(ctx context.Context) (Todo, error) {
+	var todo Todo
+	if err := sleepy.DecodeJSON(ctx, &todo); err != nil {
+		return Todo{}, ErrBadBody
+	}
+	if todo.Title == "" {
+		return Todo{}, ErrTitleMissing
+	}
+	return todo, nil
+}
+
+// noSniff is middleware that stops browsers from guessing at the
+// content type of responses.
+func noSniff(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, request *http.Request) {
+		rw.Header().Set("X-Content-Type-Options", "nosniff")
+		next.ServeHTTP(rw, request)
+	})
+}
+
+// newAPI returns the TODO list API, with an empty list.
+func newAPI() *sleepy.API {
+	api := sleepy.NewAPI()
+	api.RegisterError(ErrNotFound, http.StatusNotFound, "todo not found")
+	api.RegisterError(ErrTitleMissing, http.StatusUnprocessableEntity, "title is required")
+	api.RegisterError(ErrBadBody, http.StatusBadRequest, "body must be a JSON todo")
+	api.Use(noSniff)
+
+	todos := new(Todos)
+	api.AddResource(todos, "/todos")
+	api.AddDefaultResource(TodoItem{todos}, "/todos/")
+	return api
+}
+
+func main() {
+	api := newAPI()
+	api.EnableCommonLog(os.Stdout)
+	log.Fatal(api.Start(3000))
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/dougblack/sleepy/sleepytest"
+)
+
+func TestTodos(t *testing.T) {
+	test := sleepytest.New(t, newAPI())
+
+	test.GET("/todos").
+		AssertStatus(t, 200).
+		AssertHeader(t, "X-Content-Type-Options", "nosniff").
+		AssertJSONBody(t, `[]`)
+
+	test.POST("/todos", map[string]string{"title": "write docs"}).
+		AssertStatus(t, 201).
+		AssertHeader(t, "Location", "/todos/1").
+		AssertJSONBody(t, `{"id": 1, "title": "write docs", "done": false}`)
+	test.POST("/todos", map[string]string{"title": "ship it"}).AssertStatus(t, 201)
+
+	test.GET("/todos/1").
+		AssertStatus(t, 200).
+		AssertJSONBody(t, `{"id": 1, "title": "write docs", "done": false}`)
+	test.PUT("/todos/1", map[string]interface{}{"title": "write docs", "done": true}).
+		AssertStatus(t, 200).
+		AssertJSONBody(t, `{"id": 1, "title": "write docs", "done": true}`)
+	test.GET("/todos").
+		AssertStatus(t, 200).
+		AssertJSONBody(t, `[{"id": 1, "title": "write docs", "done": true}, {"id": 2, "title": "ship it", "done": false}]`)
+
+	test.DELETE("/todos/2").AssertStatus(t, 204)
+	test.GET("/todos/2").AssertStatus(t, 404).AssertJSONBody(t, `{"error": "todo not found"}`)
+}
+
+func TestTodoErrors(t *testing.T) {
+	test := sleepytest.New(t, newAPI())
+
+	test.POST("/todos", map[string]string{}).
+		AssertStatus(t, 422).
+		AssertJSONBody(t, `{"error": "title is required"}`)
+	test.POST("/todos", strings.NewReader("not json")).
+		AssertStatus(t, 400).
+		AssertJSONBody(t, `{"error": "body must be a JSON todo"}`)
+	test.GET("/todos/abc").
+		AssertStatus(t, 400).
+		AssertJSONBody(t, `{"error": "invalid todo ID \"abc\""}`)
+	test.PUT("/todos/9", map[string]string{"title": "nothing"}).AssertStatus(t, 404)
+	test.DELETE("/todos").AssertStatus(t, 405)
+}
@@ -0,0 +1,228 @@
+package sleepy
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"unicode"
+)
+
+// A FieldNamePolicy decides the JSON keys of struct fields that have no
+// name in a json tag.
+type FieldNamePolicy int
+
+const (
+	// GoFieldNames uses the Go field name unchanged, as encoding/json
+	// does. It is the default.
+	GoFieldNames FieldNamePolicy = iota
+
+	// SnakeCase converts field names to snake_case, so UserName becomes
+	// user_name and UserID becomes user_id.
+	SnakeCase
+)
+
+// SetFieldNamePolicy sets how the API names the JSON keys of struct
+// fields that have no name in a json tag. Fields with an explicit name
+// and values that implement json.Marshaler or encoding.TextMarshaler
+// are encoded as usual.
+func (api *API) SetFieldNamePolicy(policy FieldNamePolicy) {
+	api.fieldNames = policy
+}
+
+// SetOmitEmpty sets whether the API leaves struct fields with empty
+// values, such as 0, "", false, nil and empty slices and maps, out of
+// the JSON it encodes, as if every field were tagged omitempty; as with
+// the tag, structs such as a zero time.Time are kept. It is off by
+// default, so that fields are sent as null or zero unless their tags
+// say otherwise.
+func (api *API) SetOmitEmpty(omit bool) {
+	api.omitEmpty = omit
+}
+
+// fieldRewrite is how the API rewrites the structs in the data it
+// encodes as JSON: the policy naming their fields, and whether empty
+// fields are dropped.
+type fieldRewrite struct {
+	policy    FieldNamePolicy
+	omitEmpty bool
+}
+
+// jsonRewrite returns the API's rewrite, and whether it changes
+// anything encoding/json wouldn't.
+func (api *API) jsonRewrite() (fieldRewrite, bool) {
+	rewrite := fieldRewrite{policy: api.fieldNames, omitEmpty: api.omitEmpty}
+	return rewrite, rewrite != fieldRewrite{}
+}
+
+// name returns the JSON key for a field with the given Go name.
+func (policy FieldNamePolicy) name(field string) string {
+	if policy == SnakeCase {
+		return snakeCase(field)
+	}
+	return field
+}
+
+// snakeCase converts a CamelCase identifier to snake_case, keeping runs
+// of capitals such as "ID" or "HTTP" together as one word.
+func snakeCase(name string) string {
+	runes := []rune(name)
+	var b strings.Builder
+	for i, r := range runes {
+		if i > 0 && unicode.IsUpper(r) {
+			prev := runes[i-1]
+			nextLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if unicode.IsLower(prev) || unicode.IsDigit(prev) || (unicode.IsUpper(prev) && nextLower) {
+				b.WriteByte('_')
+			}
+		}
+		b.WriteRune(unicode.ToLower(r))
+	}
+	return b.String()
+}
+
+// objectField is one member of an object.
+type objectField struct {
+	key   string
+	value interface{}
+}
+
+// object is a JSON object whose members are encoded in order, as
+// encoding/json does for struct fields.
+type object []objectField
+
+func (o object) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	// The encoder this object is nested in escapes HTML if it should.
+	encoder.SetEscapeHTML(false)
+	buf.WriteByte('{')
+	for i, field := range o {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		if err := encoder.Encode(field.key); err != nil {
+			return nil, err
+		}
+		buf.WriteByte(':')
+		if err := encoder.Encode(field.value); err != nil {
+			return nil, err
+		}
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+var (
+	jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+	interfaceType     = reflect.TypeOf((*interface{})(nil)).Elem()
+)
+
+// applyFieldNames returns a copy of data in which every struct has been
+// replaced with an object keyed according to rewrite's policy, without
+// its empty fields if rewrite omits them.
+func applyFieldNames(data interface{}, rewrite fieldRewrite) interface{} {
+	return renameFields(reflect.ValueOf(data), rewrite)
+}
+
+func renameFields(value reflect.Value, rewrite fieldRewrite) interface{} {
+	if !value.IsValid() {
+		return nil
+	}
+	if value.Type().Implements(jsonMarshalerType) || value.Type().Implements(textMarshalerType) {
+		return value.Interface()
+	}
+
+	switch value.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if value.IsNil() {
+			return nil
+		}
+		return renameFields(value.Elem(), rewrite)
+	case reflect.Struct:
+		return structObject(value, rewrite)
+	case reflect.Map:
+		if value.IsNil() {
+			return nil
+		}
+		renamed := reflect.MakeMapWithSize(reflect.MapOf(value.Type().Key(), interfaceType), value.Len())
+		iter := value.MapRange()
+		for iter.Next() {
+			element := reflect.ValueOf(renameFields(iter.Value(), rewrite))
+			if !element.IsValid() {
+				element = reflect.Zero(interfaceType)
+			}
+			renamed.SetMapIndex(iter.Key(), element)
+		}
+		return renamed.Interface()
+	case reflect.Slice, reflect.Array:
+		if value.Kind() == reflect.Slice && value.IsNil() {
+			return nil
+		}
+		if value.Type().Elem().Kind() == reflect.Uint8 {
+			// Byte slices are encoded as base64 strings.
+			return value.Interface()
+		}
+		renamed := make([]interface{}, value.Len())
+		for i := range renamed {
+			renamed[i] = renameFields(value.Index(i), rewrite)
+		}
+		return renamed
+	}
+	return value.Interface()
+}
+
+// structObject converts a struct to an object, following the json tags
+// of its fields and flattening untagged embedded structs.
+func structObject(value reflect.Value, rewrite fieldRewrite) object {
+	o := object{}
+	t := value.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, options, _ := strings.Cut(tag, ",")
+
+		fieldValue := value.Field(i)
+		if field.Anonymous && name == "" {
+			embedded := fieldValue
+			if embedded.Kind() == reflect.Pointer {
+				if embedded.IsNil() {
+					continue
+				}
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				o = append(o, structObject(embedded, rewrite)...)
+				continue
+			}
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if (rewrite.omitEmpty || strings.Contains(options, "omitempty")) && isEmptyValue(fieldValue) {
+			continue
+		}
+		if name == "" {
+			name = rewrite.policy.name(field.Name)
+		}
+		o = append(o, objectField{name, renameFields(fieldValue, rewrite)})
+	}
+	return o
+}
+
+// isEmptyValue reports whether omitempty drops value, by the same
+// rules as encoding/json.
+func isEmptyValue(value reflect.Value) bool {
+	switch value.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return value.Len() == 0
+	case reflect.Struct:
+		return false
+	}
+	return value.IsZero()
+}
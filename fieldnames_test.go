@@ -0,0 +1,102 @@
+package sleepy
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+)
+
+type Base struct {
+	CreatedAt time.Time
+}
+
+type Profile struct {
+	Base
+	UserName  string
+	UserID    int
+	Nickname  string `json:"nick,omitempty"`
+	Secret    string `json:"-"`
+	HTTPProxy *string
+	Tags      []string `json:",omitempty"`
+	Links     map[string]Link
+}
+
+type Link struct {
+	TargetURL string
+}
+
+func (profile Profile) Get(values url.Values, headers http.Header) (int, interface{}, http.Header) {
+	return 200, profile, nil
+}
+
+func TestSetFieldNamePolicy(t *testing.T) {
+	profile := Profile{
+		Base:     Base{CreatedAt: time.Date(2014, 1, 2, 3, 4, 5, 0, time.UTC)},
+		UserName: "doug",
+		UserID:   7,
+		Secret:   "hunter2",
+		Links:    map[string]Link{"home": {TargetURL: "/"}},
+	}
+
+	api := NewAPI()
+	api.SetJSONOptions("", true)
+	api.AddResource(profile, "/profile")
+
+	get := func() string {
+		recorder := httptest.NewRecorder()
+		api.ServeHTTP(recorder, httptest.NewRequest("GET", "/profile", nil))
+		return recorder.Body.String()
+	}
+
+	expected := `{"CreatedAt":"2014-01-02T03:04:05Z","UserName":"doug","UserID":7,"HTTPProxy":null,"Links":{"home":{"TargetURL":"/"}}}`
+	if body := get(); body != expected {
+		t.Errorf("default policy: got %s, want %s", body, expected)
+	}
+
+	api.SetFieldNamePolicy(SnakeCase)
+	expected = `{"created_at":"2014-01-02T03:04:05Z","user_name":"doug","user_id":7,"http_proxy":null,"links":{"home":{"target_url":"/"}}}`
+	if body := get(); body != expected {
+		t.Errorf("snake case: got %s, want %s", body, expected)
+	}
+}
+
+func TestSetOmitEmpty(t *testing.T) {
+	profile := Profile{UserName: "", UserID: 0, Links: map[string]Link{"home": {TargetURL: "/"}}}
+	api := NewAPI()
+	api.SetJSONOptions("", true)
+	api.AddResource(profile, "/profile")
+
+	get := func() string {
+		recorder := httptest.NewRecorder()
+		api.ServeHTTP(recorder, httptest.NewRequest("GET", "/profile", nil))
+		return recorder.Body.String()
+	}
+
+	expected := `{"CreatedAt":"0001-01-01T00:00:00Z","UserName":"","UserID":0,"HTTPProxy":null,"Links":{"home":{"TargetURL":"/"}}}`
+	if body := get(); body != expected {
+		t.Errorf("disabled: got %s, want %s", body, expected)
+	}
+
+	api.SetOmitEmpty(true)
+	expected = `{"CreatedAt":"0001-01-01T00:00:00Z","Links":{"home":{"TargetURL":"/"}}}`
+	if body := get(); body != expected {
+		t.Errorf("enabled: got %s, want %s", body, expected)
+	}
+}
+
+func TestSnakeCase(t *testing.T) {
+	for name, expected := range map[string]string{
+		"UserName":   "user_name",
+		"ID":         "id",
+		"UserID":     "user_id",
+		"HTTPServer": "http_server",
+		"Page2Count": "page2_count",
+		"already":    "already",
+	} {
+		if got := snakeCase(name); got != expected {
+			t.Errorf("snakeCase(%q) = %q, want %q", name, got, expected)
+		}
+	}
+}
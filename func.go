@@ -0,0 +1,43 @@
+package sleepy
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+)
+
+// AddResourceFunc adds fn as the handler for one HTTP method at path,
+// for endpoints too small to deserve a resource type. Requests for any
+// other method get a 405 Method Not Allowed. Calling AddResourceFunc
+// again with the same path and a different method adds that method to
+// the same endpoint.
+func (api *API) AddResourceFunc(method, path string, fn func(url.Values) (int, interface{})) {
+	api.addMethod(method, path, func(ctx context.Context, values url.Values, header http.Header) (int, interface{}, http.Header) {
+		code, data := fn(values)
+		return code, data, nil
+	})
+}
+
+// HandleFunc adds fn as the handler for one HTTP method at path, like
+// AddResourceFunc, for handlers that want the whole request rather than
+// just its values. The request's form has already been parsed when fn
+// is called. Handlers added with HandleFunc and AddResourceFunc for
+// different methods at the same path share one endpoint.
+func (api *API) HandleFunc(method, path string, fn func(*http.Request) (int, interface{})) {
+	api.addMethod(method, path, func(ctx context.Context, values url.Values, header http.Header) (int, interface{}, http.Header) {
+		code, data := fn(RequestFromContext(ctx))
+		return code, data, nil
+	})
+}
+
+// addMethod registers handler for method at path, extending the
+// methodSet already registered there if there is one.
+func (api *API) addMethod(method, path string, handler handlerFunc) {
+	for _, r := range api.routes {
+		if set, ok := r.current().(methodSet); ok && r.path == path {
+			set[method] = handler
+			return
+		}
+	}
+	api.AddResource(methodSet{method: handler}, path)
+}
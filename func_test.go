@@ -0,0 +1,60 @@
+package sleepy
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+func TestAddResourceFunc(t *testing.T) {
+	api := NewAPI()
+	api.AddResourceFunc(GET, "/ping", func(values url.Values) (int, interface{}) {
+		return 200, "pong " + values.Get("name")
+	})
+
+	recorder := httptest.NewRecorder()
+	api.ServeHTTP(recorder, httptest.NewRequest("GET", "/ping?name=doug", nil))
+	if recorder.Code != 200 || recorder.Body.String() != `"pong doug"` {
+		t.Errorf("GET: got %d %q", recorder.Code, recorder.Body.String())
+	}
+
+	recorder = httptest.NewRecorder()
+	api.ServeHTTP(recorder, httptest.NewRequest("POST", "/ping", nil))
+	if recorder.Code != 405 || recorder.Header().Get("Allow") != "GET" {
+		t.Errorf("POST: got %d Allow=%q", recorder.Code, recorder.Header().Get("Allow"))
+	}
+
+	api.AddResourceFunc(POST, "/ping", func(values url.Values) (int, interface{}) {
+		return 201, "posted"
+	})
+	recorder = httptest.NewRecorder()
+	api.ServeHTTP(recorder, httptest.NewRequest("POST", "/ping", nil))
+	if recorder.Code != 201 {
+		t.Errorf("POST after adding it: got %d", recorder.Code)
+	}
+}
+
+func TestHandleFunc(t *testing.T) {
+	api := NewAPI()
+	api.HandleFunc(GET, "/whoami", func(request *http.Request) (int, interface{}) {
+		return 200, request.Header.Get("X-User") + " " + request.FormValue("format")
+	})
+	api.AddResourceFunc(DELETE, "/whoami", func(values url.Values) (int, interface{}) {
+		return 204, nil
+	})
+
+	request := httptest.NewRequest("GET", "/whoami?format=short", nil)
+	request.Header.Set("X-User", "doug")
+	recorder := httptest.NewRecorder()
+	api.ServeHTTP(recorder, request)
+	if recorder.Code != 200 || recorder.Body.String() != `"doug short"` {
+		t.Errorf("GET: got %d %q", recorder.Code, recorder.Body.String())
+	}
+
+	recorder = httptest.NewRecorder()
+	api.ServeHTTP(recorder, httptest.NewRequest("POST", "/whoami", nil))
+	if recorder.Code != 405 || recorder.Header().Get("Allow") != "GET, DELETE" {
+		t.Errorf("POST: got %d Allow=%q", recorder.Code, recorder.Header().Get("Allow"))
+	}
+}
@@ -0,0 +1,21 @@
+package sleepy
+
+import "net/http"
+
+// AddResourceGated adds resource at path like AddResource, but serves
+// it only while enabled returns true, for switching a resource on and
+// off with a live feature flag. enabled is called for every request;
+// while it returns false the path answers with the standard library's
+// 404, as though nothing were registered there. The resource still
+// appears in Routes and Resources, and its lifecycle methods still run.
+func (api *API) AddResourceGated(resource interface{}, path string, enabled func() bool) {
+	api.AddResourceWithOptions(resource, path, WithMiddleware(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(rw http.ResponseWriter, request *http.Request) {
+			if !enabled() {
+				http.NotFound(rw, request)
+				return
+			}
+			next.ServeHTTP(rw, request)
+		})
+	}))
+}
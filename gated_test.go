@@ -0,0 +1,39 @@
+package sleepy
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+)
+
+type Beta struct{}
+
+func (beta Beta) Get(values url.Values, headers http.Header) (int, interface{}, http.Header) {
+	return 200, "beta", nil
+}
+
+func TestAddResourceGated(t *testing.T) {
+	var enabled atomic.Bool
+	enabled.Store(true)
+
+	api := NewAPI()
+	api.AddResourceGated(new(Beta), "/beta", enabled.Load)
+
+	for _, c := range []struct {
+		enabled bool
+		code    int
+	}{
+		{true, http.StatusOK},
+		{false, http.StatusNotFound},
+		{true, http.StatusOK},
+	} {
+		enabled.Store(c.enabled)
+		recorder := httptest.NewRecorder()
+		api.ServeHTTP(recorder, httptest.NewRequest("GET", "/beta", nil))
+		if recorder.Code != c.code {
+			t.Errorf("enabled=%t: got %d, want %d", c.enabled, recorder.Code, c.code)
+		}
+	}
+}
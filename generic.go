@@ -0,0 +1,135 @@
+package sleepy
+
+import (
+	"context"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"reflect"
+)
+
+// A Request is what a HandlerFunc is given: the request's parameters
+// decoded into a T, along with the raw values and headers.
+type Request[T any] struct {
+	// Params holds the request's parameters. A JSON body is decoded
+	// into it; otherwise it is filled from the query string and form
+	// fields, matched to the fields of a struct by their query tags,
+	// as Bind does, or else by their JSON names.
+	Params T
+	Values url.Values
+	Header http.Header
+
+	ctx context.Context
+}
+
+// Context returns the request's context, which can be passed to
+// helpers such as RequestFromContext and DecodeJSON.
+func (request *Request[T]) Context() context.Context {
+	return request.ctx
+}
+
+// A HandlerFunc handles one HTTP method of an Endpoint. The response it
+// returns is encoded like the data a resource method returns, and a nil
+// response is sent as a 204 No Content. An error is reported with the
+// status registered for it with RegisterError, or that of a
+// *StatusError, or else as a 500 Internal Server Error whose message is
+// only shown in debug mode.
+type HandlerFunc[Req, Resp any] func(*Request[Req]) (*Resp, error)
+
+// A StatusError is an error a HandlerFunc returns to respond with Code
+// and a body of {"error": "..."} carrying the message of Err.
+type StatusError struct {
+	Code int
+	Err  error
+}
+
+func (err *StatusError) Error() string {
+	return err.Err.Error()
+}
+
+func (err *StatusError) Unwrap() error {
+	return err.Err
+}
+
+// An Endpoint is a resource made of one HandlerFunc per HTTP method,
+// for endpoints that need no type of their own:
+//
+//	api.AddResource(sleepy.Endpoints(
+//		sleepy.Get(func(r *sleepy.Request[UserQuery]) (*User, error) { ... }),
+//		sleepy.Post(func(r *sleepy.Request[NewUser]) (*User, error) { ... }),
+//	), "/users")
+type Endpoint struct {
+	set methodSet
+}
+
+// Endpoints combines endpoints into one that supports all of their
+// methods. A later endpoint's handler for a method replaces an earlier
+// one's.
+func Endpoints(endpoints ...Endpoint) Endpoint {
+	set := make(methodSet)
+	for _, endpoint := range endpoints {
+		for method, handler := range endpoint.set {
+			set[method] = handler
+		}
+	}
+	return Endpoint{set}
+}
+
+// Get returns an Endpoint that handles GET requests with fn.
+func Get[Req, Resp any](fn HandlerFunc[Req, Resp]) Endpoint {
+	return endpoint(GET, fn)
+}
+
+// Post returns an Endpoint that handles POST requests with fn.
+func Post[Req, Resp any](fn HandlerFunc[Req, Resp]) Endpoint {
+	return endpoint(POST, fn)
+}
+
+// Put returns an Endpoint that handles PUT requests with fn.
+func Put[Req, Resp any](fn HandlerFunc[Req, Resp]) Endpoint {
+	return endpoint(PUT, fn)
+}
+
+// Delete returns an Endpoint that handles DELETE requests with fn.
+func Delete[Req, Resp any](fn HandlerFunc[Req, Resp]) Endpoint {
+	return endpoint(DELETE, fn)
+}
+
+// Patch returns an Endpoint that handles PATCH requests with fn.
+func Patch[Req, Resp any](fn HandlerFunc[Req, Resp]) Endpoint {
+	return endpoint(PATCH, fn)
+}
+
+// endpoint returns an Endpoint that handles method with fn.
+func endpoint[Req, Resp any](method string, fn HandlerFunc[Req, Resp]) Endpoint {
+	return Endpoint{methodSet{method: func(ctx context.Context, values url.Values, header http.Header) (int, interface{}, http.Header) {
+		request := &Request[Req]{Values: values, Header: header, ctx: ctx}
+		if err := decodeParams(ctx, values, &request.Params); err != nil {
+			return BadRequest(err)
+		}
+
+		response, err := fn(request)
+		if err != nil {
+			return 0, err, nil
+		}
+		if response == nil {
+			return NoContent()
+		}
+		return http.StatusOK, response, nil
+	}}}
+}
+
+// decodeParams decodes the request's JSON body into params, or fills
+// params from values if the body is not JSON.
+func decodeParams(ctx context.Context, values url.Values, params interface{}) error {
+	if request := RequestFromContext(ctx); request != nil {
+		if mediaType, _, _ := mime.ParseMediaType(request.Header.Get("Content-Type")); mediaType == jsonContentType {
+			if err := DecodeJSON(ctx, params); err != nil && err != io.EOF {
+				return err
+			}
+			return nil
+		}
+	}
+	return bindValues(values, reflect.ValueOf(params).Elem(), false)
+}
@@ -0,0 +1,101 @@
+package sleepy
+
+import (
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type UserQuery struct {
+	Name  string   `json:"name"`
+	Limit int      `json:"limit"`
+	Tags  []string `json:"tag"`
+}
+
+type UserList struct {
+	Names []string `json:"names"`
+}
+
+type NewUser struct {
+	Name string `json:"name"`
+}
+
+var errNoSuchUser = errors.New("no such user")
+
+func users() Endpoint {
+	return Endpoints(
+		Get(func(r *Request[UserQuery]) (*UserList, error) {
+			if r.Params.Name == "nobody" {
+				return nil, &StatusError{Code: 404, Err: errNoSuchUser}
+			}
+			names := []string{}
+			for i := 0; i < r.Params.Limit; i++ {
+				names = append(names, r.Params.Name+strings.Join(r.Params.Tags, "+"))
+			}
+			return &UserList{names}, nil
+		}),
+		Post(func(r *Request[NewUser]) (*NewUser, error) {
+			if r.Params.Name == "" {
+				return nil, errors.New("database unreachable")
+			}
+			return &r.Params, nil
+		}),
+		Delete(func(r *Request[struct{}]) (*struct{}, error) {
+			return nil, nil
+		}),
+	)
+}
+
+func TestEndpoints(t *testing.T) {
+	api := NewAPI()
+	api.SetJSONOptions("", true)
+	api.AddResource(users(), "/users")
+
+	for _, test := range []struct {
+		method, target, body string
+		code                 int
+		response             string
+	}{
+		{"GET", "/users?name=ann&limit=2&tag=a&tag=b", "", 200, `{"names":["anna+b","anna+b"]}`},
+		{"GET", "/users?limit=lots", "", 400, `{"error":"parameter limit is \"lots\", not an integer"}`},
+		{"GET", "/users?name=nobody", "", 404, `{"error":"no such user"}`},
+		{"POST", "/users", `{"name":"bob"}`, 200, `{"name":"bob"}`},
+		{"POST", "/users", `{"name":`, 400, `{"error":"unexpected EOF"}`},
+		{"POST", "/users", `{}`, 500, `{"error":"internal server error"}`},
+		{"DELETE", "/users", "", 204, ""},
+		{"PUT", "/users", "", 405, ""},
+	} {
+		request := httptest.NewRequest(test.method, test.target, strings.NewReader(test.body))
+		if test.body != "" {
+			request.Header.Set("Content-Type", "application/json")
+		}
+		recorder := httptest.NewRecorder()
+		api.ServeHTTP(recorder, request)
+		if recorder.Code != test.code || recorder.Body.String() != test.response {
+			t.Errorf("%s %s: got %d %s, want %d %s", test.method, test.target, recorder.Code, recorder.Body.String(), test.code, test.response)
+		}
+	}
+}
+
+func TestEndpointsRoutes(t *testing.T) {
+	api := NewAPI()
+	api.AddResource(users(), "/users")
+	if methods := strings.Join(api.Routes()[0].Methods, ","); methods != "GET,POST,DELETE" {
+		t.Errorf("methods = %s, want GET,POST,DELETE", methods)
+	}
+}
+
+func TestEndpointFormParams(t *testing.T) {
+	api := NewAPI()
+	api.SetJSONOptions("", true)
+	api.AddResource(users(), "/users")
+
+	request := httptest.NewRequest("POST", "/users", strings.NewReader("name=carol"))
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	recorder := httptest.NewRecorder()
+	api.ServeHTTP(recorder, request)
+	if recorder.Code != 200 || recorder.Body.String() != `{"name":"carol"}` {
+		t.Errorf("got %d %s", recorder.Code, recorder.Body.String())
+	}
+}
@@ -0,0 +1,74 @@
+package sleepy
+
+import (
+	"context"
+	"net/http"
+	"strings"
+)
+
+// A Group registers resources under a common path prefix, such as an
+// API version, with middleware of its own.
+type Group struct {
+	api        *API
+	prefix     string
+	middleware []func(http.Handler) http.Handler
+	values     []groupValue
+}
+
+// groupValue is a value attached to the requests of a group.
+type groupValue struct {
+	key, value interface{}
+}
+
+// Group returns a Group whose resources are added to the API under
+// prefix, so that
+//
+//	v1 := api.Group("/v1")
+//	v1.AddResource(users, "/users")
+//
+// serves users at /v1/users.
+func (api *API) Group(prefix string) *Group {
+	return &Group{api: api, prefix: strings.TrimSuffix(prefix, "/")}
+}
+
+// AddResource adds resource to the API at each of paths below the
+// group's prefix.
+func (g *Group) AddResource(resource interface{}, paths ...string) {
+	for _, path := range paths {
+		r := route{path: g.prefix + path, resource: resource}
+		r.middleware = []func(http.Handler) http.Handler{g.handler}
+		g.api.mustRegisterRoute(r, g.api.resourceHandler)
+	}
+}
+
+// Use appends middleware to the chain that wraps requests to the
+// group's resources, including those added later. It runs inside the
+// API-wide middleware. The first middleware given is the outermost.
+func (g *Group) Use(middleware ...func(http.Handler) http.Handler) {
+	g.middleware = append(g.middleware, middleware...)
+}
+
+// WithValue associates value with key in the context of every request
+// to the group's resources, including those added later, for sharing a
+// dependency built once, such as an admin service, among them without
+// exposing it to the rest of the API. Resources and the group's
+// middleware read it with the context's Value method. key follows the
+// rules of context.WithValue.
+func (g *Group) WithValue(key, value interface{}) {
+	g.values = append(g.values, groupValue{key: key, value: value})
+}
+
+// handler wraps next in the group's middleware as it stands when each
+// request arrives, with the group's values in the request context.
+func (g *Group) handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, request *http.Request) {
+		if len(g.values) > 0 {
+			ctx := request.Context()
+			for _, v := range g.values {
+				ctx = context.WithValue(ctx, v.key, v.value)
+			}
+			request = request.WithContext(ctx)
+		}
+		chain(next, g.middleware).ServeHTTP(rw, request)
+	})
+}
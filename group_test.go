@@ -0,0 +1,79 @@
+package sleepy
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"testing"
+)
+
+func TestGroup(t *testing.T) {
+	api := NewAPI()
+	v1 := api.Group("/v1")
+	v1.AddResource(new(User), "/users")
+	v2 := api.Group("/v2/")
+	v2.AddResource(new(Item), "/items")
+	v1.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(rw http.ResponseWriter, request *http.Request) {
+			rw.Header().Set("X-Version", "1")
+			next.ServeHTTP(rw, request)
+		})
+	})
+
+	for _, test := range []struct {
+		path    string
+		code    int
+		version string
+	}{
+		{"/v1/users", 200, "1"},
+		{"/users", 404, ""},
+		{"/v2/items", 200, ""},
+	} {
+		recorder := httptest.NewRecorder()
+		api.ServeHTTP(recorder, httptest.NewRequest("GET", test.path, nil))
+		if recorder.Code != test.code || recorder.Header().Get("X-Version") != test.version {
+			t.Errorf("%s: got %d with X-Version %q, want %d with %q", test.path, recorder.Code, recorder.Header().Get("X-Version"), test.code, test.version)
+		}
+	}
+
+	if routes := api.Routes(); len(routes) != 2 || routes[0].Path != "/v1/users" || routes[1].Path != "/v2/items" {
+		t.Errorf("routes = %v", routes)
+	}
+}
+
+type auditLogKey struct{}
+
+type AuditedResource struct{}
+
+func (resource AuditedResource) GetContext(ctx context.Context, values url.Values, headers http.Header) (int, interface{}, http.Header) {
+	log, _ := ctx.Value(auditLogKey{}).(*[]string)
+	if log == nil {
+		return 200, "unaudited", nil
+	}
+	*log = append(*log, RequestFromContext(ctx).URL.Path)
+	return 200, "audited", nil
+}
+
+func TestGroupWithValue(t *testing.T) {
+	var adminLog, billingLog []string
+	api := NewAPI()
+	admin := api.Group("/admin")
+	admin.WithValue(auditLogKey{}, &adminLog)
+	admin.AddResource(new(AuditedResource), "/users", "/settings")
+	billing := api.Group("/billing")
+	billing.WithValue(auditLogKey{}, &billingLog)
+	billing.AddResource(new(AuditedResource), "/invoices")
+	api.AddResource(new(AuditedResource), "/public")
+
+	for _, path := range []string{"/admin/users", "/billing/invoices", "/admin/settings", "/public"} {
+		api.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", path, nil))
+	}
+	if !reflect.DeepEqual(adminLog, []string{"/admin/users", "/admin/settings"}) {
+		t.Errorf("admin log = %v", adminLog)
+	}
+	if !reflect.DeepEqual(billingLog, []string{"/billing/invoices"}) {
+		t.Errorf("billing log = %v", billingLog)
+	}
+}
@@ -0,0 +1,216 @@
+package sleepy
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strings"
+)
+
+// A GRPCGatewayConfig controls how a GRPCGatewayResource converts
+// between JSON and protocol buffer messages.
+type GRPCGatewayConfig struct {
+	// UnmarshalJSON decodes a JSON request body into a message, and
+	// MarshalJSON encodes a response message as JSON. Left nil, messages
+	// are converted with encoding/json through the JSON names of their
+	// fields, as the API encodes any other data; set them to the
+	// functions of the protojson package for the canonical protobuf JSON
+	// mapping. With google.golang.org/protobuf:
+	//
+	//	sleepy.GRPCGatewayConfig{
+	//		UnmarshalJSON: func(b []byte, m sleepy.ProtoMessage) error { return protojson.Unmarshal(b, m.(proto.Message)) },
+	//		MarshalJSON:   func(m sleepy.ProtoMessage) ([]byte, error) { return protojson.Marshal(m.(proto.Message)) },
+	//	}
+	UnmarshalJSON func([]byte, ProtoMessage) error
+	MarshalJSON   func(ProtoMessage) ([]byte, error)
+}
+
+// A GRPCMethod is a unary gRPC method that a GRPCGatewayResource calls
+// for one HTTP method. GRPCUnary makes one.
+type GRPCMethod struct {
+	method string
+	call   func(ctx context.Context, config GRPCGatewayConfig, request *http.Request, values url.Values) (int, interface{}, http.Header)
+}
+
+// GRPCUnary returns the GRPCMethod that answers requests with the given
+// HTTP method by calling call, usually a method of a gRPC client stub
+// with its call options left off:
+//
+//	sleepy.GRPCUnary(sleepy.GET, func(ctx context.Context, in *pb.GetUserRequest) (*pb.User, error) {
+//		return client.GetUser(ctx, in)
+//	})
+//
+// Req must be a pointer to a message struct.
+func GRPCUnary[Req, Resp ProtoMessage](method string, call func(context.Context, Req) (Resp, error)) GRPCMethod {
+	return GRPCMethod{method: method, call: func(ctx context.Context, config GRPCGatewayConfig, request *http.Request, values url.Values) (int, interface{}, http.Header) {
+		var in Req
+		in = reflect.New(reflect.TypeOf(in).Elem()).Interface().(Req)
+		if err := transcodeRequest(config, request, values, in); err != nil {
+			return BadRequest(err)
+		}
+
+		out, err := call(ctx, in)
+		if err != nil {
+			return 0, grpcError(err), nil
+		}
+		if config.MarshalJSON == nil {
+			return http.StatusOK, out, nil
+		}
+		body, err := config.MarshalJSON(out)
+		if err != nil {
+			return 0, err, nil
+		}
+		return http.StatusOK, json.RawMessage(body), nil
+	}}
+}
+
+// GRPCGatewayResource returns a resource that transcodes HTTP/JSON
+// requests into calls of unary gRPC methods, one for each HTTP method
+// it supports, in the manner of grpc-gateway, for serving gRPC services
+// to REST clients:
+//
+//	api.AddResource(sleepy.GRPCGatewayResource(sleepy.GRPCGatewayConfig{},
+//		sleepy.GRPCUnary(sleepy.GET, getUser),
+//		sleepy.GRPCUnary(sleepy.PATCH, updateUser),
+//	), "/users/{id}")
+//
+// The request message is decoded from the JSON body, if there is one,
+// and then any of its fields named, by their JSON names, in the path
+// parameters or the query string are set from them, the path taking
+// precedence. The response message is sent as JSON, or as protobuf to
+// clients that ask for it once SetProtoCodec is set. An error carrying
+// a gRPC status, as the errors of gRPC clients do, is answered with the
+// HTTP status grpc-gateway uses for its code and its message; any other
+// error is handled as a resource method's error would be.
+func GRPCGatewayResource(config GRPCGatewayConfig, methods ...GRPCMethod) Endpoint {
+	set := make(methodSet)
+	for _, m := range methods {
+		call := m.call
+		set[m.method] = func(ctx context.Context, values url.Values, header http.Header) (int, interface{}, http.Header) {
+			request := RequestFromContext(ctx)
+			if request == nil {
+				return 0, errors.New("sleepy: no request in context"), nil
+			}
+			return call(ctx, config, request, values)
+		}
+	}
+	return Endpoint{set}
+}
+
+// transcodeRequest fills in the message in from the body, path
+// parameters and query of request.
+func transcodeRequest(config GRPCGatewayConfig, request *http.Request, values url.Values, in ProtoMessage) error {
+	var body []byte
+	var err error
+	if request.Body != nil {
+		if body, err = io.ReadAll(request.Body); err != nil {
+			return err
+		}
+	}
+	if len(body) > 0 {
+		if config.UnmarshalJSON != nil {
+			err = config.UnmarshalJSON(body, in)
+		} else {
+			err = json.Unmarshal(body, in)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	message := reflect.ValueOf(in).Elem()
+	if message.Kind() != reflect.Struct {
+		return nil
+	}
+	params := make(url.Values, len(values))
+	for name, given := range values {
+		params[name] = given
+	}
+	for i := 0; i < message.NumField(); i++ {
+		field := message.Type().Field(i)
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "" {
+			name = field.Name
+		}
+		if value := request.PathValue(name); value != "" {
+			params[name] = []string{value}
+		}
+	}
+	return bindValues(params, message, false)
+}
+
+// grpcStatusHTTP maps gRPC status codes to HTTP statuses, as
+// grpc-gateway does.
+var grpcStatusHTTP = []int{
+	http.StatusOK,                  // OK
+	StatusClientClosedRequest,      // Canceled
+	http.StatusInternalServerError, // Unknown
+	http.StatusBadRequest,          // InvalidArgument
+	http.StatusGatewayTimeout,      // DeadlineExceeded
+	http.StatusNotFound,            // NotFound
+	http.StatusConflict,            // AlreadyExists
+	http.StatusForbidden,           // PermissionDenied
+	http.StatusTooManyRequests,     // ResourceExhausted
+	http.StatusBadRequest,          // FailedPrecondition
+	http.StatusConflict,            // Aborted
+	http.StatusBadRequest,          // OutOfRange
+	http.StatusNotImplemented,      // Unimplemented
+	http.StatusInternalServerError, // Internal
+	http.StatusServiceUnavailable,  // Unavailable
+	http.StatusInternalServerError, // DataLoss
+	http.StatusUnauthorized,        // Unauthenticated
+}
+
+// grpcError returns the error to respond with for err, an error from a
+// gRPC call. An error with a gRPC status becomes a StatusError with the
+// corresponding HTTP status and the status's message.
+func grpcError(err error) error {
+	code, message, ok := grpcStatus(err)
+	if !ok {
+		return err
+	}
+	status := http.StatusInternalServerError
+	if code < uint64(len(grpcStatusHTTP)) {
+		status = grpcStatusHTTP[code]
+	}
+	return &StatusError{Code: status, Err: fmt.Errorf("%s", message)}
+}
+
+// grpcStatus finds the gRPC status of err or an error it wraps. gRPC
+// errors have a GRPCStatus method whose result has Code and Message
+// methods; they are called by reflection so that the package need not
+// depend on gRPC.
+func grpcStatus(err error) (code uint64, message string, ok bool) {
+	for ; err != nil; err = errors.Unwrap(err) {
+		method := reflect.ValueOf(err).MethodByName("GRPCStatus")
+		if !method.IsValid() || method.Type().NumIn() != 0 || method.Type().NumOut() != 1 {
+			continue
+		}
+		status := method.Call(nil)[0]
+		if status.Kind() == reflect.Pointer && status.IsNil() {
+			continue
+		}
+		codeMethod, messageMethod := status.MethodByName("Code"), status.MethodByName("Message")
+		if !codeMethod.IsValid() || !messageMethod.IsValid() ||
+			codeMethod.Type().NumIn() != 0 || codeMethod.Type().NumOut() != 1 ||
+			messageMethod.Type().NumIn() != 0 || messageMethod.Type().NumOut() != 1 {
+			continue
+		}
+		c, m := codeMethod.Call(nil)[0], messageMethod.Call(nil)[0]
+		switch c.Kind() {
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		default:
+			continue
+		}
+		if m.Kind() != reflect.String {
+			continue
+		}
+		return c.Uint(), m.String(), true
+	}
+	return 0, "", false
+}
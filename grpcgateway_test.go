@@ -0,0 +1,120 @@
+package sleepy
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// UpdateShelfRequest and Shelf stand in for generated messages.
+type UpdateShelfRequest struct {
+	ID    int64  `json:"id"`
+	Theme string `json:"theme"`
+	Dry   bool   `json:"dry"`
+}
+
+func (m *UpdateShelfRequest) Reset()         { *m = UpdateShelfRequest{} }
+func (m *UpdateShelfRequest) String() string { return m.Theme }
+func (m *UpdateShelfRequest) ProtoMessage()  {}
+
+type Shelf struct {
+	ID    int64  `json:"id"`
+	Theme string `json:"theme"`
+}
+
+func (m *Shelf) Reset()         { *m = Shelf{} }
+func (m *Shelf) String() string { return m.Theme }
+func (m *Shelf) ProtoMessage()  {}
+
+// fakeStatus and fakeStatusError mimic the status.Status of gRPC and
+// the errors its clients return.
+type fakeStatus struct {
+	code    uint32
+	message string
+}
+
+func (s *fakeStatus) Code() uint32    { return s.code }
+func (s *fakeStatus) Message() string { return s.message }
+
+type fakeStatusError struct{ status *fakeStatus }
+
+func (e fakeStatusError) Error() string           { return e.status.message }
+func (e fakeStatusError) GRPCStatus() *fakeStatus { return e.status }
+
+// shelfClient stands in for a gRPC client stub.
+type shelfClient struct{}
+
+func (c shelfClient) GetShelf(ctx context.Context, in *UpdateShelfRequest) (*Shelf, error) {
+	if in.ID != 1 {
+		return nil, fmt.Errorf("getting shelf: %w", fakeStatusError{&fakeStatus{5, "no such shelf"}})
+	}
+	return &Shelf{ID: 1, Theme: "poetry"}, nil
+}
+
+func (c shelfClient) UpdateShelf(ctx context.Context, in *UpdateShelfRequest) (*Shelf, error) {
+	if in.Theme == "" {
+		return nil, fakeStatusError{&fakeStatus{3, "theme is required"}}
+	}
+	if in.Dry {
+		return nil, fakeStatusError{&fakeStatus{14, "unavailable"}}
+	}
+	return &Shelf{ID: in.ID, Theme: in.Theme}, nil
+}
+
+func TestGRPCGatewayResource(t *testing.T) {
+	client := shelfClient{}
+	api := NewAPI()
+	api.SetJSONOptions("", true)
+	api.AddResource(GRPCGatewayResource(GRPCGatewayConfig{},
+		GRPCUnary(GET, client.GetShelf),
+		GRPCUnary(PATCH, client.UpdateShelf),
+	), "/shelves/{id}")
+
+	for _, c := range []struct {
+		method, path, body string
+		code               int
+		response           string
+	}{
+		{GET, "/shelves/1", "", 200, `{"id":1,"theme":"poetry"}`},
+		{GET, "/shelves/2", "", 404, `{"error":"no such shelf"}`},
+		{GET, "/shelves/x", "", 400, ""},
+		{PATCH, "/shelves/7", `{"id":3,"theme":"maps"}`, 200, `{"id":7,"theme":"maps"}`},
+		{PATCH, "/shelves/7?theme=atlases", "", 200, `{"id":7,"theme":"atlases"}`},
+		{PATCH, "/shelves/7", `{}`, 400, `{"error":"theme is required"}`},
+		{PATCH, "/shelves/7?dry=true", `{"theme":"maps"}`, 503, `{"error":"unavailable"}`},
+		{PATCH, "/shelves/7", `{"theme":`, 400, ""},
+	} {
+		recorder := httptest.NewRecorder()
+		request, _ := http.NewRequest(c.method, c.path, strings.NewReader(c.body))
+		if c.body != "" {
+			request.Header.Set("Content-Type", "application/json")
+		}
+		api.ServeHTTP(recorder, request)
+		if recorder.Code != c.code {
+			t.Errorf("%s %s: got %d, want %d: %s", c.method, c.path, recorder.Code, c.code, recorder.Body)
+			continue
+		}
+		if c.response != "" && strings.TrimSpace(recorder.Body.String()) != c.response {
+			t.Errorf("%s %s: got %s, want %s", c.method, c.path, recorder.Body, c.response)
+		}
+	}
+}
+
+func TestGRPCGatewayMarshalJSON(t *testing.T) {
+	api := NewAPI()
+	api.AddResource(GRPCGatewayResource(GRPCGatewayConfig{
+		MarshalJSON: func(m ProtoMessage) ([]byte, error) {
+			return []byte(`{"theme":"` + m.String() + `"}`), nil
+		},
+	}, GRPCUnary(GET, shelfClient{}.GetShelf)), "/shelves/{id}")
+
+	recorder := httptest.NewRecorder()
+	request, _ := http.NewRequest(GET, "/shelves/1", nil)
+	api.ServeHTTP(recorder, request)
+	if body := strings.TrimSpace(recorder.Body.String()); recorder.Code != 200 || body != `{"theme":"poetry"}` {
+		t.Errorf("got %d %s, want 200 {\"theme\":\"poetry\"}", recorder.Code, body)
+	}
+}
@@ -0,0 +1,29 @@
+package sleepy
+
+// SetAutoHEAD sets whether a HEAD request to a resource without a Head
+// method is answered as a GET would be, without the body, which is the
+// default. Turning it off suits APIs whose Get methods have side
+// effects a HEAD shouldn't cause; HEADs are then only served by
+// resources that support them, and otherwise get the response to any
+// unsupported method, 405 Method Not Allowed by default. A resource
+// that implements AutoHEADSupported decides for itself.
+func (api *API) SetAutoHEAD(enabled bool) {
+	api.noAutoHEAD = !enabled
+}
+
+// AutoHEADSupported is the interface that provides the AutoHEAD method
+// a resource implements to override SetAutoHEAD for itself. AutoHEAD
+// reports whether a HEAD request to the resource, if it has no Head
+// method, is answered with its Get method.
+type AutoHEADSupported interface {
+	AutoHEAD() bool
+}
+
+// autoHEAD reports whether HEAD requests to resource fall back to its
+// Get method.
+func (api *API) autoHEAD(resource interface{}) bool {
+	if resource, ok := resource.(AutoHEADSupported); ok {
+		return resource.AutoHEAD()
+	}
+	return !api.noAutoHEAD
+}
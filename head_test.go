@@ -0,0 +1,43 @@
+package sleepy
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+// Counter has a side effect in Get, so it opts out of auto-HEAD.
+type Counter struct{ hits *int }
+
+func (counter Counter) Get(values url.Values, headers http.Header) (int, interface{}, http.Header) {
+	*counter.hits++
+	return 200, *counter.hits, nil
+}
+
+func (counter Counter) AutoHEAD() bool { return false }
+
+func TestSetAutoHEAD(t *testing.T) {
+	hits := 0
+	api := NewAPI()
+	api.AddResource(new(Item), "/items")
+	api.AddResource(Counter{&hits}, "/counter")
+
+	head := func(path string) *httptest.ResponseRecorder {
+		recorder := httptest.NewRecorder()
+		api.ServeHTTP(recorder, httptest.NewRequest("HEAD", path, nil))
+		return recorder
+	}
+
+	if recorder := head("/items"); recorder.Code != 200 || recorder.Body.Len() != 0 {
+		t.Errorf("auto-HEAD on: got %d with %d body bytes, want 200 and none", recorder.Code, recorder.Body.Len())
+	}
+	if recorder := head("/counter"); recorder.Code != 405 || hits != 0 {
+		t.Errorf("resource opted out: got %d after %d GETs, want 405 and none", recorder.Code, hits)
+	}
+
+	api.SetAutoHEAD(false)
+	if recorder := head("/items"); recorder.Code != 405 || recorder.Header().Get("Allow") != "GET" {
+		t.Errorf("auto-HEAD off: got %d with Allow %q, want 405 with GET", recorder.Code, recorder.Header().Get("Allow"))
+	}
+}
@@ -0,0 +1,116 @@
+package sleepy
+
+import (
+	"crypto/tls"
+	"errors"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// defaultHSTSMaxAge is how long browsers are told to keep to HTTPS when
+// no other duration is given: a year, as HSTS preload lists require.
+const defaultHSTSMaxAge = 365 * 24 * time.Hour
+
+// A SecureConfig describes the listeners started by StartSecure.
+type SecureConfig struct {
+	// HTTPPort is the port on which plain HTTP requests are redirected
+	// to HTTPS. It defaults to 80.
+	HTTPPort int
+
+	// HTTPSPort is the port on which the API is served over TLS. It
+	// defaults to 443.
+	HTTPSPort int
+
+	// TLSConfig holds the server's certificates.
+	TLSConfig *tls.Config
+
+	// HSTSMaxAge is how long browsers should remember to reach the API
+	// only over HTTPS. It defaults to a year.
+	HSTSMaxAge time.Duration
+
+	// HSTSPreload asks for the API's domain, and all of its subdomains,
+	// to be included in the HSTS preload lists built into browsers.
+	HSTSPreload bool
+}
+
+// HTTPSRedirectMiddleware returns middleware that answers every request
+// not made over TLS with a 301 redirect to the same URL on https, at
+// httpsPort. The port is left out of the redirect when it is 443.
+// Requests that already arrived over TLS are passed through.
+func HTTPSRedirectMiddleware(httpsPort int) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(rw http.ResponseWriter, request *http.Request) {
+			if request.TLS != nil {
+				next.ServeHTTP(rw, request)
+				return
+			}
+
+			host := request.Host
+			if h, _, err := net.SplitHostPort(host); err == nil {
+				host = h
+			}
+			if httpsPort != 443 {
+				host = net.JoinHostPort(host, strconv.Itoa(httpsPort))
+			} else if strings.Contains(host, ":") {
+				host = "[" + host + "]"
+			}
+
+			target := "https://" + host + request.URL.RequestURI()
+			http.Redirect(rw, request, target, http.StatusMovedPermanently)
+		})
+	}
+}
+
+// HSTSMiddleware returns middleware that adds a Strict-Transport-Security
+// header to every response to a request made over TLS, telling browsers
+// to use only HTTPS for the next maxAge. With preload set, the header
+// also covers subdomains and asks to be preloaded. Browsers ignore the
+// header on plain HTTP, so it is not sent there.
+func HSTSMiddleware(maxAge time.Duration, preload bool) func(http.Handler) http.Handler {
+	value := "max-age=" + strconv.FormatInt(int64(maxAge/time.Second), 10)
+	if preload {
+		value += "; includeSubDomains; preload"
+	}
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(rw http.ResponseWriter, request *http.Request) {
+			if request.TLS != nil {
+				rw.Header().Set("Strict-Transport-Security", value)
+			}
+			next.ServeHTTP(rw, request)
+		})
+	}
+}
+
+// StartSecure causes the API to begin serving requests over HTTPS, with
+// HSTS headers, on cfg.HTTPSPort, while a listener on cfg.HTTPPort
+// redirects every plain HTTP request to the same URL on HTTPS. It
+// behaves as StartMulti does with those two listeners, and fails at
+// once if cfg has no TLSConfig.
+func (api *API) StartSecure(cfg SecureConfig) error {
+	if cfg.TLSConfig == nil {
+		return errors.New("sleepy: StartSecure needs a TLSConfig holding the server's certificates")
+	}
+	if cfg.HTTPPort == 0 {
+		cfg.HTTPPort = 80
+	}
+	if cfg.HTTPSPort == 0 {
+		cfg.HTTPSPort = 443
+	}
+	if cfg.HSTSMaxAge == 0 {
+		cfg.HSTSMaxAge = defaultHSTSMaxAge
+	}
+	return api.StartMulti([]ListenConfig{
+		{
+			Port:       cfg.HTTPSPort,
+			TLSConfig:  cfg.TLSConfig,
+			Middleware: []func(http.Handler) http.Handler{HSTSMiddleware(cfg.HSTSMaxAge, cfg.HSTSPreload)},
+		},
+		{
+			Port:       cfg.HTTPPort,
+			Middleware: []func(http.Handler) http.Handler{HTTPSRedirectMiddleware(cfg.HTTPSPort)},
+		},
+	})
+}
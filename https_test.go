@@ -0,0 +1,119 @@
+package sleepy
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestHTTPSRedirectMiddleware(t *testing.T) {
+	next := http.HandlerFunc(func(rw http.ResponseWriter, request *http.Request) {})
+
+	cases := []struct {
+		port     int
+		url      string
+		location string
+	}{
+		{443, "http://example.com/items?page=2", "https://example.com/items?page=2"},
+		{443, "http://example.com:8080/items", "https://example.com/items"},
+		{8443, "http://example.com:8080/items", "https://example.com:8443/items"},
+		{443, "http://[::1]:8080/items", "https://[::1]/items"},
+	}
+	for _, c := range cases {
+		recorder := httptest.NewRecorder()
+		HTTPSRedirectMiddleware(c.port)(next).ServeHTTP(recorder, httptest.NewRequest("GET", c.url, nil))
+		if recorder.Code != http.StatusMovedPermanently || recorder.Header().Get("Location") != c.location {
+			t.Errorf("%s to port %d: got %d %q, want %q", c.url, c.port, recorder.Code, recorder.Header().Get("Location"), c.location)
+		}
+	}
+
+	request := httptest.NewRequest("GET", "https://example.com/items", nil)
+	request.TLS = &tls.ConnectionState{}
+	recorder := httptest.NewRecorder()
+	HTTPSRedirectMiddleware(443)(next).ServeHTTP(recorder, request)
+	if recorder.Code != http.StatusOK {
+		t.Errorf("TLS request was redirected: %d", recorder.Code)
+	}
+}
+
+func TestHSTSMiddleware(t *testing.T) {
+	next := http.HandlerFunc(func(rw http.ResponseWriter, request *http.Request) {})
+
+	request := httptest.NewRequest("GET", "https://example.com/items", nil)
+	request.TLS = &tls.ConnectionState{}
+	for _, c := range []struct {
+		preload  bool
+		expected string
+	}{
+		{false, "max-age=86400"},
+		{true, "max-age=86400; includeSubDomains; preload"},
+	} {
+		recorder := httptest.NewRecorder()
+		HSTSMiddleware(24*time.Hour, c.preload)(next).ServeHTTP(recorder, request)
+		if hsts := recorder.Header().Get("Strict-Transport-Security"); hsts != c.expected {
+			t.Errorf("preload %v: got %q, want %q", c.preload, hsts, c.expected)
+		}
+	}
+
+	recorder := httptest.NewRecorder()
+	HSTSMiddleware(24*time.Hour, false)(next).ServeHTTP(recorder, httptest.NewRequest("GET", "http://example.com/items", nil))
+	if hsts := recorder.Header().Get("Strict-Transport-Security"); hsts != "" {
+		t.Errorf("plain HTTP: got %q", hsts)
+	}
+}
+
+func TestStartSecure(t *testing.T) {
+	// The test server's certificate, which its client trusts, is valid
+	// for 127.0.0.1.
+	certified := httptest.NewTLSServer(http.NotFoundHandler())
+	defer certified.Close()
+	client := certified.Client()
+	client.CheckRedirect = func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }
+
+	api := NewAPI()
+	api.AddResource(new(Item), "/items")
+	if err := api.StartSecure(SecureConfig{}); err == nil {
+		t.Error("StartSecure without a TLSConfig succeeded")
+	}
+	done := make(chan error, 1)
+	go func() {
+		done <- api.StartSecure(SecureConfig{
+			HTTPPort:    3023,
+			HTTPSPort:   3024,
+			TLSConfig:   &tls.Config{Certificates: certified.TLS.Certificates},
+			HSTSPreload: true,
+		})
+	}()
+	waitForListener(t, "127.0.0.1:3023")
+	waitForListener(t, "127.0.0.1:3024")
+
+	response, err := client.Get("http://127.0.0.1:3023/items?page=2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	response.Body.Close()
+	if location := response.Header.Get("Location"); response.StatusCode != http.StatusMovedPermanently || location != "https://127.0.0.1:3024/items?page=2" {
+		t.Errorf("plain HTTP: got %d to %q", response.StatusCode, location)
+	}
+
+	response, err = client.Get("https://127.0.0.1:3024/items")
+	if err != nil {
+		t.Fatal(err)
+	}
+	response.Body.Close()
+	if hsts := response.Header.Get("Strict-Transport-Security"); response.StatusCode != 200 || hsts != "max-age=31536000; includeSubDomains; preload" {
+		t.Errorf("HTTPS: got %d with Strict-Transport-Security %q", response.StatusCode, hsts)
+	}
+
+	api.Stop()
+	select {
+	case err := <-done:
+		if err != http.ErrServerClosed {
+			t.Errorf("StartSecure returned %v, want ErrServerClosed", err)
+		}
+	case <-time.After(time.Second):
+		t.Error("StartSecure did not return after Stop")
+	}
+}
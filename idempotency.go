@@ -0,0 +1,213 @@
+package sleepy
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// idempotencyHeader is the request header that carries an idempotency
+// key.
+const idempotencyHeader = "Idempotency-Key"
+
+// A StoredResponse is a response kept by an IdempotencyStore for
+// replay.
+type StoredResponse struct {
+	Status int
+	Header http.Header
+	Body   []byte
+
+	// RequestHash is the hex SHA-256 of the body of the request that
+	// was answered, so that a repeat with a different body can be told
+	// apart.
+	RequestHash string
+}
+
+// An IdempotencyStore keeps responses to requests that carried an
+// idempotency key, for as long as a repeat of the request should be
+// answered with the same response. Implementations must be safe for
+// concurrent use.
+type IdempotencyStore interface {
+	// Get returns the response stored under key, if there is one that
+	// has not expired.
+	Get(key string) (StoredResponse, bool)
+
+	// Set stores response under key.
+	Set(key string, response StoredResponse)
+}
+
+// EnableIdempotency makes POST and PATCH requests that carry an
+// Idempotency-Key header safe to retry. The first response to each key
+// is kept in store, unless it is a server error or the client went away
+// before it was answered, and a repeat of the request with the same key
+// is answered with the stored response, marked with an
+// Idempotent-Replayed header, without running the resource again. A
+// repeat whose body differs from the first request's is refused with a
+// 422 Unprocessable Entity. Keys are scoped to the method and path, and
+// to the client if SetIdempotencyScope is used. The body is read into
+// memory first; one over the limit set with SetMaxBodyBytes is refused
+// with a 413 Request Entity Too Large. Pass a nil store to turn it off.
+func (api *API) EnableIdempotency(store IdempotencyStore) {
+	api.idempotency = store
+}
+
+// SetIdempotencyScope sets the function that names the client a request
+// comes from, typically the authenticated user or tenant, so that two
+// clients that happen to send the same Idempotency-Key never see each
+// other's responses. Without it, keys are shared by all clients. Pass
+// nil to remove it.
+func (api *API) SetIdempotencyScope(scope func(r *http.Request) string) {
+	api.idempotencyScope = scope
+}
+
+func (api *API) idempotencyHandler(next http.Handler) http.Handler {
+	store := api.idempotency
+	return http.HandlerFunc(func(rw http.ResponseWriter, request *http.Request) {
+		key := request.Header.Get(idempotencyHeader)
+		if key == "" || (request.Method != POST && request.Method != PATCH) {
+			next.ServeHTTP(rw, request)
+			return
+		}
+		key = request.Method + " " + request.URL.Path + " " + key
+		if api.idempotencyScope != nil {
+			key = api.idempotencyScope(request) + " " + key
+		}
+
+		body, ok := api.bufferBody(rw, request)
+		if !ok {
+			return
+		}
+		sum := sha256.Sum256(body)
+		hash := hex.EncodeToString(sum[:])
+
+		// Concurrent retries wait for the first attempt, so the resource
+		// runs once.
+		unlock := api.idempotencyLocks.lock(key)
+		defer unlock()
+
+		if stored, ok := store.Get(key); ok {
+			if stored.RequestHash != hash {
+				api.respond(rw, request, http.StatusUnprocessableEntity, map[string]string{"error": "idempotency key reused with a different body"}, nil)
+				return
+			}
+			addHeaders(rw, stored.Header)
+			rw.Header().Set("Idempotent-Replayed", "true")
+			rw.WriteHeader(stored.Status)
+			rw.Write(stored.Body)
+			return
+		}
+
+		buffered := newBufferedResponse()
+		next.ServeHTTP(buffered, request)
+		// A request abandoned by its client may be answered with nothing,
+		// or only part of a response, neither of which may be replayed
+		// to the client's retry.
+		if buffered.status == 0 && buffered.body.Len() == 0 {
+			return
+		}
+		if request.Context().Err() != nil {
+			buffered.copyTo(rw)
+			return
+		}
+		if buffered.status == 0 {
+			buffered.status = http.StatusOK
+		}
+		if buffered.status < 500 {
+			store.Set(key, StoredResponse{
+				Status:      buffered.status,
+				Header:      buffered.header.Clone(),
+				Body:        append([]byte(nil), buffered.body.Bytes()...),
+				RequestHash: hash,
+			})
+		}
+		buffered.copyTo(rw)
+	})
+}
+
+// keyedMutex is a set of mutexes, one per key, created as needed.
+type keyedMutex struct {
+	mu    sync.Mutex
+	locks map[string]*keyedLock
+}
+
+type keyedLock struct {
+	sync.Mutex
+	waiters int
+}
+
+// lock locks the mutex for key and returns the function that unlocks
+// it.
+func (m *keyedMutex) lock(key string) func() {
+	m.mu.Lock()
+	if m.locks == nil {
+		m.locks = make(map[string]*keyedLock)
+	}
+	l, ok := m.locks[key]
+	if !ok {
+		l = new(keyedLock)
+		m.locks[key] = l
+	}
+	l.waiters++
+	m.mu.Unlock()
+
+	l.Lock()
+	return func() {
+		l.Unlock()
+		m.mu.Lock()
+		l.waiters--
+		if l.waiters == 0 {
+			delete(m.locks, key)
+		}
+		m.mu.Unlock()
+	}
+}
+
+// A MemoryIdempotencyStore is an IdempotencyStore that keeps responses
+// in memory for a fixed time.
+type MemoryIdempotencyStore struct {
+	ttl       time.Duration
+	mu        sync.Mutex
+	responses map[string]storedEntry
+}
+
+type storedEntry struct {
+	response StoredResponse
+	expires  time.Time
+}
+
+// NewMemoryIdempotencyStore returns a store that keeps each response
+// for ttl.
+func NewMemoryIdempotencyStore(ttl time.Duration) *MemoryIdempotencyStore {
+	return &MemoryIdempotencyStore{ttl: ttl, responses: make(map[string]storedEntry)}
+}
+
+// Get returns the response stored under key, if it has not expired.
+func (s *MemoryIdempotencyStore) Get(key string) (StoredResponse, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	entry, ok := s.responses[key]
+	if !ok {
+		return StoredResponse{}, false
+	}
+	if time.Now().After(entry.expires) {
+		delete(s.responses, key)
+		return StoredResponse{}, false
+	}
+	return entry.response, true
+}
+
+// Set stores response under key for the store's ttl, and forgets any
+// responses that have expired.
+func (s *MemoryIdempotencyStore) Set(key string, response StoredResponse) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	for k, entry := range s.responses {
+		if now.After(entry.expires) {
+			delete(s.responses, k)
+		}
+	}
+	s.responses[key] = storedEntry{response: response, expires: now.Add(s.ttl)}
+}
@@ -0,0 +1,121 @@
+package sleepy
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type Charge struct {
+	calls *int32
+}
+
+func (charge Charge) Post(values url.Values, headers http.Header) (int, interface{}, http.Header) {
+	n := atomic.AddInt32(charge.calls, 1)
+	return 201, map[string]interface{}{"charge": n}, http.Header{"X-Charge": {"new"}}
+}
+
+func TestEnableIdempotency(t *testing.T) {
+	var calls int32
+	api := NewAPI()
+	api.AddResource(Charge{&calls}, "/charges")
+	api.EnableIdempotency(NewMemoryIdempotencyStore(time.Minute))
+
+	post := func(key string) *httptest.ResponseRecorder {
+		request := httptest.NewRequest("POST", "/charges", nil)
+		if key != "" {
+			request.Header.Set("Idempotency-Key", key)
+		}
+		recorder := httptest.NewRecorder()
+		api.ServeHTTP(recorder, request)
+		return recorder
+	}
+
+	first := post("abc")
+	second := post("abc")
+	if calls != 1 {
+		t.Errorf("handler ran %d times, want 1", calls)
+	}
+	if first.Code != 201 || second.Code != first.Code || second.Body.String() != first.Body.String() {
+		t.Errorf("first %d %q, repeat %d %q", first.Code, first.Body.String(), second.Code, second.Body.String())
+	}
+	if second.Header().Get("X-Charge") != "new" || second.Header().Get("Idempotent-Replayed") != "true" {
+		t.Errorf("repeat headers = %v", second.Header())
+	}
+	if first.Header().Get("Idempotent-Replayed") != "" {
+		t.Error("first response marked as replayed")
+	}
+
+	post("def")
+	post("")
+	post("")
+	if calls != 4 {
+		t.Errorf("handler ran %d times, want 4", calls)
+	}
+}
+
+func TestMemoryIdempotencyStoreExpiry(t *testing.T) {
+	store := NewMemoryIdempotencyStore(10 * time.Millisecond)
+	store.Set("key", StoredResponse{Status: 201})
+	if _, ok := store.Get("key"); !ok {
+		t.Fatal("response not stored")
+	}
+	time.Sleep(20 * time.Millisecond)
+	if _, ok := store.Get("key"); ok {
+		t.Error("response not expired")
+	}
+}
+
+func TestIdempotencyClientGone(t *testing.T) {
+	var calls int32
+	api := NewAPI()
+	api.AddResource(Charge{&calls}, "/charges")
+	api.EnableIdempotency(NewMemoryIdempotencyStore(time.Minute))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	request := httptest.NewRequest("POST", "/charges", nil).WithContext(ctx)
+	request.Header.Set("Idempotency-Key", "abc")
+	api.ServeHTTP(httptest.NewRecorder(), request)
+
+	retry := httptest.NewRequest("POST", "/charges", nil)
+	retry.Header.Set("Idempotency-Key", "abc")
+	recorder := httptest.NewRecorder()
+	api.ServeHTTP(recorder, retry)
+	if calls != 2 || recorder.Code != 201 || recorder.Header().Get("Idempotent-Replayed") != "" {
+		t.Errorf("retry after a disconnect: handler ran %d times, got %d %q replayed %q", calls, recorder.Code, recorder.Body.String(), recorder.Header().Get("Idempotent-Replayed"))
+	}
+}
+
+func TestIdempotencyScopeAndBody(t *testing.T) {
+	var calls int32
+	api := NewAPI()
+	api.AddResource(Charge{&calls}, "/charges")
+	api.EnableIdempotency(NewMemoryIdempotencyStore(time.Minute))
+	api.SetIdempotencyScope(func(r *http.Request) string { return r.Header.Get("X-User") })
+
+	post := func(user, body string) *httptest.ResponseRecorder {
+		request := httptest.NewRequest("POST", "/charges", strings.NewReader(body))
+		request.Header.Set("Idempotency-Key", "abc")
+		request.Header.Set("X-User", user)
+		recorder := httptest.NewRecorder()
+		api.ServeHTTP(recorder, request)
+		return recorder
+	}
+
+	post("ann", "amount=10")
+	if recorder := post("bob", "amount=10"); calls != 2 || recorder.Code != 201 || recorder.Header().Get("Idempotent-Replayed") != "" {
+		t.Errorf("another client's key: handler ran %d times, got %d replayed %q", calls, recorder.Code, recorder.Header().Get("Idempotent-Replayed"))
+	}
+	if recorder := post("ann", "amount=10"); calls != 2 || recorder.Header().Get("Idempotent-Replayed") != "true" {
+		t.Errorf("repeat: handler ran %d times, got %d", calls, recorder.Code)
+	}
+	if recorder := post("ann", "amount=99"); calls != 2 || recorder.Code != http.StatusUnprocessableEntity {
+		t.Errorf("different body: handler ran %d times, got %d %q", calls, recorder.Code, recorder.Body.String())
+	}
+}
@@ -0,0 +1,73 @@
+package sleepy
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+)
+
+// IPFilterMiddleware returns middleware that rejects requests from
+// client addresses it does not permit with a 403 Forbidden.
+//
+// Entries in allow, deny and trustedProxies are CIDR blocks such as
+// "10.0.0.0/8" or single addresses such as "192.0.2.7". An address in
+// deny is always rejected. If allow is non-empty, an address must also
+// appear in it to be accepted.
+//
+// The client address is normally the remote address of the connection.
+// When that address belongs to one of trustedProxies, the address is
+// taken from the forwarding headers instead, as described for RealIP.
+//
+// IPFilterMiddleware panics if any entry cannot be parsed.
+func IPFilterMiddleware(allow, deny []string, trustedProxies ...string) func(http.Handler) http.Handler {
+	allowed := mustParseNetworks(allow)
+	denied := mustParseNetworks(deny)
+	trusted := mustParseNetworks(trustedProxies)
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(rw http.ResponseWriter, request *http.Request) {
+			ip := RealIP(request, trusted)
+			if ip == nil || containsIP(denied, ip) || (len(allowed) > 0 && !containsIP(allowed, ip)) {
+				rw.WriteHeader(http.StatusForbidden)
+				return
+			}
+			next.ServeHTTP(rw, request)
+		})
+	}
+}
+
+// mustParseNetworks parses each entry as a CIDR block or a single IP
+// address, panicking on the first one that is neither.
+func mustParseNetworks(entries []string) []*net.IPNet {
+	networks := make([]*net.IPNet, 0, len(entries))
+	for _, entry := range entries {
+		if !strings.Contains(entry, "/") {
+			ip := net.ParseIP(entry)
+			if ip == nil {
+				panic(fmt.Sprintf("sleepy: invalid IP address %q", entry))
+			}
+			bits := 8 * net.IPv6len
+			if ip4 := ip.To4(); ip4 != nil {
+				ip, bits = ip4, 8*net.IPv4len
+			}
+			networks = append(networks, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+		_, network, err := net.ParseCIDR(entry)
+		if err != nil {
+			panic(fmt.Sprintf("sleepy: invalid CIDR block %q", entry))
+		}
+		networks = append(networks, network)
+	}
+	return networks
+}
+
+func containsIP(networks []*net.IPNet, ip net.IP) bool {
+	for _, network := range networks {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,52 @@
+package sleepy
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestIPFilterMiddleware(t *testing.T) {
+	filter := IPFilterMiddleware(
+		[]string{"10.0.0.0/8", "192.0.2.7"},
+		[]string{"10.1.0.0/16"},
+		"172.16.0.1",
+	)
+	handler := filter(http.HandlerFunc(func(rw http.ResponseWriter, request *http.Request) {}))
+
+	cases := []struct {
+		remote, forwarded string
+		code              int
+	}{
+		{"10.2.3.4:1234", "", http.StatusOK},
+		{"192.0.2.7:1234", "", http.StatusOK},
+		{"192.0.2.8:1234", "", http.StatusForbidden},
+		{"10.1.2.3:1234", "", http.StatusForbidden},
+		{"192.0.2.8:1234", "10.2.3.4", http.StatusForbidden},
+		{"172.16.0.1:1234", "10.2.3.4", http.StatusOK},
+		{"172.16.0.1:1234", "10.2.3.4, 10.1.2.3", http.StatusForbidden},
+		{"172.16.0.1:1234", "10.1.2.3, 10.2.3.4, 172.16.0.1", http.StatusOK},
+	}
+
+	for _, c := range cases {
+		request := httptest.NewRequest("GET", "/", nil)
+		request.RemoteAddr = c.remote
+		if c.forwarded != "" {
+			request.Header.Set("X-Forwarded-For", c.forwarded)
+		}
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, request)
+		if recorder.Code != c.code {
+			t.Errorf("remote %s forwarded %q: got %d, want %d", c.remote, c.forwarded, recorder.Code, c.code)
+		}
+	}
+}
+
+func TestIPFilterMiddlewareInvalidEntry(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("expected a panic for an invalid CIDR block")
+		}
+	}()
+	IPFilterMiddleware([]string{"10.0.0.0/40"}, nil)
+}
@@ -0,0 +1,65 @@
+package sleepy
+
+import (
+	"mime"
+	"net/http"
+	"time"
+)
+
+// defaultKeepAlive is the interval between keep-alive pings when
+// KeepAlive is given none.
+const defaultKeepAlive = 30 * time.Second
+
+// KeepAlive makes the route send a comment line,
+//
+//	: ping
+//
+// on any Server-Sent Events stream that has been quiet for interval, so
+// that proxies don't time out a connection that is waiting for the
+// next event. An event stream is a channel of SSEEvents, or the
+// response of a StreamingSupported resource whose Content-Type is
+// text/event-stream; other streams are left alone, as a ping would
+// corrupt them. Each connection keeps its own time. An interval of zero
+// means 30 seconds.
+func KeepAlive(interval time.Duration) ResourceOption {
+	if interval <= 0 {
+		interval = defaultKeepAlive
+	}
+	return WithMiddleware(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(rw http.ResponseWriter, request *http.Request) {
+			next.ServeHTTP(rw, request.WithContext(SetContextValue(request.Context(), keepAliveKey, interval)))
+		})
+	})
+}
+
+// isEventStream reports whether contentType is that of Server-Sent
+// Events.
+func isEventStream(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	return err == nil && mediaType == "text/event-stream"
+}
+
+// ping writes a keep-alive comment whenever c has been quiet for its
+// keep-alive interval, until stop is closed.
+func (c *ChunkedWriter) ping(stop <-chan struct{}) {
+	defer c.pinging.Done()
+	timer := time.NewTimer(c.keepAlive)
+	defer timer.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-timer.C:
+		}
+		c.mu.Lock()
+		if quiet := time.Since(c.lastWrite); quiet < c.keepAlive {
+			timer.Reset(c.keepAlive - quiet)
+		} else {
+			c.w.Write([]byte(": ping\n\n"))
+			http.NewResponseController(c.rw).Flush()
+			c.lastWrite = time.Now()
+			timer.Reset(c.keepAlive)
+		}
+		c.mu.Unlock()
+	}
+}
@@ -0,0 +1,76 @@
+package sleepy
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+)
+
+type Ticker struct {
+	next chan struct{}
+}
+
+func (ticker Ticker) GetStream(ctx context.Context, values url.Values, header http.Header, w *ChunkedWriter) error {
+	w.Header().Set("Content-Type", "text/event-stream")
+	for i := 1; i <= 2; i++ {
+		fmt.Fprintf(w, "data: %d\n\n", i)
+		w.Flush()
+		<-ticker.next
+	}
+	return nil
+}
+
+func TestKeepAlive(t *testing.T) {
+	ticker := Ticker{next: make(chan struct{})}
+	table := TableExport{next: make(chan struct{})}
+	api := NewAPI()
+	api.AddResourceWithOptions(ticker, "/events", KeepAlive(20*time.Millisecond))
+	api.AddResourceWithOptions(table, "/table", KeepAlive(20*time.Millisecond))
+	server := httptest.NewServer(api)
+	defer server.Close()
+
+	response, err := http.Get(server.URL + "/events")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer response.Body.Close()
+	reader := bufio.NewReader(response.Body)
+	read := func() string {
+		line, _ := reader.ReadString('\n')
+		reader.ReadString('\n')
+		return line
+	}
+	if line := read(); line != "data: 1\n" {
+		t.Fatalf("first event: got %q", line)
+	}
+	for i := 0; i < 2; i++ {
+		if line := read(); line != ": ping\n" {
+			t.Fatalf("while quiet: got %q, want a ping", line)
+		}
+	}
+	ticker.next <- struct{}{}
+	if line := read(); line != "data: 2\n" {
+		t.Errorf("second event: got %q", line)
+	}
+	ticker.next <- struct{}{}
+
+	response, err = http.Get(server.URL + "/table?table=users")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer response.Body.Close()
+	reader = bufio.NewReader(response.Body)
+	reader.ReadString('\n')
+	reader.ReadString('\n')
+	time.Sleep(50 * time.Millisecond)
+	table.next <- struct{}{}
+	if line, _ := reader.ReadString('\n'); line != "2,row2\n" {
+		t.Errorf("CSV stream: got %q, want no ping", line)
+	}
+	table.next <- struct{}{}
+}
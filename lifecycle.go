@@ -0,0 +1,154 @@
+package sleepy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+)
+
+// Startable is the interface a resource implements to prepare itself,
+// for example by opening database connections or warming caches, before
+// the API starts serving requests.
+type Startable interface {
+	Start(ctx context.Context) error
+}
+
+// Teardownable is the interface a resource implements to release what
+// it holds, such as database connections, when the API is stopped.
+type Teardownable interface {
+	Teardown() error
+}
+
+// Initializer is the interface a resource implements to set itself up
+// before it is first served, however it is added to the API or put in
+// place with ReplaceResource. A resource added at several paths is
+// initialized once.
+type Initializer interface {
+	Init() error
+}
+
+// Closer is the interface a resource implements to release what it
+// holds when the API is stopped or shut down. It is called after
+// Teardown for resources that implement both.
+type Closer interface {
+	Close() error
+}
+
+// Stop closes the servers started by Start and its variants, and then
+// calls Teardown and Close on every registered resource that implements
+// Teardownable or Closer, in the reverse of the order they were added.
+// A resource added at several paths is torn down once. Every resource
+// is torn down even if some fail; the errors are returned joined
+// together.
+func (api *API) Stop() error {
+	var errs []error
+	for _, server := range api.takeServers() {
+		if err := server.Close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	errs = append(errs, api.teardown()...)
+	return errors.Join(errs...)
+}
+
+// Shutdown behaves like Stop, except that the servers are shut down
+// gracefully: they stop accepting connections and wait for the requests
+// in flight to finish, or for ctx to be done, before the resources are
+// torn down.
+func (api *API) Shutdown(ctx context.Context) error {
+	var errs []error
+	for _, server := range api.takeServers() {
+		if err := server.Shutdown(ctx); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	errs = append(errs, api.teardown()...)
+	return errors.Join(errs...)
+}
+
+// takeServers returns the running servers and forgets them.
+func (api *API) takeServers() []*http.Server {
+	api.serverMu.Lock()
+	defer api.serverMu.Unlock()
+	servers := api.running
+	api.running = nil
+	return servers
+}
+
+// startResources starts every registered resource that implements
+// Startable, in the order they were added, stopping at the first that
+// fails. A resource added at several paths is started once.
+func (api *API) startResources(ctx context.Context) error {
+	var started []interface{}
+	for _, r := range api.routes {
+		resource, ok := r.current().(Startable)
+		if !ok || containsResource(started, resource) {
+			continue
+		}
+		started = append(started, resource)
+		if err := resource.Start(ctx); err != nil {
+			return fmt.Errorf("sleepy: starting the resource at %s: %w", r.path, err)
+		}
+	}
+	return nil
+}
+
+// teardown tears down the API's resources, newest first.
+func (api *API) teardown() []error {
+	var errs []error
+	var torn []interface{}
+	for i := len(api.routes) - 1; i >= 0; i-- {
+		resource := api.routes[i].current()
+		if containsResource(torn, resource) {
+			continue
+		}
+		torn = append(torn, resource)
+		if resource, ok := resource.(Teardownable); ok {
+			if err := resource.Teardown(); err != nil {
+				errs = append(errs, err)
+			}
+		}
+		if resource, ok := resource.(Closer); ok {
+			if err := resource.Close(); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+	return errs
+}
+
+// initResource calls Init on resource if it implements Initializer and
+// is not already served by one of the API's routes.
+func (api *API) initResource(resource interface{}) error {
+	initializer, ok := resource.(Initializer)
+	if !ok {
+		return nil
+	}
+	for _, r := range api.routes {
+		if sameResource(r.current(), resource) {
+			return nil
+		}
+	}
+	return initializer.Init()
+}
+
+// containsResource reports whether resource is one of resources.
+func containsResource(resources []interface{}, resource interface{}) bool {
+	for _, r := range resources {
+		if sameResource(r, resource) {
+			return true
+		}
+	}
+	return false
+}
+
+// sameResource reports whether a and b are the same resource. Resources
+// that cannot be compared, such as maps, are never the same.
+func sameResource(a, b interface{}) bool {
+	if !reflect.ValueOf(b).Comparable() {
+		return false
+	}
+	return reflect.TypeOf(a) == reflect.TypeOf(b) && a == b
+}
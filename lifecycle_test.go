@@ -0,0 +1,219 @@
+package sleepy
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/url"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type Pool struct {
+	name string
+	torn *[]string
+	err  error
+}
+
+func (pool *Pool) Get(values url.Values, headers http.Header) (int, interface{}, http.Header) {
+	return 200, pool.name, nil
+}
+
+func (pool *Pool) Teardown() error {
+	*pool.torn = append(*pool.torn, pool.name)
+	return pool.err
+}
+
+func TestStop(t *testing.T) {
+	var torn []string
+	errPrimary := errors.New("primary: connection reset")
+	errReplica := errors.New("replica: timeout")
+	primary := &Pool{name: "primary", torn: &torn, err: errPrimary}
+
+	api := NewAPI()
+	api.AddResource(primary, "/primary", "/db")
+	api.AddResource(new(Item), "/items")
+	api.AddResource(&Pool{name: "cache", torn: &torn}, "/cache")
+	api.AddResource(&Pool{name: "replica", torn: &torn, err: errReplica}, "/replica")
+
+	started := make(chan error, 1)
+	go func() { started <- api.Start(3017) }()
+	waitForListener(t, "localhost:3017")
+
+	err := api.Stop()
+	if !errors.Is(err, errPrimary) || !errors.Is(err, errReplica) {
+		t.Errorf("Stop returned %v, want both teardown errors", err)
+	}
+	if expected := []string{"replica", "cache", "primary"}; !reflect.DeepEqual(torn, expected) {
+		t.Errorf("torn down %v, want %v", torn, expected)
+	}
+
+	select {
+	case err := <-started:
+		if err != http.ErrServerClosed {
+			t.Errorf("Start returned %v, want ErrServerClosed", err)
+		}
+	case <-time.After(time.Second):
+		t.Error("Start did not return after Stop")
+	}
+}
+
+type Migrator struct {
+	started *[]string
+	name    string
+	err     error
+}
+
+func (migrator *Migrator) Get(values url.Values, headers http.Header) (int, interface{}, http.Header) {
+	return 200, migrator.name, nil
+}
+
+func (migrator *Migrator) Start(ctx context.Context) error {
+	*migrator.started = append(*migrator.started, migrator.name)
+	return migrator.err
+}
+
+func TestStartResources(t *testing.T) {
+	var started []string
+	errSchema := errors.New("schema out of date")
+
+	api := NewAPI()
+	api.AddResource(&Migrator{started: &started, name: "users"}, "/users", "/people")
+	api.AddResource(new(Item), "/items")
+	api.AddResource(&Migrator{started: &started, name: "orders", err: errSchema}, "/orders")
+	api.AddResource(&Migrator{started: &started, name: "late"}, "/late")
+
+	err := api.StartOn("localhost:3018")
+	if !errors.Is(err, errSchema) {
+		t.Errorf("StartOn returned %v, want the startup error", err)
+	}
+	if expected := []string{"users", "orders"}; !reflect.DeepEqual(started, expected) {
+		t.Errorf("started %v, want %v", started, expected)
+	}
+	if _, err := net.Dial("tcp", "localhost:3018"); err == nil {
+		t.Error("API is listening despite the failed start")
+	}
+}
+
+type Store struct {
+	events  *[]string
+	initErr error
+}
+
+func (store *Store) Get(values url.Values, headers http.Header) (int, interface{}, http.Header) {
+	return 200, "ok", nil
+}
+
+func (store *Store) Init() error {
+	*store.events = append(*store.events, "init")
+	return store.initErr
+}
+
+func (store *Store) Close() error {
+	*store.events = append(*store.events, "close")
+	return nil
+}
+
+func TestInitAndClose(t *testing.T) {
+	var events []string
+	api := NewAPI()
+	api.AddResource(&Store{events: &events}, "/store", "/kv")
+	if expected := []string{"init"}; !reflect.DeepEqual(events, expected) {
+		t.Errorf("after AddResource, events = %v, want %v", events, expected)
+	}
+
+	go api.Start(3022)
+	waitForListener(t, "localhost:3022")
+	response, err := http.Get("http://localhost:3022/store")
+	if err != nil {
+		t.Fatal(err)
+	}
+	response.Body.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := api.Shutdown(ctx); err != nil {
+		t.Errorf("Shutdown returned %v", err)
+	}
+	if expected := []string{"init", "close"}; !reflect.DeepEqual(events, expected) {
+		t.Errorf("after Shutdown, events = %v, want %v", events, expected)
+	}
+	if _, err := net.Dial("tcp", "localhost:3022"); err == nil {
+		t.Error("API is still listening after Shutdown")
+	}
+}
+
+func TestInitFailure(t *testing.T) {
+	var events []string
+	errUnreachable := errors.New("store unreachable")
+	api := NewAPI()
+
+	err := api.AddResourceErr(func() (interface{}, error) {
+		return &Store{events: &events, initErr: errUnreachable}, nil
+	}, "/store")
+	if !errors.Is(err, errUnreachable) {
+		t.Errorf("AddResourceErr returned %v, want the Init error", err)
+	}
+
+	func() {
+		defer func() {
+			if recovered := recover(); recovered == nil {
+				t.Error("AddResource did not panic on the Init error")
+			} else if err, ok := recovered.(error); !ok || !errors.Is(err, errUnreachable) {
+				t.Errorf("AddResource panicked with %v, want the Init error", recovered)
+			}
+		}()
+		api.AddResource(&Store{events: &events, initErr: errUnreachable}, "/kv")
+	}()
+	if len(api.Routes()) != 0 {
+		t.Errorf("failed Init registered %v", api.Routes())
+	}
+
+	if err := api.ReplaceResource("/kv", &Store{events: &events}); err == nil {
+		t.Error("ReplaceResource of an unregistered path succeeded")
+	}
+}
+
+func TestInitEveryRegistration(t *testing.T) {
+	var events []string
+	api := NewAPI()
+	store := &Store{events: &events}
+	v1 := api.Group("/v1")
+	v1.AddResource(store, "/store", "/kv")
+	api.AddResourceWithOptions(store, "/store")
+	if expected := []string{"init"}; !reflect.DeepEqual(events, expected) {
+		t.Errorf("after adding one store three times, events = %v, want %v", events, expected)
+	}
+
+	replacement := &Store{events: &events, initErr: errors.New("store unreachable")}
+	if err := api.ReplaceResource("/v1/kv", replacement); err == nil {
+		t.Error("ReplaceResource with a failing Init succeeded")
+	}
+	if api.Resources()[1].Resource != store {
+		t.Error("a failed replacement is being served")
+	}
+	if err := api.ReplaceResource("/v1/kv", &Store{events: &events}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := api.Stop(); err != nil {
+		t.Fatal(err)
+	}
+	// The failed replacement was initialized but never served.
+	if expected := []string{"init", "init", "init", "close", "close"}; !reflect.DeepEqual(events, expected) {
+		t.Errorf("events = %v, want %v", events, expected)
+	}
+
+	failing := NewAPI()
+	defer func() {
+		if recover() == nil {
+			t.Error("Group.AddResource did not panic on the Init error")
+		}
+		if len(failing.Routes()) != 0 {
+			t.Errorf("failed Init registered %v", failing.Routes())
+		}
+	}()
+	failing.Group("/v1").AddResource(replacement, "/store")
+}
@@ -0,0 +1,256 @@
+package sleepy
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// commonLogTime is the timestamp layout used by the Common Log Format.
+const commonLogTime = "02/Jan/2006:15:04:05 -0700"
+
+// EnableCommonLog causes the API to write a line in the Apache/NCSA
+// Common Log Format to w after every request it serves:
+//
+//	host ident authuser [date] "request" status bytes
+//
+// The status of a request whose client disconnected before it was
+// answered is logged as StatusClientClosedRequest. Writes to w are
+// serialized across concurrent requests.
+func (api *API) EnableCommonLog(w io.Writer) {
+	api.commonLog = w
+}
+
+func (api *API) commonLogHandler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, request *http.Request) {
+		start := time.Now()
+		writer := wrapResponseWriter(rw)
+		next.ServeHTTP(writer, request)
+		if isQuiet(request) {
+			return
+		}
+
+		status := writer.status
+		if clientGone(request) {
+			status = StatusClientClosedRequest
+		}
+		line := commonLogLine(request, start, status, writer.bytes)
+		api.commonLogMu.Lock()
+		io.WriteString(api.commonLog, line)
+		api.commonLogMu.Unlock()
+	})
+}
+
+// commonLogLine formats a single Common Log Format entry, terminated
+// by a newline.
+func commonLogLine(request *http.Request, start time.Time, status, bytes int) string {
+	host, _, err := net.SplitHostPort(request.RemoteAddr)
+	if err != nil {
+		host = request.RemoteAddr
+	}
+	if host == "" {
+		host = "-"
+	}
+
+	user := "-"
+	if request.URL.User != nil && request.URL.User.Username() != "" {
+		user = request.URL.User.Username()
+	} else if name, _, ok := request.BasicAuth(); ok && name != "" {
+		user = name
+	}
+
+	uri := request.RequestURI
+	if uri == "" {
+		uri = request.URL.RequestURI()
+	}
+
+	if status == 0 {
+		status = http.StatusOK
+	}
+	size := "-"
+	if bytes > 0 {
+		size = fmt.Sprint(bytes)
+	}
+
+	return fmt.Sprintf("%s - %s [%s] \"%s %s %s\" %d %s\n",
+		host, user, start.Format(commonLogTime),
+		request.Method, uri, request.Proto, status, size)
+}
+
+// A LogEntry describes a request the API has served. It is passed to
+// the hook installed with SetLogHook. The Status of a request whose
+// client disconnected before it was answered is
+// StatusClientClosedRequest.
+type LogEntry struct {
+	Time       time.Time
+	Method     string
+	Path       string
+	RemoteAddr string
+	Status     int
+	Duration   time.Duration
+
+	// BytesIn is the number of bytes of the request body read while
+	// serving the request, and BytesOut the number of bytes of the
+	// response body written, after any compression.
+	BytesIn  int64
+	BytesOut int64
+
+	// Bytes is the number of bytes of the response body written.
+	//
+	// Deprecated: Use BytesOut.
+	Bytes int
+
+	// Fields holds the values attached to the request with LogField.
+	Fields map[string]interface{}
+}
+
+// SetLogHook causes the API to call hook with a LogEntry after every
+// request it serves. Pass nil to remove the hook.
+func (api *API) SetLogHook(hook func(LogEntry)) {
+	api.logHook = hook
+}
+
+// SetSlowRequestThreshold causes the API to call log with a LogEntry
+// after every request that takes longer than d to serve, and after no
+// others, so that requests over a latency budget stand out. It works
+// alongside any hook installed with SetLogHook. Pass a nil log to
+// remove it.
+func (api *API) SetSlowRequestThreshold(d time.Duration, log func(LogEntry)) {
+	api.slowAfter = d
+	api.slowLog = log
+}
+
+// SetQuietPaths stops requests served by the routes registered at the
+// given patterns, such as those of health checks and metrics scrapes,
+// from being logged by the common log, the log hook, the slow request
+// log or the debug header log, so that they don't drown out everything
+// else. A pattern is given as it was registered, "/health/{check}" say,
+// and quiets every request that route serves; requests that match no
+// route are logged. It applies to routes registered before and after
+// the call.
+func (api *API) SetQuietPaths(patterns ...string) {
+	if api.quietPaths == nil {
+		api.quietPaths = make(map[string]bool)
+	}
+	for _, pattern := range patterns {
+		api.quietPaths[pattern] = true
+	}
+}
+
+// AddResourceQuiet behaves like AddResource, except that requests the
+// resource serves are not logged, as SetQuietPaths describes.
+func (api *API) AddResourceQuiet(resource interface{}, paths ...string) {
+	for _, path := range paths {
+		api.mustRegisterRoute(route{path: path, resource: resource, quiet: true}, api.resourceHandler)
+	}
+}
+
+// Quiet keeps the requests the route serves out of the logs, as
+// SetQuietPaths describes.
+func Quiet() ResourceOption {
+	return func(r *route) {
+		r.quiet = true
+	}
+}
+
+// markQuiet records that request was served by a quiet route, for the
+// log handlers to see once it has been answered.
+func markQuiet(request *http.Request) {
+	if quiet, ok := GetContextValue(request.Context(), quietKey).(*atomic.Bool); ok {
+		quiet.Store(true)
+	}
+}
+
+// isQuiet reports whether request was served by a quiet route.
+func isQuiet(request *http.Request) bool {
+	quiet, ok := GetContextValue(request.Context(), quietKey).(*atomic.Bool)
+	return ok && quiet.Load()
+}
+
+// logFields collects the fields attached to a single request.
+type logFields struct {
+	mu     sync.Mutex
+	values map[string]interface{}
+}
+
+// LogField attaches a key/value pair to the log entry of the request
+// ctx belongs to, such as the ID of the user it was made for. It does
+// nothing when neither a log hook nor a slow request log is installed.
+func LogField(ctx context.Context, key string, value interface{}) {
+	fields, ok := GetContextValue(ctx, logFieldsKey).(*logFields)
+	if !ok {
+		return
+	}
+	fields.mu.Lock()
+	fields.values[key] = value
+	fields.mu.Unlock()
+}
+
+func (api *API) logHookHandler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, request *http.Request) {
+		start := time.Now()
+		fields := &logFields{values: map[string]interface{}{}}
+		writer := wrapResponseWriter(rw)
+		body := countBody(request)
+		defer func() {
+			if isQuiet(request) {
+				return
+			}
+			status := writer.status
+			if clientGone(request) {
+				status = StatusClientClosedRequest
+			} else if status == 0 {
+				status = http.StatusOK
+			}
+			fields.mu.Lock()
+			defer fields.mu.Unlock()
+			entry := LogEntry{
+				Time:       start,
+				Method:     request.Method,
+				Path:       request.URL.Path,
+				RemoteAddr: request.RemoteAddr,
+				Status:     status,
+				Duration:   time.Since(start),
+				BytesIn:    body.bytes,
+				BytesOut:   int64(writer.bytes),
+				Bytes:      writer.bytes,
+				Fields:     fields.values,
+			}
+			if api.logHook != nil {
+				api.logHook(entry)
+			}
+			if api.slowLog != nil && entry.Duration > api.slowAfter {
+				api.slowLog(entry)
+			}
+		}()
+		next.ServeHTTP(writer, request.WithContext(SetContextValue(request.Context(), logFieldsKey, fields)))
+	})
+}
+
+// countingBody is a request body that counts the bytes read from it.
+type countingBody struct {
+	io.ReadCloser
+	bytes int64
+}
+
+func (body *countingBody) Read(p []byte) (int, error) {
+	n, err := body.ReadCloser.Read(p)
+	body.bytes += int64(n)
+	return n, err
+}
+
+// countBody replaces the body of request with one that counts the
+// bytes read from it. A missing or empty body is left alone, so that
+// handlers can still recognize http.NoBody.
+func countBody(request *http.Request) *countingBody {
+	body := &countingBody{ReadCloser: request.Body}
+	if request.Body != nil && request.Body != http.NoBody {
+		request.Body = body
+	}
+	return body
+}
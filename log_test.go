@@ -0,0 +1,145 @@
+package sleepy
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+var commonLogPattern = regexp.MustCompile(`^\S+ \S+ \S+ \[\d{2}/\w{3}/\d{4}:\d{2}:\d{2}:\d{2} [+-]\d{4}\] "GET /items\?page=2 HTTP/1\.1" 200 \d+\n$`)
+
+func TestCommonLog(t *testing.T) {
+	var out bytes.Buffer
+
+	api := NewAPI()
+	api.AddResource(new(Item), "/items")
+	api.EnableCommonLog(&out)
+
+	request := httptest.NewRequest("GET", "/items?page=2", nil)
+	request.SetBasicAuth("doug", "secret")
+	api.ServeHTTP(httptest.NewRecorder(), request)
+
+	if !commonLogPattern.Match(out.Bytes()) {
+		t.Errorf("log line %q does not match the Common Log Format", out.String())
+	}
+	if !bytes.HasPrefix(out.Bytes(), []byte("192.0.2.1 - doug [")) {
+		t.Errorf("log line %q has the wrong host or user", out.String())
+	}
+}
+
+type Account struct{}
+
+func (account Account) GetContext(ctx context.Context, values url.Values, headers http.Header) (int, interface{}, http.Header) {
+	LogField(ctx, "user_id", 42)
+	return 200, "ok", nil
+}
+
+func TestLogField(t *testing.T) {
+	var entries []LogEntry
+
+	api := NewAPI()
+	api.AddResource(new(Account), "/account")
+	api.SetLogHook(func(entry LogEntry) {
+		entries = append(entries, entry)
+	})
+
+	api.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/account", nil))
+
+	if len(entries) != 1 {
+		t.Fatalf("got %d log entries, want 1", len(entries))
+	}
+	entry := entries[0]
+	if entry.Method != "GET" || entry.Path != "/account" || entry.Status != 200 {
+		t.Errorf("got entry %+v", entry)
+	}
+	if entry.Fields["user_id"] != 42 {
+		t.Errorf("got fields %v, want user_id 42", entry.Fields)
+	}
+}
+
+func TestSlowRequestThreshold(t *testing.T) {
+	var slow []LogEntry
+
+	api := NewAPI()
+	api.AddResource(Sleeper(0), "/fast")
+	api.AddResource(Sleeper(30*time.Millisecond), "/slow")
+	api.SetSlowRequestThreshold(15*time.Millisecond, func(entry LogEntry) {
+		slow = append(slow, entry)
+	})
+
+	api.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/fast", nil))
+	if len(slow) != 0 {
+		t.Errorf("fast request was logged: %+v", slow)
+	}
+
+	api.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/slow", nil))
+	if len(slow) != 1 || slow[0].Path != "/slow" || slow[0].Duration < 30*time.Millisecond {
+		t.Errorf("slow requests logged: %+v, want the one to /slow", slow)
+	}
+}
+
+func TestQuietPaths(t *testing.T) {
+	var entries []LogEntry
+	var out bytes.Buffer
+
+	api := NewAPI()
+	api.AddResource(new(Item), "/items")
+	api.AddResourceQuiet(new(Item), "/healthz", "/health/{check}")
+	api.AddHandler("/metrics/", http.NotFoundHandler())
+	api.SetQuietPaths("/metrics/")
+	api.AddResourceWithOptions(new(Item), "/ready", Quiet())
+	api.EnableCommonLog(&out)
+	api.SetLogHook(func(entry LogEntry) {
+		entries = append(entries, entry)
+	})
+
+	for _, path := range []string{"/healthz", "/health/db", "/metrics/", "/metrics/go", "/items", "/ready", "/healthz"} {
+		api.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", path, nil))
+	}
+	if len(entries) != 1 || entries[0].Path != "/items" {
+		t.Errorf("log hook got %+v, want only /items", entries)
+	}
+	if lines := bytes.Count(out.Bytes(), []byte("\n")); lines != 1 || !bytes.Contains(out.Bytes(), []byte("GET /items")) {
+		t.Errorf("common log = %q, want only /items", out.String())
+	}
+}
+
+type Submission struct{}
+
+func (submission Submission) Post(values url.Values, headers http.Header) (int, interface{}, http.Header) {
+	return 200, strings.Repeat("x", 100), nil
+}
+
+func TestLogBytes(t *testing.T) {
+	var entries []LogEntry
+
+	api := NewAPI()
+	api.AddResource(new(Submission), "/submissions")
+	api.SetLogHook(func(entry LogEntry) {
+		entries = append(entries, entry)
+	})
+
+	body := "data=" + strings.Repeat("a", 250)
+	request := httptest.NewRequest("POST", "/submissions", strings.NewReader(body))
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	recorder := httptest.NewRecorder()
+	api.ServeHTTP(recorder, request)
+
+	if len(entries) != 1 {
+		t.Fatalf("got %d log entries, want 1", len(entries))
+	}
+	if entry := entries[0]; entry.BytesIn != int64(len(body)) || entry.BytesOut != int64(recorder.Body.Len()) {
+		t.Errorf("logged %d bytes in and %d out, want %d and %d", entry.BytesIn, entry.BytesOut, len(body), recorder.Body.Len())
+	}
+
+	api.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("POST", "/submissions", nil))
+	if entries[1].BytesIn != 0 {
+		t.Errorf("logged %d bytes in for an empty body", entries[1].BytesIn)
+	}
+}
@@ -0,0 +1,38 @@
+package sleepy
+
+import (
+	"context"
+	"io"
+)
+
+// CustomMarshaller is the interface a resource implements to encode its
+// own JSON responses, in place of the API's JSON encoder and its
+// options, for instance to name fields differently from the rest of the
+// API. Marshaller returns the function, such as json.Marshal, that
+// encodes the data the resource's methods return. Responses negotiated
+// as another content type are encoded as usual.
+type CustomMarshaller interface {
+	Marshaller() func(interface{}) ([]byte, error)
+}
+
+// marshalEncoder encodes with a resource's own marshaller.
+type marshalEncoder func(interface{}) ([]byte, error)
+
+func (marshal marshalEncoder) Encode(w io.Writer, data interface{}) error {
+	content, err := marshal(data)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(content)
+	return err
+}
+
+// resourceMarshaller returns the marshaller stored in ctx for the
+// resource serving the request, or nil if it has none.
+func resourceMarshaller(ctx context.Context) Encoder {
+	marshal, _ := GetContextValue(ctx, marshallerKey).(func(interface{}) ([]byte, error))
+	if marshal == nil {
+		return nil
+	}
+	return marshalEncoder(marshal)
+}
@@ -0,0 +1,53 @@
+package sleepy
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+type Member struct {
+	UserName string
+}
+
+type LegacyMembers struct{}
+
+func (members LegacyMembers) Get(values url.Values, headers http.Header) (int, interface{}, http.Header) {
+	return 200, Member{UserName: "ann"}, nil
+}
+
+func (members LegacyMembers) Marshaller() func(interface{}) ([]byte, error) {
+	return func(data interface{}) ([]byte, error) {
+		return json.Marshal(map[string]interface{}{"userName": data.(Member).UserName})
+	}
+}
+
+type Members struct{}
+
+func (members Members) Get(values url.Values, headers http.Header) (int, interface{}, http.Header) {
+	return 200, Member{UserName: "ann"}, nil
+}
+
+func TestCustomMarshaller(t *testing.T) {
+	api := NewAPI()
+	api.SetJSONOptions("", true)
+	api.SetFieldNamePolicy(SnakeCase)
+	api.AddResource(new(LegacyMembers), "/v1/member")
+	api.AddResource(new(Members), "/v2/member")
+
+	for path, expected := range map[string]string{
+		"/v1/member": `{"userName":"ann"}`,
+		"/v2/member": `{"user_name":"ann"}`,
+	} {
+		recorder := httptest.NewRecorder()
+		api.ServeHTTP(recorder, httptest.NewRequest("GET", path, nil))
+		if body := recorder.Body.String(); body != expected {
+			t.Errorf("%s: body = %q, want %q", path, body, expected)
+		}
+		if ct := recorder.Header().Get("Content-Type"); ct != "application/json" {
+			t.Errorf("%s: Content-Type = %q", path, ct)
+		}
+	}
+}
@@ -0,0 +1,60 @@
+package sleepy
+
+import "sync"
+
+// A MemoryStore is a map that is safe for concurrent use, for keeping
+// the data of resources in examples and tests without a database:
+//
+//	type Items struct {
+//		store sleepy.MemoryStore[string, Item]
+//	}
+//
+//	func (items *Items) Get(values url.Values, headers http.Header) (int, interface{}, http.Header) {
+//		if item, ok := items.store.Get(values.Get("id")); ok {
+//			return 200, item, nil
+//		}
+//		return 404, nil, nil
+//	}
+//
+// The zero value is an empty store ready to use. A MemoryStore must not
+// be copied after first use.
+type MemoryStore[K comparable, V any] struct {
+	mu     sync.RWMutex
+	values map[K]V
+}
+
+// Get returns the value stored under key, and whether there is one.
+func (s *MemoryStore[K, V]) Get(key K) (V, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	value, ok := s.values[key]
+	return value, ok
+}
+
+// Set stores value under key, replacing any value already there.
+func (s *MemoryStore[K, V]) Set(key K, value V) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.values == nil {
+		s.values = make(map[K]V)
+	}
+	s.values[key] = value
+}
+
+// Delete removes the value stored under key, if there is one.
+func (s *MemoryStore[K, V]) Delete(key K) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.values, key)
+}
+
+// List returns every value in the store, in no particular order.
+func (s *MemoryStore[K, V]) List() []V {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	values := make([]V, 0, len(s.values))
+	for _, value := range s.values {
+		values = append(values, value)
+	}
+	return values
+}
@@ -0,0 +1,80 @@
+package sleepy
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sort"
+	"strconv"
+	"sync"
+	"testing"
+)
+
+type Notes struct {
+	store MemoryStore[string, string]
+}
+
+func (notes *Notes) Get(values url.Values, headers http.Header) (int, interface{}, http.Header) {
+	if id := values.Get("id"); id != "" {
+		if note, ok := notes.store.Get(id); ok {
+			return 200, note, nil
+		}
+		return 404, nil, nil
+	}
+	list := notes.store.List()
+	sort.Strings(list)
+	return 200, list, nil
+}
+
+func (notes *Notes) Put(values url.Values, headers http.Header) (int, interface{}, http.Header) {
+	notes.store.Set(values.Get("id"), values.Get("text"))
+	return 204, nil, nil
+}
+
+func (notes *Notes) Delete(values url.Values, headers http.Header) (int, interface{}, http.Header) {
+	notes.store.Delete(values.Get("id"))
+	return 204, nil, nil
+}
+
+func TestMemoryStore(t *testing.T) {
+	var store MemoryStore[int, string]
+	if _, ok := store.Get(1); ok || len(store.List()) != 0 {
+		t.Error("zero store is not empty")
+	}
+	store.Set(1, "one")
+	store.Set(2, "two")
+	store.Set(1, "uno")
+	if value, ok := store.Get(1); !ok || value != "uno" {
+		t.Errorf("Get(1) = %q, %v", value, ok)
+	}
+	store.Delete(2)
+	store.Delete(3)
+	if list := store.List(); len(list) != 1 || list[0] != "uno" {
+		t.Errorf("List() = %q", list)
+	}
+}
+
+func TestMemoryStoreConcurrent(t *testing.T) {
+	api := NewAPI()
+	api.SetJSONOptions("", true)
+	api.AddResource(new(Notes), "/notes")
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(id string) {
+			defer wg.Done()
+			for _, method := range []string{"PUT", "GET", "DELETE", "PUT"} {
+				target := "/notes?id=" + id + "&text=note" + id
+				api.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(method, target, nil))
+			}
+		}(strconv.Itoa(i))
+	}
+	wg.Wait()
+
+	recorder := httptest.NewRecorder()
+	api.ServeHTTP(recorder, httptest.NewRequest("GET", "/notes?id=7", nil))
+	if body := recorder.Body.String(); body != `"note7"` {
+		t.Errorf("GET id=7: body = %q", body)
+	}
+}
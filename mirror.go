@@ -0,0 +1,91 @@
+package sleepy
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"time"
+)
+
+// The default limits on mirroring: how many copies may be in flight at
+// once, and how long each may run.
+const (
+	defaultMirrorConcurrency = 64
+	defaultMirrorTimeout     = 10 * time.Second
+)
+
+// MirrorTo sends a copy of every request the API serves to handler, for
+// example a canary of a new implementation, once the API has responded.
+// The copy runs in its own goroutine with its own copy of the body, and
+// whatever handler writes is discarded. Because the body has to be
+// shared, it is read into memory before the API handles the request;
+// a body over the limit set with SetMaxBodyBytes is refused with a 413
+// Request Entity Too Large and not mirrored. A panic in handler is
+// logged rather than crashing the program. At most 64 copies are in
+// flight at once, each with a context that is canceled after 10
+// seconds; SetMirrorLimits changes both. A request that arrives while
+// all of them are busy is not mirrored, which is logged and counted by
+// DroppedMirrors, so that a slow handler cannot pile up goroutines.
+// Pass nil to stop mirroring.
+func (api *API) MirrorTo(handler http.Handler) {
+	api.mirror = handler
+	if api.mirrorSlots == nil {
+		api.SetMirrorLimits(defaultMirrorConcurrency, defaultMirrorTimeout)
+	}
+}
+
+// SetMirrorLimits sets how many copies of requests MirrorTo may have in
+// flight at once, and how long each may run before its context is
+// canceled. A concurrency or timeout of zero or less means the default.
+func (api *API) SetMirrorLimits(concurrency int, timeout time.Duration) {
+	if concurrency <= 0 {
+		concurrency = defaultMirrorConcurrency
+	}
+	if timeout <= 0 {
+		timeout = defaultMirrorTimeout
+	}
+	api.mirrorSlots = make(chan struct{}, concurrency)
+	api.mirrorTimeout = timeout
+}
+
+// DroppedMirrors returns the number of requests that were not mirrored
+// because the limit set with SetMirrorLimits had been reached.
+func (api *API) DroppedMirrors() int64 {
+	return api.mirrorDropped.Load()
+}
+
+func (api *API) mirrorHandler(next http.Handler) http.Handler {
+	mirror, slots, timeout := api.mirror, api.mirrorSlots, api.mirrorTimeout
+	return http.HandlerFunc(func(rw http.ResponseWriter, request *http.Request) {
+		body, ok := api.bufferBody(rw, request)
+		if !ok {
+			return
+		}
+		copied := request.Clone(context.WithoutCancel(request.Context()))
+
+		next.ServeHTTP(rw, request)
+
+		if body != nil {
+			copied.Body = io.NopCloser(bytes.NewReader(body))
+		}
+		select {
+		case slots <- struct{}{}:
+		default:
+			api.mirrorDropped.Add(1)
+			logf("sleepy: not mirroring %s %s, as %d mirrored requests are in flight", copied.Method, copied.URL.Path, cap(slots))
+			return
+		}
+		go func() {
+			ctx, cancel := context.WithTimeout(copied.Context(), timeout)
+			defer func() {
+				cancel()
+				<-slots
+				if recovered := recover(); recovered != nil {
+					logf("sleepy: panic in mirror of %s %s: %v", copied.Method, copied.URL.Path, recovered)
+				}
+			}()
+			mirror.ServeHTTP(discardResponse{make(http.Header)}, copied.WithContext(ctx))
+		}()
+	})
+}
@@ -0,0 +1,100 @@
+package sleepy
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMirrorTo(t *testing.T) {
+	type mirrored struct {
+		method, path, body string
+	}
+	copies := make(chan mirrored, 1)
+
+	api := NewAPI()
+	api.AddResource(new(Upload), "/uploads")
+	api.MirrorTo(http.HandlerFunc(func(rw http.ResponseWriter, request *http.Request) {
+		body, _ := io.ReadAll(request.Body)
+		rw.WriteHeader(http.StatusTeapot)
+		rw.Write([]byte("ignored"))
+		copies <- mirrored{request.Method, request.URL.Path, string(body)}
+	}))
+
+	request := httptest.NewRequest("POST", "/uploads", strings.NewReader("name=a.txt"))
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	recorder := httptest.NewRecorder()
+	api.ServeHTTP(recorder, request)
+	if recorder.Code != 201 || recorder.Body.String() != `"a.txt"` {
+		t.Errorf("primary: got %d %q", recorder.Code, recorder.Body.String())
+	}
+
+	select {
+	case copy := <-copies:
+		if copy != (mirrored{"POST", "/uploads", "name=a.txt"}) {
+			t.Errorf("mirror got %+v", copy)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("request was not mirrored")
+	}
+}
+
+func TestMirrorToBodyLimit(t *testing.T) {
+	mirrored := make(chan bool, 1)
+	api := NewAPI()
+	api.AddResource(new(Upload), "/uploads")
+	api.SetMaxBodyBytes(16)
+	api.MirrorTo(http.HandlerFunc(func(rw http.ResponseWriter, request *http.Request) {
+		mirrored <- true
+	}))
+
+	reader := &countingReader{Reader: strings.NewReader("name=" + strings.Repeat("a", 1<<20))}
+	request := httptest.NewRequest("POST", "/uploads", reader)
+	request.ContentLength = -1
+	recorder := httptest.NewRecorder()
+	api.ServeHTTP(recorder, request)
+	if recorder.Code != http.StatusRequestEntityTooLarge || reader.read > 1<<10 {
+		t.Errorf("got %d after reading %d bytes, want 413 at the limit", recorder.Code, reader.read)
+	}
+	select {
+	case <-mirrored:
+		t.Error("an oversized request was mirrored")
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestMirrorLimits(t *testing.T) {
+	var logged []string
+	logf = func(format string, args ...interface{}) {
+		logged = append(logged, fmt.Sprintf(format, args...))
+	}
+	defer func() { logf = log.Printf }()
+
+	canceled := make(chan bool, 2)
+	api := NewAPI()
+	api.AddResource(new(Item), "/items")
+	api.MirrorTo(http.HandlerFunc(func(rw http.ResponseWriter, request *http.Request) {
+		<-request.Context().Done()
+		canceled <- true
+	}))
+	api.SetMirrorLimits(2, 20*time.Millisecond)
+
+	for i := 0; i < 3; i++ {
+		api.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/items", nil))
+	}
+	if dropped := api.DroppedMirrors(); dropped != 1 || len(logged) != 1 || !strings.Contains(logged[0], "not mirroring GET /items") {
+		t.Errorf("dropped %d, logged %q", dropped, logged)
+	}
+	for i := 0; i < 2; i++ {
+		select {
+		case <-canceled:
+		case <-time.After(time.Second):
+			t.Fatal("a mirrored request outlived its timeout")
+		}
+	}
+}
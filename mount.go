@@ -0,0 +1,27 @@
+package sleepy
+
+import (
+	"net/http"
+	"strings"
+)
+
+// Mount serves backend, another API, below prefix, so that one API can
+// act as a gateway in front of several. A request for prefix+"/users"
+// reaches backend as a request for "/users", passing in process through
+// this API's middleware and hooks and then backend's own, so that each
+// backend keeps its validation, limits and error handling. The patterns
+// of backend's resources, path parameters included, are matched against
+// the path below the prefix, and a request for the prefix itself is
+// redirected to prefix+"/", as the ServeMux does for any subtree.
+// backend need not be started; mounting it doesn't stop it from also
+// serving its own port.
+func (api *API) Mount(prefix string, backend *API) {
+	prefix = strings.TrimSuffix(prefix, "/")
+	api.register(prefix+"/", backend, func(resource interface{}) http.Handler {
+		handler, ok := resource.(http.Handler)
+		if !ok {
+			handler = api.requestHandler(resource)
+		}
+		return http.StripPrefix(prefix, handler)
+	})
+}
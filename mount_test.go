@@ -0,0 +1,75 @@
+package sleepy
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestMount(t *testing.T) {
+	users := NewAPI()
+	users.AddResource(new(User), "/users")
+	users.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(rw http.ResponseWriter, request *http.Request) {
+			rw.Header().Set("X-Backend", "users")
+			next.ServeHTTP(rw, request)
+		})
+	})
+	items := NewAPI()
+	items.AddResource(new(Item), "/items")
+
+	gateway := NewAPI()
+	gateway.Mount("/accounts/", users)
+	gateway.Mount("/catalog", items)
+
+	for _, c := range []struct {
+		path    string
+		code    int
+		backend string
+	}{
+		{"/accounts/users", 200, "users"},
+		{"/catalog/items", 200, ""},
+		{"/accounts/items", 404, "users"},
+		{"/users", 404, ""},
+	} {
+		recorder := httptest.NewRecorder()
+		gateway.ServeHTTP(recorder, httptest.NewRequest("GET", c.path, nil))
+		if recorder.Code != c.code || recorder.Header().Get("X-Backend") != c.backend {
+			t.Errorf("%s: got %d from %q, want %d from %q", c.path, recorder.Code, recorder.Header().Get("X-Backend"), c.code, c.backend)
+		}
+	}
+}
+
+type BillingInvoice struct{}
+
+func (invoice BillingInvoice) PostContext(ctx context.Context, values url.Values, headers http.Header) (int, interface{}, http.Header) {
+	request := RequestFromContext(ctx)
+	return 200, map[string]string{"id": request.PathValue("id"), "path": request.URL.Path, "amount": values.Get("amount")}, nil
+}
+
+func TestMountSubAPI(t *testing.T) {
+	billing := NewAPI()
+	billing.SetJSONOptions("", true)
+	billing.AddResource(new(BillingInvoice), "/invoices/{id}")
+
+	parent := NewAPI()
+	parent.AddResource(new(Item), "/items")
+	parent.Mount("/billing", billing)
+
+	request := httptest.NewRequest("POST", "/billing/invoices/7", strings.NewReader("amount=12.50"))
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	recorder := httptest.NewRecorder()
+	parent.ServeHTTP(recorder, request)
+	if body := recorder.Body.String(); recorder.Code != 200 || body != `{"amount":"12.50","id":"7","path":"/invoices/7"}` {
+		t.Errorf("got %d %s", recorder.Code, body)
+	}
+
+	recorder = httptest.NewRecorder()
+	parent.ServeHTTP(recorder, httptest.NewRequest("GET", "/items", nil))
+	if recorder.Code != 200 {
+		t.Errorf("parent's own resource: got %d", recorder.Code)
+	}
+}
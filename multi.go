@@ -0,0 +1,75 @@
+package sleepy
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// A ListenConfig describes one of the listeners started by StartMulti.
+type ListenConfig struct {
+	// Port is the TCP port to listen on.
+	Port int
+
+	// TLSConfig, if not nil, makes the listener serve HTTPS. It must
+	// hold the server's certificates.
+	TLSConfig *tls.Config
+
+	// Middleware wraps every request arriving on this listener, around
+	// everything the API itself does. The first middleware given is the
+	// outermost.
+	Middleware []func(http.Handler) http.Handler
+}
+
+// StartMulti causes the API to begin serving the same resources on
+// several ports at once, each with middleware of its own; for example
+// an internal port with full access and an external one behind rate
+// limiting. Resources that implement Startable are started first. It
+// blocks until a listener fails, and then closes the others and returns
+// that listener's error. The server given to UseServer is not used;
+// options given to ConfigureServer apply to every listener.
+func (api *API) StartMulti(configs []ListenConfig) error {
+	if !api.muxInitialized {
+		return errors.New("You must add at least one resource to this API.")
+	}
+	if len(configs) == 0 {
+		return errors.New("sleepy: StartMulti needs at least one ListenConfig")
+	}
+	if err := api.startResources(context.Background()); err != nil {
+		return err
+	}
+
+	servers := make([]*http.Server, len(configs))
+	errs := make(chan error, len(configs))
+	for i, config := range configs {
+		server := api.configuredServer(fmt.Sprintf(":%d", config.Port), chain(api, config.Middleware))
+		if config.TLSConfig != nil {
+			server.TLSConfig = config.TLSConfig
+		}
+		servers[i] = server
+		api.track(server)
+	}
+	for _, server := range servers {
+		go func(server *http.Server) {
+			listener, err := listen(server.Addr)
+			if err != nil {
+				errs <- api.serveError(err)
+			} else if server.TLSConfig != nil {
+				errs <- api.serveError(server.ServeTLS(listener, "", ""))
+			} else {
+				errs <- api.serveError(server.Serve(listener))
+			}
+		}(server)
+	}
+
+	err := <-errs
+	for _, server := range servers {
+		server.Close()
+	}
+	for range servers[1:] {
+		<-errs
+	}
+	return err
+}
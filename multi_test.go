@@ -0,0 +1,103 @@
+package sleepy
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestStartMulti(t *testing.T) {
+	api := NewAPI()
+	api.AddResource(new(Item), "/items")
+
+	external := func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(rw http.ResponseWriter, request *http.Request) {
+			rw.Header().Set("X-Listener", "external")
+			next.ServeHTTP(rw, request)
+		})
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- api.StartMulti([]ListenConfig{
+			{Port: 3019},
+			{Port: 3020, Middleware: []func(http.Handler) http.Handler{external}},
+		})
+	}()
+	waitForListener(t, "localhost:3019")
+	waitForListener(t, "localhost:3020")
+
+	for port, listener := range map[string]string{"3019": "", "3020": "external"} {
+		response, err := http.Get("http://localhost:" + port + "/items")
+		if err != nil {
+			t.Fatal(err)
+		}
+		response.Body.Close()
+		if response.StatusCode != 200 || response.Header.Get("X-Listener") != listener {
+			t.Errorf("port %s: got %d with X-Listener %q", port, response.StatusCode, response.Header.Get("X-Listener"))
+		}
+	}
+
+	if err := api.Stop(); err != nil {
+		t.Errorf("Stop: %v", err)
+	}
+	select {
+	case err := <-done:
+		if err != http.ErrServerClosed {
+			t.Errorf("StartMulti returned %v, want ErrServerClosed", err)
+		}
+	case <-time.After(time.Second):
+		t.Error("StartMulti did not return after Stop")
+	}
+}
+
+func TestErrors(t *testing.T) {
+	taken, err := net.Listen("tcp", ":3025")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer taken.Close()
+
+	api := NewAPI()
+	api.AddResource(new(Item), "/items")
+	errs := api.Errors()
+	go api.StartMulti([]ListenConfig{{Port: 3026}, {Port: 3025}})
+	defer api.Stop()
+
+	select {
+	case err := <-errs:
+		var opErr *net.OpError
+		if !errors.As(err, &opErr) || !strings.Contains(err.Error(), ":3025") {
+			t.Errorf("got %v, want the bind failure on :3025", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("no error reported for the failed listener")
+	}
+	select {
+	case err := <-errs:
+		t.Errorf("the listener closed in response was reported too: %v", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestErrorsNotRead(t *testing.T) {
+	var logged []string
+	logf = func(format string, args ...interface{}) {
+		logged = append(logged, fmt.Sprintf(format, args...))
+	}
+	defer func() { logf = log.Printf }()
+
+	api := NewAPI()
+	failure := errors.New("listen tcp :80: bind: permission denied")
+	if err := api.serveError(failure); err != failure {
+		t.Errorf("got %v, want the error back", err)
+	}
+	if len(logged) != 0 {
+		t.Errorf("logged %q without Errors being called", logged)
+	}
+}
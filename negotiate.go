@@ -0,0 +1,97 @@
+package sleepy
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// A qualityValue is one element of a header like Accept or
+// Accept-Encoding, with its q parameter parsed.
+type qualityValue struct {
+	value string
+	q     float64
+}
+
+// parseQualityList parses a comma-separated header value in which each
+// element may carry a q parameter, as described in RFC 7231 §5.3.1.
+// Elements without a q parameter have a weight of 1. Other parameters
+// are discarded and values are lowercased.
+func parseQualityList(header string) []qualityValue {
+	var list []qualityValue
+	for _, element := range strings.Split(header, ",") {
+		params := strings.Split(element, ";")
+		value := strings.ToLower(strings.TrimSpace(params[0]))
+		if value == "" {
+			continue
+		}
+		q := 1.0
+		for _, param := range params[1:] {
+			name, weight, ok := strings.Cut(strings.TrimSpace(param), "=")
+			if !ok || strings.ToLower(strings.TrimSpace(name)) != "q" {
+				continue
+			}
+			if parsed, err := strconv.ParseFloat(strings.TrimSpace(weight), 64); err == nil && parsed >= 0 && parsed <= 1 {
+				q = parsed
+			}
+		}
+		list = append(list, qualityValue{value: value, q: q})
+	}
+	return list
+}
+
+// NegotiateContentType returns the element of offered that the client
+// prefers according to the request's Accept header, following the
+// q-value weighting of RFC 7231 §5.3.2. Of types with the same weight,
+// one the client named outright beats one it accepts through type/*,
+// which beats one it accepts only through */*; remaining ties go to
+// whichever type comes first in offered. If the request has no Accept
+// header, the first offered type is returned; if it accepts none of
+// them, the empty string is returned.
+func NegotiateContentType(r *http.Request, offered []string) string {
+	header := strings.Join(r.Header.Values("Accept"), ",")
+	if strings.TrimSpace(header) == "" {
+		if len(offered) == 0 {
+			return ""
+		}
+		return offered[0]
+	}
+
+	ranges := parseQualityList(header)
+	best, bestQ, bestSpecificity := "", 0.0, -1
+	for _, contentType := range offered {
+		q, specificity := mediaQuality(ranges, contentType)
+		if q > bestQ || (q == bestQ && q > 0 && specificity > bestSpecificity) {
+			best, bestQ, bestSpecificity = contentType, q, specificity
+		}
+	}
+	return best
+}
+
+// mediaQuality returns the weight the accepted ranges give contentType,
+// and the specificity of the range it comes from: 2 for a full type, 1
+// for type/* and 0 for */*, or -1 if none matches. When several ranges
+// match, the most specific one decides.
+func mediaQuality(ranges []qualityValue, contentType string) (float64, int) {
+	contentType = strings.ToLower(contentType)
+	mainType, _, _ := strings.Cut(contentType, "/")
+
+	q, specificity := 0.0, -1
+	for _, r := range ranges {
+		var s int
+		switch {
+		case r.value == contentType:
+			s = 2
+		case r.value == mainType+"/*":
+			s = 1
+		case r.value == "*/*" || r.value == "*":
+			s = 0
+		default:
+			continue
+		}
+		if s > specificity {
+			q, specificity = r.q, s
+		}
+	}
+	return q, specificity
+}
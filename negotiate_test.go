@@ -0,0 +1,178 @@
+package sleepy
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+func TestNegotiateContentType(t *testing.T) {
+	offered := []string{"application/json", "application/xml", "text/csv"}
+
+	cases := []struct {
+		accept, expected string
+	}{
+		{"", "application/json"},
+		{"application/xml", "application/xml"},
+		{"application/xml;q=0.9, application/json", "application/json"},
+		{"application/json;q=0.5, application/xml;q=0.8", "application/xml"},
+		{"text/*", "text/csv"},
+		{"*/*;q=0.1, text/csv;q=0", "application/json"},
+		{"application/*;q=0.2, application/xml;q=0.4", "application/xml"},
+		{"image/png", ""},
+		{"application/json;q=0, */*;q=0.5", "application/xml"},
+		{"application/xml;q=0.9, application/json;q=0.8", "application/xml"},
+		{"application/*, application/xml", "application/xml"},
+		{"*/*, text/csv", "text/csv"},
+		{"*/*", "application/json"},
+	}
+	for _, c := range cases {
+		request := httptest.NewRequest("GET", "/", nil)
+		if c.accept != "" {
+			request.Header.Set("Accept", c.accept)
+		}
+		if got := NegotiateContentType(request, offered); got != c.expected {
+			t.Errorf("Accept %q: got %q, want %q", c.accept, got, c.expected)
+		}
+	}
+}
+
+type Book struct {
+	Title  string `json:"title" xml:"title"`
+	Author string `json:"author" xml:"author"`
+}
+
+type Library struct{}
+
+func (library Library) Get(values url.Values, headers http.Header) (int, interface{}, http.Header) {
+	return 200, Book{Title: "Dune", Author: "Frank Herbert"}, nil
+}
+
+func TestRegisterEncoder(t *testing.T) {
+	api := NewAPI()
+	api.AddResource(new(Library), "/books")
+	api.RegisterEncoder("application/xml", XMLEncoder)
+
+	get := func(accept string) (string, string) {
+		request := httptest.NewRequest("GET", "/books", nil)
+		request.Header.Set("Accept", accept)
+		recorder := httptest.NewRecorder()
+		api.ServeHTTP(recorder, request)
+		return recorder.Header().Get("Content-Type"), recorder.Body.String()
+	}
+
+	if ct, body := get("application/xml;q=0.9, application/json"); ct != "application/json" || body[0] != '{' {
+		t.Errorf("JSON preferred: got %q %q", ct, body)
+	}
+	if ct, body := get("application/json;q=0.5, application/xml"); ct != "application/xml" || body != "<Book><title>Dune</title><author>Frank Herbert</author></Book>" {
+		t.Errorf("XML preferred: got %q %q", ct, body)
+	}
+	if ct, _ := get("image/png"); ct != "application/json" {
+		t.Errorf("unacceptable: got %q, want the JSON fallback", ct)
+	}
+}
+
+type Token struct{}
+
+func (token Token) Get(values url.Values, headers http.Header) (int, interface{}, http.Header) {
+	return 200, map[string]string{"access_token": "abc", "token_type": "bearer"}, nil
+}
+
+func TestFormEncodedResponse(t *testing.T) {
+	api := NewAPI()
+	api.SetJSONOptions("", true)
+	api.AddResource(new(Token), "/token")
+	api.AddResource(new(Library), "/books")
+
+	get := func(path, accept string) (string, string) {
+		request := httptest.NewRequest("GET", path, nil)
+		request.Header.Set("Accept", accept)
+		recorder := httptest.NewRecorder()
+		api.ServeHTTP(recorder, request)
+		return recorder.Header().Get("Content-Type"), recorder.Body.String()
+	}
+
+	if ct, body := get("/token", "application/x-www-form-urlencoded"); ct != "application/x-www-form-urlencoded" || body != "access_token=abc&token_type=bearer" {
+		t.Errorf("form: got %q %q", ct, body)
+	}
+	if ct, body := get("/token", "application/json"); ct != "application/json" || body != `{"access_token":"abc","token_type":"bearer"}` {
+		t.Errorf("JSON: got %q %q", ct, body)
+	}
+	if ct, _ := get("/books", "application/x-www-form-urlencoded"); ct != "application/json" {
+		t.Errorf("data that is not a flat map: got %q, want the JSON fallback", ct)
+	}
+}
+
+func TestDefaultContentType(t *testing.T) {
+	api := NewAPI()
+	api.AddResource(new(Library), "/books")
+	api.RegisterEncoder("application/xml", XMLEncoder)
+	api.SetDefaultContentType("application/xml")
+
+	recorder := httptest.NewRecorder()
+	api.ServeHTTP(recorder, httptest.NewRequest("GET", "/books", nil))
+	if ct, body := recorder.Header().Get("Content-Type"), recorder.Body.String(); ct != "application/xml" || body != "<Book><title>Dune</title><author>Frank Herbert</author></Book>" {
+		t.Errorf("no Accept: got %q %q, want XML", ct, body)
+	}
+
+	request := httptest.NewRequest("GET", "/books", nil)
+	request.Header.Set("Accept", "application/json")
+	recorder = httptest.NewRecorder()
+	api.ServeHTTP(recorder, request)
+	if ct := recorder.Header().Get("Content-Type"); ct != "application/json" {
+		t.Errorf("Accept application/json: got %q", ct)
+	}
+
+	request = httptest.NewRequest("GET", "/books", nil)
+	request.Header.Set("Accept", "*/*")
+	recorder = httptest.NewRecorder()
+	api.ServeHTTP(recorder, request)
+	if ct := recorder.Header().Get("Content-Type"); ct != "application/xml" {
+		t.Errorf("Accept */*: got %q, want the default", ct)
+	}
+
+	api.SetDefaultContentType("text/csv")
+	recorder = httptest.NewRecorder()
+	api.ServeHTTP(recorder, httptest.NewRequest("GET", "/books", nil))
+	if ct := recorder.Header().Get("Content-Type"); ct != "application/json" {
+		t.Errorf("unregistered default: got %q, want JSON", ct)
+	}
+}
+
+func TestSuffixEncoders(t *testing.T) {
+	api := NewAPI()
+	api.SetJSONOptions("", true)
+	api.AddResource(new(Library), "/books")
+
+	get := func(accept string) (string, string) {
+		request := httptest.NewRequest("GET", "/books", nil)
+		request.Header.Set("Accept", accept)
+		recorder := httptest.NewRecorder()
+		api.ServeHTTP(recorder, request)
+		return recorder.Header().Get("Content-Type"), recorder.Body.String()
+	}
+
+	const bookJSON = `{"title":"Dune","author":"Frank Herbert"}`
+	if ct, body := get("application/vnd.myapp+json"); ct != "application/vnd.myapp+json" || body != bookJSON {
+		t.Errorf("+json: got %q %q", ct, body)
+	}
+	if ct, body := get("application/vnd.myapp.v2+xml"); ct != "application/vnd.myapp.v2+xml" || body != "<Book><title>Dune</title><author>Frank Herbert</author></Book>" {
+		t.Errorf("+xml: got %q %q", ct, body)
+	}
+	if ct, _ := get("application/json, application/vnd.myapp+json"); ct != "application/json" {
+		t.Errorf("tie: got %q, want plain JSON", ct)
+	}
+	if ct, _ := get("application/vnd.myapp+yaml"); ct != "application/json" {
+		t.Errorf("unknown suffix: got %q, want the JSON fallback", ct)
+	}
+
+	api.RegisterSuffixEncoder("yaml", EncoderFunc(func(w io.Writer, data interface{}) error {
+		_, err := io.WriteString(w, "title: "+data.(Book).Title)
+		return err
+	}))
+	if ct, body := get("application/vnd.myapp+yaml"); ct != "application/vnd.myapp+yaml" || body != "title: Dune" {
+		t.Errorf("registered suffix: got %q %q", ct, body)
+	}
+}
@@ -0,0 +1,77 @@
+package sleepy
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// openAPIVersion is the version of the OpenAPI specification that
+// GenerateOpenAPI's documents follow.
+const openAPIVersion = "3.0.3"
+
+// An OpenAPIDocument is a minimal OpenAPI document describing the
+// paths an API serves and the operations available on each.
+type OpenAPIDocument struct {
+	OpenAPI string                                 `json:"openapi"`
+	Info    OpenAPIInfo                            `json:"info"`
+	Paths   map[string]map[string]OpenAPIOperation `json:"paths"`
+}
+
+// OpenAPIInfo is the info object of an OpenAPIDocument.
+type OpenAPIInfo struct {
+	Title   string `json:"title"`
+	Version string `json:"version"`
+}
+
+// An OpenAPIOperation describes one HTTP method on a path of an
+// OpenAPIDocument.
+type OpenAPIOperation struct {
+	Summary     string                     `json:"summary,omitempty"`
+	Description string                     `json:"description,omitempty"`
+	Responses   map[string]OpenAPIResponse `json:"responses"`
+}
+
+// An OpenAPIResponse describes a possible response to an
+// OpenAPIOperation.
+type OpenAPIResponse struct {
+	Description string `json:"description"`
+}
+
+// GenerateOpenAPI describes the API's routes as an OpenAPI document.
+// Each path lists the operations its resource supports, with the
+// documentation given to RegisterWithDocs. Request and response
+// schemas are not known, so every operation has only a default
+// response.
+func (api *API) GenerateOpenAPI() OpenAPIDocument {
+	document := OpenAPIDocument{
+		OpenAPI: openAPIVersion,
+		Info:    OpenAPIInfo{Title: "API", Version: "1.0.0"},
+		Paths:   make(map[string]map[string]OpenAPIOperation),
+	}
+	for _, r := range api.Routes() {
+		operations := make(map[string]OpenAPIOperation, len(r.Methods))
+		for _, method := range r.Methods {
+			operations[strings.ToLower(method)] = OpenAPIOperation{
+				Summary:     r.Summary,
+				Description: r.Description,
+				Responses:   map[string]OpenAPIResponse{"default": {Description: "Response"}},
+			}
+		}
+		document.Paths[r.Path] = operations
+	}
+	return document
+}
+
+// EnableOpenAPI registers an endpoint at path that responds to GET
+// with the API's OpenAPI document, generated afresh for each request.
+// Like EnableDebugRoutes, the endpoint is a route like any other, and
+// appears in the document.
+func (api *API) EnableOpenAPI(path string) {
+	api.register(path, methodSet{
+		GET: func(ctx context.Context, values url.Values, header http.Header) (int, interface{}, http.Header) {
+			return http.StatusOK, api.GenerateOpenAPI(), nil
+		},
+	}, api.resourceHandler)
+}
@@ -0,0 +1,51 @@
+package sleepy
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestEnableOpenAPI(t *testing.T) {
+	api := NewAPI()
+	api.AddResource(new(Item), "/items")
+	api.RegisterWithDocs(new(Upload), "/uploads", "Upload a file", "")
+	api.AddRPCResource(Shop{}, "/users", "Users")
+	api.EnableOpenAPI("/openapi.json")
+
+	recorder := httptest.NewRecorder()
+	api.ServeHTTP(recorder, httptest.NewRequest("GET", "/openapi.json", nil))
+
+	var document struct {
+		OpenAPI string
+		Paths   map[string]map[string]struct{ Summary string }
+	}
+	if err := json.Unmarshal(recorder.Body.Bytes(), &document); err != nil {
+		t.Fatal(err)
+	}
+	if document.OpenAPI != "3.0.3" {
+		t.Errorf("openapi = %q", document.OpenAPI)
+	}
+
+	operations := make(map[string][]string)
+	for path, ops := range document.Paths {
+		for _, method := range []string{"get", "post", "put", "delete", "head", "patch"} {
+			if _, ok := ops[method]; ok {
+				operations[path] = append(operations[path], method)
+			}
+		}
+	}
+	expected := map[string][]string{
+		"/items":        {"get"},
+		"/uploads":      {"post"},
+		"/users":        {"get", "post"},
+		"/openapi.json": {"get"},
+	}
+	if !reflect.DeepEqual(operations, expected) {
+		t.Errorf("operations = %v, want %v", operations, expected)
+	}
+	if summary := document.Paths["/uploads"]["post"].Summary; summary != "Upload a file" {
+		t.Errorf("summary = %q", summary)
+	}
+}
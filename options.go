@@ -0,0 +1,124 @@
+package sleepy
+
+import (
+	"net/http"
+	"time"
+)
+
+// An Option configures an API created with NewAPIWithOptions.
+type Option func(*API)
+
+// NewAPIWithOptions allocates and returns a new API configured by
+// options, which are applied in order.
+func NewAPIWithOptions(options ...Option) *API {
+	api := NewAPI()
+	for _, option := range options {
+		option(api)
+	}
+	return api
+}
+
+// WithEncoder registers encoder for contentType, as RegisterEncoder
+// does.
+func WithEncoder(contentType string, encoder Encoder) Option {
+	return func(api *API) {
+		api.RegisterEncoder(contentType, encoder)
+	}
+}
+
+// WithDebug sets debug mode, as SetDebug does.
+func WithDebug(debug bool) Option {
+	return func(api *API) {
+		api.SetDebug(debug)
+	}
+}
+
+// WithTimeouts sets the read, write and idle timeouts of the server
+// behind Start. A zero duration leaves that timeout unset.
+func WithTimeouts(read, write, idle time.Duration) Option {
+	return func(api *API) {
+		api.ConfigureServer(WithReadTimeout(read), WithWriteTimeout(write), WithIdleTimeout(idle))
+	}
+}
+
+// WithMaxResponseBodyBytes limits response bodies to n bytes, as
+// measured before compression. A response whose body would be larger is
+// not sent: the error is logged and the client gets an empty 500
+// Internal Server Error. A stream is cut off once it reaches the limit.
+func WithMaxResponseBodyBytes(n int64) Option {
+	return func(api *API) {
+		api.maxResponseBytes = n
+	}
+}
+
+// A ResourceOption configures how a resource added with
+// AddResourceWithOptions is served.
+type ResourceOption func(*route)
+
+// AddResourceWithOptions behaves like AddResource for a single path,
+// with the behaviour of the route adjusted by options.
+func (api *API) AddResourceWithOptions(resource interface{}, path string, options ...ResourceOption) {
+	r := route{path: path, resource: resource}
+	for _, option := range options {
+		option(&r)
+	}
+	api.mustRegisterRoute(r, api.resourceHandler)
+}
+
+// WithTimeout gives the route its own timeout, as AddResourceWithTimeout
+// does.
+func WithTimeout(timeout time.Duration) ResourceOption {
+	return func(r *route) {
+		r.timeout = timeout
+	}
+}
+
+// WithMiddleware wraps every request to the route in middleware, inside
+// the API-wide middleware added with Use. The first middleware given is
+// the outermost.
+func WithMiddleware(middleware ...func(http.Handler) http.Handler) ResourceOption {
+	return func(r *route) {
+		r.middleware = append(r.middleware, middleware...)
+	}
+}
+
+// WithMethodMiddleware wraps only the requests to the route with the
+// given HTTP method in middleware, for example to require
+// authentication for POST while GET stays public. It runs inside the
+// API-wide and route middleware, just before the resource's method.
+func WithMethodMiddleware(method string, middleware ...func(http.Handler) http.Handler) ResourceOption {
+	return func(r *route) {
+		if r.methodMiddleware == nil {
+			r.methodMiddleware = make(map[string][]func(http.Handler) http.Handler)
+		}
+		r.methodMiddleware[method] = append(r.methodMiddleware[method], middleware...)
+	}
+}
+
+// withRouteMiddleware wraps handler in the route's method middleware and
+// then its route middleware.
+func withRouteMiddleware(r route, handler http.Handler) http.Handler {
+	if len(r.methodMiddleware) > 0 {
+		byMethod := make(map[string]http.Handler, len(r.methodMiddleware))
+		for method, middleware := range r.methodMiddleware {
+			byMethod[method] = chain(handler, middleware)
+		}
+		next := handler
+		handler = http.HandlerFunc(func(rw http.ResponseWriter, request *http.Request) {
+			if wrapped, ok := byMethod[request.Method]; ok {
+				wrapped.ServeHTTP(rw, request)
+				return
+			}
+			next.ServeHTTP(rw, request)
+		})
+	}
+	return chain(handler, r.middleware)
+}
+
+// chain wraps handler in middleware, the first of which is outermost.
+func chain(handler http.Handler, middleware []func(http.Handler) http.Handler) http.Handler {
+	for i := len(middleware) - 1; i >= 0; i-- {
+		handler = middleware[i](handler)
+	}
+	return handler
+}
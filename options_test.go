@@ -0,0 +1,135 @@
+package sleepy
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+type Note struct{}
+
+func (note Note) Get(values url.Values, headers http.Header) (int, interface{}, http.Header) {
+	return 200, "note", nil
+}
+
+func (note Note) Post(values url.Values, headers http.Header) (int, interface{}, http.Header) {
+	return 201, "saved", nil
+}
+
+func TestResourceOptions(t *testing.T) {
+	var order []string
+	record := func(name string) func(http.Handler) http.Handler {
+		return func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(rw http.ResponseWriter, request *http.Request) {
+				order = append(order, name)
+				next.ServeHTTP(rw, request)
+			})
+		}
+	}
+	requireAuth := func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(rw http.ResponseWriter, request *http.Request) {
+			if request.Header.Get("Authorization") == "" {
+				rw.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			next.ServeHTTP(rw, request)
+		})
+	}
+
+	api := NewAPI()
+	api.Use(record("global"))
+	api.AddResourceWithOptions(new(Note), "/notes",
+		WithMiddleware(record("route")),
+		WithMethodMiddleware(POST, record("post"), requireAuth),
+	)
+
+	recorder := httptest.NewRecorder()
+	api.ServeHTTP(recorder, httptest.NewRequest("GET", "/notes", nil))
+	if recorder.Code != 200 || !reflect.DeepEqual(order, []string{"global", "route"}) {
+		t.Errorf("GET: got %d after %v", recorder.Code, order)
+	}
+
+	order = nil
+	recorder = httptest.NewRecorder()
+	api.ServeHTTP(recorder, httptest.NewRequest("POST", "/notes", nil))
+	if recorder.Code != 401 || !reflect.DeepEqual(order, []string{"global", "route", "post"}) {
+		t.Errorf("POST without credentials: got %d after %v", recorder.Code, order)
+	}
+
+	request := httptest.NewRequest("POST", "/notes", nil)
+	request.Header.Set("Authorization", "Bearer token")
+	recorder = httptest.NewRecorder()
+	api.ServeHTTP(recorder, request)
+	if recorder.Code != 201 {
+		t.Errorf("POST with credentials: got %d", recorder.Code)
+	}
+}
+
+func TestNewAPIWithOptions(t *testing.T) {
+	api := NewAPIWithOptions(
+		WithEncoder("application/xml", XMLEncoder),
+		WithDebug(true),
+		WithTimeouts(5*time.Second, 10*time.Second, time.Minute),
+	)
+	api.AddResource(new(Library), "/books")
+	api.AddResource(new(Broken), "/broken")
+
+	request := httptest.NewRequest("GET", "/books", nil)
+	request.Header.Set("Accept", "application/xml")
+	recorder := httptest.NewRecorder()
+	api.ServeHTTP(recorder, request)
+	if ct := recorder.Header().Get("Content-Type"); ct != "application/xml" {
+		t.Errorf("WithEncoder: got Content-Type %q", ct)
+	}
+
+	recorder = httptest.NewRecorder()
+	api.ServeHTTP(recorder, httptest.NewRequest("GET", "/broken", nil))
+	if !strings.Contains(recorder.Body.String(), "database unreachable") {
+		t.Errorf("WithDebug: got body %q", recorder.Body.String())
+	}
+
+	server := api.newServer(":3000")
+	if server.ReadTimeout != 5*time.Second || server.WriteTimeout != 10*time.Second || server.IdleTimeout != time.Minute {
+		t.Errorf("WithTimeouts: read %v, write %v, idle %v", server.ReadTimeout, server.WriteTimeout, server.IdleTimeout)
+	}
+}
+
+func TestWithMaxResponseBodyBytes(t *testing.T) {
+	var logged []string
+	logf = func(format string, args ...interface{}) {
+		logged = append(logged, fmt.Sprintf(format, args...))
+	}
+	defer func() { logf = log.Printf }()
+
+	api := NewAPIWithOptions(WithMaxResponseBodyBytes(64))
+	api.AddResource(new(Blob), "/blob")
+
+	recorder := httptest.NewRecorder()
+	api.ServeHTTP(recorder, httptest.NewRequest("GET", "/blob?size=64", nil))
+	if recorder.Code != 200 || recorder.Body.Len() != 64 {
+		t.Errorf("at the limit: got %d with %d bytes", recorder.Code, recorder.Body.Len())
+	}
+
+	recorder = httptest.NewRecorder()
+	api.ServeHTTP(recorder, httptest.NewRequest("GET", "/blob?size=65", nil))
+	if recorder.Code != 500 || recorder.Body.Len() != 0 {
+		t.Errorf("over the limit: got %d with %d bytes", recorder.Code, recorder.Body.Len())
+	}
+	if len(logged) != 1 || !strings.Contains(logged[0], "65 bytes exceeds the limit of 64") {
+		t.Errorf("logged %q", logged)
+	}
+
+	api = NewAPIWithOptions(WithMaxResponseBodyBytes(20))
+	api.AddResource(new(Export), "/export")
+	recorder = httptest.NewRecorder()
+	api.ServeHTTP(recorder, httptest.NewRequest("GET", "/export", nil))
+	if body := recorder.Body.String(); body != "{\"id\":1}\n{\"id\":2}\n" {
+		t.Errorf("stream: got %q", body)
+	}
+}
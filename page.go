@@ -0,0 +1,37 @@
+package sleepy
+
+import (
+	"net/http"
+	"strconv"
+)
+
+// A Page is data a resource returns for one page of a paginated
+// collection. The response body holds only Items; the rest is sent in
+// headers, a Link header (RFC 8288) with the URLs of the next and
+// previous pages, those that are not empty, and an X-Total-Count
+// header with the size of the whole collection:
+//
+//	return 200, sleepy.Page{Items: users, Next: "/users?page=3", Prev: "/users?page=1", Total: 57}, nil
+type Page struct {
+	Items interface{}
+	Next  string
+	Prev  string
+	Total int
+}
+
+// headers returns header with the page's Link and X-Total-Count headers
+// added.
+func (page Page) headers(header http.Header) http.Header {
+	header = header.Clone()
+	if header == nil {
+		header = make(http.Header)
+	}
+	if page.Next != "" {
+		header.Add("Link", "<"+page.Next+`>; rel="next"`)
+	}
+	if page.Prev != "" {
+		header.Add("Link", "<"+page.Prev+`>; rel="prev"`)
+	}
+	header.Set("X-Total-Count", strconv.Itoa(page.Total))
+	return header
+}
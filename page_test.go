@@ -0,0 +1,55 @@
+package sleepy
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+type Pages struct{}
+
+func (pages Pages) Get(values url.Values, headers http.Header) (int, interface{}, http.Header) {
+	n, _ := strconv.Atoi(values.Get("page"))
+	page := Page{Items: []int{n*2 - 1, n * 2}, Total: 6}
+	if n < 3 {
+		page.Next = "/pages?page=" + strconv.Itoa(n+1)
+	}
+	if n > 1 {
+		page.Prev = "/pages?page=" + strconv.Itoa(n-1)
+	}
+	return 200, page, http.Header{"X-Page": {values.Get("page")}}
+}
+
+func TestPage(t *testing.T) {
+	api := NewAPI()
+	api.SetJSONOptions("", true)
+	api.AddResource(new(Pages), "/pages")
+
+	for _, c := range []struct {
+		page  string
+		links []string
+		body  string
+	}{
+		{"1", []string{`</pages?page=2>; rel="next"`}, `[1,2]`},
+		{"2", []string{`</pages?page=3>; rel="next"`, `</pages?page=1>; rel="prev"`}, `[3,4]`},
+		{"3", []string{`</pages?page=2>; rel="prev"`}, `[5,6]`},
+	} {
+		recorder := httptest.NewRecorder()
+		api.ServeHTTP(recorder, httptest.NewRequest("GET", "/pages?page="+c.page, nil))
+		if body := recorder.Body.String(); body != c.body {
+			t.Errorf("page %s: body = %q, want %q", c.page, body, c.body)
+		}
+		if links := recorder.Header().Values("Link"); !reflect.DeepEqual(links, c.links) {
+			t.Errorf("page %s: Link = %q, want %q", c.page, links, c.links)
+		}
+		if total := recorder.Header().Get("X-Total-Count"); total != "6" {
+			t.Errorf("page %s: X-Total-Count = %q", c.page, total)
+		}
+		if page := recorder.Header().Get("X-Page"); page != c.page {
+			t.Errorf("page %s: the resource's own header was lost: %q", c.page, page)
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package sleepy
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strconv"
+)
+
+// Param returns the value of the path parameter name, such as id in a
+// resource added at "/users/{id}", from the context passed to a
+// context-aware resource method. Paths are matched by the ServeMux
+// patterns of Go 1.22 and later. It returns "" if the path has no such
+// parameter.
+func Param(ctx context.Context, name string) string {
+	request := RequestFromContext(ctx)
+	if request == nil {
+		return ""
+	}
+	return request.PathValue(name)
+}
+
+// ParamInt returns the path parameter name as an int. If it is missing
+// or not a decimal integer, the error is a *StatusError with the code
+// 400, so a resource method can return it as it is:
+//
+//	id, err := sleepy.ParamInt(ctx, "id")
+//	if err != nil {
+//		return 0, err, nil
+//	}
+func ParamInt(ctx context.Context, name string) (int, error) {
+	value := Param(ctx, name)
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, badParam(name, value, "an integer")
+	}
+	return n, nil
+}
+
+// A UUID is a universally unique identifier, as RFC 9562 describes. It
+// has the same representation as the UUID types of the common uuid
+// packages, such as github.com/google/uuid, and converts to them
+// directly.
+type UUID [16]byte
+
+// String formats u in the standard form, such as
+// "f81d4fae-7dec-11d0-a765-00a0c91e6bf6".
+func (u UUID) String() string {
+	var buf [36]byte
+	hex.Encode(buf[0:8], u[0:4])
+	buf[8] = '-'
+	hex.Encode(buf[9:13], u[4:6])
+	buf[13] = '-'
+	hex.Encode(buf[14:18], u[6:8])
+	buf[18] = '-'
+	hex.Encode(buf[19:23], u[8:10])
+	buf[23] = '-'
+	hex.Encode(buf[24:], u[10:])
+	return string(buf[:])
+}
+
+// ParamUUID returns the path parameter name as a UUID, which must be in
+// the standard hyphenated form, in either case. Errors are as for
+// ParamInt.
+func ParamUUID(ctx context.Context, name string) (UUID, error) {
+	value := Param(ctx, name)
+	var u UUID
+	if len(value) != 36 || value[8] != '-' || value[13] != '-' || value[18] != '-' || value[23] != '-' {
+		return UUID{}, badParam(name, value, "a UUID")
+	}
+	digits := value[0:8] + value[9:13] + value[14:18] + value[19:23] + value[24:]
+	if _, err := hex.Decode(u[:], []byte(digits)); err != nil {
+		return UUID{}, badParam(name, value, "a UUID")
+	}
+	return u, nil
+}
+
+// badParam returns the error for a path parameter whose value is not
+// what was wanted.
+func badParam(name, value, want string) error {
+	if value == "" {
+		return &StatusError{Code: http.StatusBadRequest, Err: fmt.Errorf("path parameter %s is missing", name)}
+	}
+	return &StatusError{Code: http.StatusBadRequest, Err: fmt.Errorf("path parameter %s is %q, not %s", name, value, want)}
+}
@@ -0,0 +1,50 @@
+package sleepy
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+type CustomerOrder struct{}
+
+func (order CustomerOrder) GetContext(ctx context.Context, values url.Values, headers http.Header) (int, interface{}, http.Header) {
+	id, err := ParamInt(ctx, "id")
+	if err != nil {
+		return 0, err, nil
+	}
+	customer, err := ParamUUID(ctx, "customer")
+	if err != nil {
+		return 0, err, nil
+	}
+	return 200, map[string]interface{}{"id": id, "customer": customer.String()}, nil
+}
+
+func TestParams(t *testing.T) {
+	api := NewAPI()
+	api.SetJSONOptions("", true)
+	api.AddResource(new(CustomerOrder), "/customers/{customer}/orders/{id}")
+
+	for _, c := range []struct {
+		path string
+		code int
+		body string
+	}{
+		{"/customers/F81D4FAE-7DEC-11D0-A765-00A0C91E6BF6/orders/42", 200, `{"customer":"f81d4fae-7dec-11d0-a765-00a0c91e6bf6","id":42}`},
+		{"/customers/f81d4fae-7dec-11d0-a765-00a0c91e6bf6/orders/abc", 400, `{"error":"path parameter id is \"abc\", not an integer"}`},
+		{"/customers/f81d4fae7dec11d0a76500a0c91e6bf6/orders/1", 400, `{"error":"path parameter customer is \"f81d4fae7dec11d0a76500a0c91e6bf6\", not a UUID"}`},
+		{"/customers/g81d4fae-7dec-11d0-a765-00a0c91e6bf6/orders/1", 400, `{"error":"path parameter customer is \"g81d4fae-7dec-11d0-a765-00a0c91e6bf6\", not a UUID"}`},
+	} {
+		recorder := httptest.NewRecorder()
+		api.ServeHTTP(recorder, httptest.NewRequest("GET", c.path, nil))
+		if recorder.Code != c.code || recorder.Body.String() != c.body {
+			t.Errorf("%s: got %d %s, want %d %s", c.path, recorder.Code, recorder.Body.String(), c.code, c.body)
+		}
+	}
+
+	if _, err := ParamInt(context.Background(), "id"); err == nil || err.Error() != "path parameter id is missing" {
+		t.Errorf("missing parameter: got %v", err)
+	}
+}
@@ -0,0 +1,103 @@
+package sleepy
+
+import (
+	"bytes"
+	"encoding/json"
+	"sync"
+)
+
+// EnableBufferPooling makes the API encode responses into buffers, and
+// JSON with encoders, that it reuses from one request to the next,
+// rather than allocating fresh ones each time, which eases the load on
+// the garbage collector for busy APIs. An Encoder or ResponseWriter
+// that holds on to the bytes it is given after returning, as the
+// io.Writer contract forbids, sees them overwritten, so pooling is off
+// by default.
+func (api *API) EnableBufferPooling() {
+	api.bufferPooling = true
+}
+
+// bufferPool holds the buffers responses are encoded into when pooling
+// is enabled.
+var bufferPool = sync.Pool{
+	New: func() interface{} { return new(bytes.Buffer) },
+}
+
+// maxPooledBuffer is the capacity above which a buffer is dropped
+// rather than pooled, so that one very large response doesn't keep its
+// memory alive.
+const maxPooledBuffer = 64 << 10
+
+// getBuffer returns an empty buffer, from the pool if pooling is
+// enabled.
+func (api *API) getBuffer() *bytes.Buffer {
+	if !api.bufferPooling {
+		return new(bytes.Buffer)
+	}
+	return bufferPool.Get().(*bytes.Buffer)
+}
+
+// putBuffer returns buf to the pool if pooling is enabled. Nothing may
+// use buf afterwards.
+func (api *API) putBuffer(buf *bytes.Buffer) {
+	if !api.bufferPooling || buf.Cap() > maxPooledBuffer {
+		return
+	}
+	buf.Reset()
+	bufferPool.Put(buf)
+}
+
+// jsonOptions are the settings a JSON encoder is configured with.
+type jsonOptions struct {
+	indent     string
+	escapeHTML bool
+}
+
+// A pooledEncoder is a JSON encoder configured with options that writes
+// into its own buffer.
+type pooledEncoder struct {
+	*json.Encoder
+	buf     bytes.Buffer
+	options jsonOptions
+}
+
+// encoderPools holds a pool of encoders for each set of options in use,
+// so that APIs configured differently never share an encoder.
+var encoderPools sync.Map // jsonOptions -> *sync.Pool
+
+// getEncoder returns a JSON encoder with the API's options and an empty
+// buffer, from the pool if pooling is enabled.
+func (api *API) getEncoder() *pooledEncoder {
+	options := jsonOptions{indent: api.jsonIndent, escapeHTML: api.jsonEscapeHTML}
+	if !api.bufferPooling {
+		return newPooledEncoder(options)
+	}
+	pool, ok := encoderPools.Load(options)
+	if !ok {
+		pool, _ = encoderPools.LoadOrStore(options, &sync.Pool{
+			New: func() interface{} { return newPooledEncoder(options) },
+		})
+	}
+	return pool.(*sync.Pool).Get().(*pooledEncoder)
+}
+
+// putEncoder returns encoder to its pool if pooling is enabled. Nothing
+// may use encoder or its buffer afterwards.
+func (api *API) putEncoder(encoder *pooledEncoder) {
+	if !api.bufferPooling || encoder.buf.Cap() > maxPooledBuffer {
+		return
+	}
+	encoder.buf.Reset()
+	if pool, ok := encoderPools.Load(encoder.options); ok {
+		pool.(*sync.Pool).Put(encoder)
+	}
+}
+
+// newPooledEncoder returns an encoder configured with options.
+func newPooledEncoder(options jsonOptions) *pooledEncoder {
+	encoder := &pooledEncoder{options: options}
+	encoder.Encoder = json.NewEncoder(&encoder.buf)
+	encoder.SetIndent("", options.indent)
+	encoder.SetEscapeHTML(options.escapeHTML)
+	return encoder
+}
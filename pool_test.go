@@ -0,0 +1,116 @@
+package sleepy
+
+import (
+	"fmt"
+	"io"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestBufferPooling(t *testing.T) {
+	serve := func(pooling bool) []string {
+		api := NewAPI()
+		if pooling {
+			api.EnableBufferPooling()
+		}
+		api.AddResourceFunc(GET, "/echo", func(values url.Values) (int, interface{}) {
+			return 200, map[string]string{"echo": values.Get("s")}
+		})
+		var bodies []string
+		// Shrinking responses show up any bytes left over in a reused
+		// buffer.
+		for _, s := range []string{"a long first response", "short", "", "mid-sized"} {
+			recorder := httptest.NewRecorder()
+			api.ServeHTTP(recorder, httptest.NewRequest("GET", "/echo?s="+url.QueryEscape(s), nil))
+			bodies = append(bodies, recorder.Body.String())
+		}
+		return bodies
+	}
+
+	unpooled, pooled := serve(false), serve(true)
+	for i := range unpooled {
+		if pooled[i] != unpooled[i] {
+			t.Errorf("response %d: pooled %q, unpooled %q", i, pooled[i], unpooled[i])
+		}
+	}
+}
+
+func TestEncoderPoolingConcurrent(t *testing.T) {
+	compact, indented := NewAPI(), NewAPI()
+	compact.SetJSONOptions("", true)
+	indented.SetJSONOptions("  ", false)
+	want := map[*API]string{
+		compact:  `{"html":"\u003cb\u003e","n":%d}`,
+		indented: "{\n  \"html\": \"<b>\",\n  \"n\": %d\n}",
+	}
+	for api := range want {
+		api.EnableBufferPooling()
+		api.AddResourceFunc(GET, "/n", func(values url.Values) (int, interface{}) {
+			return 200, map[string]string{"html": "<b>", "n": values.Get("n")}
+		})
+	}
+	for api, body := range want {
+		want[api] = strings.ReplaceAll(body, "%d", `"%d"`)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		for api, body := range want {
+			wg.Add(1)
+			go func(api *API, body string, n int) {
+				defer wg.Done()
+				recorder := httptest.NewRecorder()
+				api.ServeHTTP(recorder, httptest.NewRequest("GET", fmt.Sprintf("/n?n=%d", n), nil))
+				if got, want := recorder.Body.String(), fmt.Sprintf(body, n); got != want {
+					t.Errorf("got %q, want %q", got, want)
+				}
+			}(api, body, i)
+		}
+	}
+	wg.Wait()
+}
+
+func BenchmarkRespond(b *testing.B) {
+	catalog := make([]map[string]interface{}, 100)
+	for i := range catalog {
+		catalog[i] = map[string]interface{}{"id": i, "name": fmt.Sprintf("item %d", i)}
+	}
+	for _, pooling := range []bool{false, true} {
+		b.Run(fmt.Sprintf("pooling=%t", pooling), func(b *testing.B) {
+			api := NewAPI()
+			if pooling {
+				api.EnableBufferPooling()
+			}
+			api.AddResourceFunc(GET, "/catalog", func(values url.Values) (int, interface{}) {
+				return 200, catalog
+			})
+			request := httptest.NewRequest("GET", "/catalog", nil)
+
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				api.ServeHTTP(httptest.NewRecorder(), request)
+			}
+		})
+	}
+}
+
+func BenchmarkEncodeJSON(b *testing.B) {
+	data := map[string]interface{}{"id": 7, "name": "<widget>", "tags": []string{"a", "b"}}
+	for _, pooling := range []bool{false, true} {
+		b.Run(fmt.Sprintf("pooling=%t", pooling), func(b *testing.B) {
+			api := NewAPI()
+			api.SetJSONOptions("", false)
+			if pooling {
+				api.EnableBufferPooling()
+			}
+
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				api.encodeJSON(io.Discard, data)
+			}
+		})
+	}
+}
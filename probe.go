@@ -0,0 +1,50 @@
+package sleepy
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+)
+
+// EnableLivenessProbe registers an endpoint at path, such as "/livez",
+// that answers GET with a 200 and {"status": "ok"} for as long as the
+// API is able to serve requests at all, including while it drains.
+func (api *API) EnableLivenessProbe(path string) {
+	api.addProbe(path, func() (int, interface{}) {
+		return http.StatusOK, map[string]string{"status": "ok"}
+	})
+}
+
+// EnableReadinessProbe registers an endpoint at path, such as
+// "/readyz", that answers GET with a 200 and {"status": "ok"} when the
+// API is ready for traffic. It answers with a 503 instead while the API
+// drains, or when check returns an error, which is reported in the
+// body. check may be nil.
+func (api *API) EnableReadinessProbe(path string, check func() error) {
+	api.addProbe(path, func() (int, interface{}) {
+		if api.isDraining() {
+			return http.StatusServiceUnavailable, map[string]string{"status": "unavailable", "error": "draining"}
+		}
+		if check != nil {
+			if err := check(); err != nil {
+				return http.StatusServiceUnavailable, map[string]string{"status": "unavailable", "error": err.Error()}
+			}
+		}
+		return http.StatusOK, map[string]string{"status": "ok"}
+	})
+}
+
+// addProbe serves probe at path. Probes skip the API-wide hooks, so
+// that they are not rate limited, logged or turned away while draining,
+// and are not listed in Routes.
+func (api *API) addProbe(path string, probe func() (int, interface{})) {
+	if api.probes == nil {
+		api.probes = make(map[string]http.Handler)
+	}
+	api.probes[path] = api.requestHandler(methodSet{
+		GET: func(ctx context.Context, values url.Values, header http.Header) (int, interface{}, http.Header) {
+			code, data := probe()
+			return code, data, nil
+		},
+	})
+}
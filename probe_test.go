@@ -0,0 +1,46 @@
+package sleepy
+
+import (
+	"errors"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestProbes(t *testing.T) {
+	var dbErr error
+	api := NewAPI()
+	api.SetJSONOptions("", true)
+	api.AddResource(new(Item), "/items")
+	api.SetGlobalRPS(1)
+	api.EnableLivenessProbe("/livez")
+	api.EnableReadinessProbe("/readyz", func() error { return dbErr })
+
+	get := func(path string) (int, string) {
+		recorder := httptest.NewRecorder()
+		api.ServeHTTP(recorder, httptest.NewRequest("GET", path, nil))
+		return recorder.Code, recorder.Body.String()
+	}
+
+	for i := 0; i < 3; i++ {
+		if code, body := get("/readyz"); code != 200 || body != `{"status":"ok"}` {
+			t.Errorf("ready: got %d %s", code, body)
+		}
+	}
+
+	dbErr = errors.New("database unreachable")
+	if code, body := get("/readyz"); code != 503 || body != `{"error":"database unreachable","status":"unavailable"}` {
+		t.Errorf("failing check: got %d %s", code, body)
+	}
+	dbErr = nil
+
+	api.Drain()
+	if code, body := get("/readyz"); code != 503 || body != `{"error":"draining","status":"unavailable"}` {
+		t.Errorf("draining: got %d %s", code, body)
+	}
+	if code, body := get("/livez"); code != 200 || body != `{"status":"ok"}` {
+		t.Errorf("live while draining: got %d %s", code, body)
+	}
+	if code, _ := get("/items"); code != 503 {
+		t.Errorf("resource while draining: got %d", code)
+	}
+}
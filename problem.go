@@ -0,0 +1,108 @@
+package sleepy
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// problemContentType is the media type of RFC 7807 problem details.
+const problemContentType = "application/problem+json"
+
+// An ErrorEncoder serializes the body of an error response. Unlike an
+// Encoder it is told the status code, so it can describe the failure.
+type ErrorEncoder interface {
+	EncodeError(w io.Writer, code int, data interface{}) error
+}
+
+// ErrorEncoderFunc adapts an ordinary function to the ErrorEncoder
+// interface.
+type ErrorEncoderFunc func(w io.Writer, code int, data interface{}) error
+
+// EncodeError calls f(w, code, data).
+func (f ErrorEncoderFunc) EncodeError(w io.Writer, code int, data interface{}) error {
+	return f(w, code, data)
+}
+
+// SetErrorEncoder makes the API encode the body of every response with
+// a status of 400 or above with encoder, labelled with contentType,
+// instead of negotiating an Encoder. Pass a nil encoder to go back to
+// treating error responses like any other. For RFC 7807 problem
+// details:
+//
+//	api.SetErrorEncoder("application/problem+json", sleepy.ProblemEncoder)
+func (api *API) SetErrorEncoder(contentType string, encoder ErrorEncoder) {
+	api.errorType = contentType
+	api.errorEncoder = encoder
+}
+
+// SetProblemJSON controls whether a resource method that returns an
+// error as its data is answered with RFC 7807 problem details, labelled
+// application/problem+json, in place of {"error": "..."}:
+//
+//	{"title": "...", "status": 404}
+//
+// The status is the one the method returned, with an error status, or
+// the one RegisterError decides for a zero status. The title is the
+// error's message, or for a zero status the message that would
+// otherwise be reported. Other responses are unaffected.
+func (api *API) SetProblemJSON(enabled bool) {
+	api.problemJSON = enabled
+}
+
+// errorProblem is the data of an error response when SetProblemJSON is
+// on. It is always encoded as problem+json.
+type errorProblem struct {
+	Title  string `json:"title"`
+	Status int    `json:"status"`
+}
+
+// A Problem is an RFC 7807 problem details object. A resource can
+// return one as its data to control every member of the problem
+// response.
+type Problem struct {
+	Type   string `json:"type"`
+	Title  string `json:"title"`
+	Status int    `json:"status"`
+	Detail string `json:"detail,omitempty"`
+}
+
+// ProblemEncoder encodes error responses as RFC 7807 problem details.
+// The title is the standard text for the status code and the detail is
+// taken from the returned data when it is an error, a string or a
+// fmt.Stringer. A returned Problem is used as it is, with any missing
+// members filled in.
+var ProblemEncoder ErrorEncoder = ErrorEncoderFunc(func(w io.Writer, code int, data interface{}) error {
+	return json.NewEncoder(w).Encode(newProblem(code, data))
+})
+
+// newProblem describes a response with the given code and data as a
+// Problem.
+func newProblem(code int, data interface{}) Problem {
+	var problem Problem
+	switch data := data.(type) {
+	case Problem:
+		problem = data
+	case *Problem:
+		if data != nil {
+			problem = *data
+		}
+	case error:
+		problem.Detail = data.Error()
+	case string:
+		problem.Detail = data
+	case fmt.Stringer:
+		problem.Detail = data.String()
+	}
+	if problem.Type == "" {
+		problem.Type = "about:blank"
+	}
+	if problem.Title == "" {
+		problem.Title = http.StatusText(code)
+	}
+	if problem.Status == 0 {
+		problem.Status = code
+	}
+	return problem
+}
@@ -0,0 +1,88 @@
+package sleepy
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+type Lookup struct{}
+
+func (lookup Lookup) Get(values url.Values, headers http.Header) (int, interface{}, http.Header) {
+	if values.Get("id") != "1" {
+		return 404, errors.New("no item with id " + values.Get("id")), nil
+	}
+	return 200, map[string]string{"id": "1"}, nil
+}
+
+func TestProblemEncoder(t *testing.T) {
+	api := NewAPI()
+	api.AddResource(new(Lookup), "/lookup")
+	api.SetErrorEncoder(problemContentType, ProblemEncoder)
+
+	recorder := httptest.NewRecorder()
+	api.ServeHTTP(recorder, httptest.NewRequest("GET", "/lookup?id=7", nil))
+	if ct := recorder.Header().Get("Content-Type"); recorder.Code != 404 || ct != problemContentType {
+		t.Errorf("404: got %d with Content-Type %q", recorder.Code, ct)
+	}
+	var problem Problem
+	if err := json.Unmarshal(recorder.Body.Bytes(), &problem); err != nil {
+		t.Fatal(err)
+	}
+	expected := Problem{Type: "about:blank", Title: "Not Found", Status: 404, Detail: "no item with id 7"}
+	if problem != expected {
+		t.Errorf("404: got %+v, want %+v", problem, expected)
+	}
+
+	recorder = httptest.NewRecorder()
+	api.ServeHTTP(recorder, httptest.NewRequest("GET", "/lookup?id=1", nil))
+	if ct := recorder.Header().Get("Content-Type"); recorder.Code != 200 || ct != "application/json" {
+		t.Errorf("200: got %d with Content-Type %q", recorder.Code, ct)
+	}
+	if body := recorder.Body.String(); body != "{\n  \"id\": \"1\"\n}" {
+		t.Errorf("200: got %q", body)
+	}
+}
+
+func TestProblemJSON(t *testing.T) {
+	notFound := errors.New("not found")
+	api := NewAPI()
+	api.SetJSONOptions("", true)
+	api.SetProblemJSON(true)
+	api.RegisterError(notFound, 404, "no such item")
+	api.AddResource(new(Lookup), "/lookup")
+	api.AddResourceFunc(GET, "/registered", func(values url.Values) (int, interface{}) {
+		return 0, notFound
+	})
+	api.AddResourceFunc(GET, "/unregistered", func(values url.Values) (int, interface{}) {
+		return 0, errors.New("database is down")
+	})
+
+	for _, c := range []struct {
+		path string
+		code int
+		body string
+	}{
+		{"/lookup?id=7", 404, `{"title":"no item with id 7","status":404}`},
+		{"/registered", 404, `{"title":"no such item","status":404}`},
+		{"/unregistered", 500, `{"title":"internal server error","status":500}`},
+	} {
+		recorder := httptest.NewRecorder()
+		api.ServeHTTP(recorder, httptest.NewRequest("GET", c.path, nil))
+		if ct := recorder.Header().Get("Content-Type"); recorder.Code != c.code || ct != problemContentType {
+			t.Errorf("%s: got %d with Content-Type %q", c.path, recorder.Code, ct)
+		}
+		if body := recorder.Body.String(); body != c.body {
+			t.Errorf("%s: got %q, want %q", c.path, body, c.body)
+		}
+	}
+
+	recorder := httptest.NewRecorder()
+	api.ServeHTTP(recorder, httptest.NewRequest("GET", "/lookup?id=1", nil))
+	if ct := recorder.Header().Get("Content-Type"); recorder.Code != 200 || ct != "application/json" {
+		t.Errorf("200: got %d with Content-Type %q", recorder.Code, ct)
+	}
+}
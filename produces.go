@@ -0,0 +1,60 @@
+package sleepy
+
+import (
+	"context"
+	"net/http"
+)
+
+// ProducesSupported is the interface a resource implements to declare
+// the content types its responses can be encoded as, such as only
+// "application/json" for data that has no sensible XML or form
+// encoding. A request whose Accept header allows none of them is
+// answered with a 406 Not Acceptable before any of the resource's
+// methods run, and the content type of other responses is negotiated
+// among them alone. A request without an Accept header gets the first.
+type ProducesSupported interface {
+	ProducesContentTypes() []string
+}
+
+// acceptable reports whether the client making request accepts one of
+// the content types resource produces, and returns request with the
+// types recorded for negotiating the response. If not, it has already
+// answered the request.
+func (api *API) acceptable(rw http.ResponseWriter, request *http.Request, resource interface{}) (*http.Request, bool) {
+	producer, ok := resource.(ProducesSupported)
+	if !ok {
+		return request, true
+	}
+	produces := producer.ProducesContentTypes()
+	if len(produces) == 0 {
+		return request, true
+	}
+	request = request.WithContext(SetContextValue(request.Context(), producesKey, produces))
+	if NegotiateContentType(request, produces) == "" {
+		api.respond(rw, request, http.StatusNotAcceptable, map[string]string{"error": "not acceptable"}, nil)
+		return request, false
+	}
+	return request, true
+}
+
+// resourceProduces returns the content types the resource serving the
+// request with ctx produces, or nil if it didn't declare any.
+func resourceProduces(ctx context.Context) []string {
+	produces, _ := GetContextValue(ctx, producesKey).([]string)
+	return produces
+}
+
+// onlyProduced returns the elements of offered among produces, or
+// offered itself if there are none.
+func onlyProduced(offered, produces []string) []string {
+	var kept []string
+	for _, contentType := range offered {
+		if containsType(produces, contentType) {
+			kept = append(kept, contentType)
+		}
+	}
+	if len(kept) == 0 {
+		return offered
+	}
+	return kept
+}
@@ -0,0 +1,49 @@
+package sleepy
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+// Metrics can only be sent as JSON.
+type Metrics struct{}
+
+func (metrics Metrics) Get(values url.Values, headers http.Header) (int, interface{}, http.Header) {
+	return 200, map[string]int{"requests": 12}, nil
+}
+
+func (metrics Metrics) ProducesContentTypes() []string {
+	return []string{"application/json"}
+}
+
+func TestProducesContentTypes(t *testing.T) {
+	api := NewAPI()
+	api.SetJSONOptions("", true)
+	api.RegisterEncoder("application/xml", XMLEncoder)
+	api.AddResource(new(Metrics), "/metrics")
+
+	for _, c := range []struct {
+		accept, contentType string
+		code                int
+	}{
+		{"application/xml", "application/json", 406},
+		{"application/json", "application/json", 200},
+		{"application/xml, application/json;q=0.5", "application/json", 200},
+		{"", "application/json", 200},
+	} {
+		request := httptest.NewRequest("GET", "/metrics", nil)
+		if c.accept != "" {
+			request.Header.Set("Accept", c.accept)
+		}
+		recorder := httptest.NewRecorder()
+		api.ServeHTTP(recorder, request)
+		if recorder.Code != c.code || recorder.Header().Get("Content-Type") != c.contentType {
+			t.Errorf("Accept %q: got %d as %q, want %d as %q", c.accept, recorder.Code, recorder.Header().Get("Content-Type"), c.code, c.contentType)
+		}
+		if recorder.Header().Get("Vary") != "Accept" {
+			t.Errorf("Accept %q: got Vary %q, want Accept", c.accept, recorder.Header().Get("Vary"))
+		}
+	}
+}
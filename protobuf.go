@@ -0,0 +1,75 @@
+package sleepy
+
+import (
+	"context"
+	"errors"
+	"io"
+	"mime"
+)
+
+// protobufContentType is the content type of protocol buffer request
+// and response bodies.
+const protobufContentType = "application/protobuf"
+
+// ProtoMessage is the interface implemented by protocol buffer messages
+// generated by protoc-gen-go. It has the same methods as proto.Message
+// from github.com/golang/protobuf, so that the API can recognize
+// messages without depending on a protobuf library.
+type ProtoMessage interface {
+	Reset()
+	String() string
+	ProtoMessage()
+}
+
+// SetProtoCodec lets the API speak protocol buffers. Once it is set, a
+// resource method that returns a ProtoMessage responds with the message
+// encoded by marshal, as application/protobuf, to requests that prefer
+// it in their Accept header; others still get JSON. ProtoUnmarshal
+// decodes request bodies with unmarshal. With the golang/protobuf
+// package:
+//
+//	api.SetProtoCodec(
+//		func(m sleepy.ProtoMessage) ([]byte, error) { return proto.Marshal(m) },
+//		func(b []byte, m sleepy.ProtoMessage) error { return proto.Unmarshal(b, m) },
+//	)
+func (api *API) SetProtoCodec(marshal func(ProtoMessage) ([]byte, error), unmarshal func([]byte, ProtoMessage) error) {
+	api.protoMarshal = marshal
+	api.protoUnmarshal = unmarshal
+}
+
+// protoEncoder encodes messages with the API's codec.
+type protoEncoder struct {
+	api *API
+}
+
+func (e protoEncoder) Encode(w io.Writer, data interface{}) error {
+	content, err := e.api.protoMarshal(data.(ProtoMessage))
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(content)
+	return err
+}
+
+// ProtoUnmarshal decodes the application/protobuf body of the request
+// being served into m, from the context passed to a context-aware
+// resource method, using the codec given to SetProtoCodec. It fails if
+// the body has another content type or no codec is set.
+func ProtoUnmarshal(ctx context.Context, m ProtoMessage) error {
+	request := RequestFromContext(ctx)
+	if request == nil {
+		return errors.New("sleepy: no request in context")
+	}
+	api, _ := GetContextValue(ctx, apiKey).(*API)
+	if api == nil || api.protoUnmarshal == nil {
+		return errors.New("sleepy: no protocol buffer codec is set")
+	}
+	if mediaType, _, _ := mime.ParseMediaType(request.Header.Get("Content-Type")); mediaType != protobufContentType {
+		return errors.New("sleepy: request body is not application/protobuf")
+	}
+	body, err := io.ReadAll(request.Body)
+	if err != nil {
+		return err
+	}
+	return api.protoUnmarshal(body, m)
+}
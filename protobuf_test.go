@@ -0,0 +1,111 @@
+package sleepy
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+// Greeting stands in for a generated message. Its wire format, as far
+// as these tests are concerned, is "greeting:" followed by the text.
+type Greeting struct {
+	Text string `json:"text"`
+}
+
+func (greeting *Greeting) Reset()         { *greeting = Greeting{} }
+func (greeting *Greeting) String() string { return greeting.Text }
+func (greeting *Greeting) ProtoMessage()  {}
+
+func marshalGreeting(m ProtoMessage) ([]byte, error) {
+	return []byte("greeting:" + m.(*Greeting).Text), nil
+}
+
+func unmarshalGreeting(b []byte, m ProtoMessage) error {
+	text, ok := strings.CutPrefix(string(b), "greeting:")
+	if !ok {
+		return errors.New("not a greeting")
+	}
+	m.(*Greeting).Text = text
+	return nil
+}
+
+type Greeter struct{}
+
+func (greeter Greeter) GetContext(ctx context.Context, values url.Values, headers http.Header) (int, interface{}, http.Header) {
+	return 200, &Greeting{Text: "hello"}, nil
+}
+
+func (greeter Greeter) PostContext(ctx context.Context, values url.Values, headers http.Header) (int, interface{}, http.Header) {
+	var greeting Greeting
+	if err := ProtoUnmarshal(ctx, &greeting); err != nil {
+		return 400, map[string]string{"error": err.Error()}, nil
+	}
+	return 200, &Greeting{Text: greeting.Text + " to you too"}, nil
+}
+
+func TestProtobufResponse(t *testing.T) {
+	api := NewAPI()
+	api.SetJSONOptions("", true)
+	api.SetProtoCodec(marshalGreeting, unmarshalGreeting)
+	api.AddResource(Greeter{}, "/greeting")
+
+	for _, c := range []struct {
+		accept, contentType, body string
+	}{
+		{"application/protobuf", "application/protobuf", "greeting:hello"},
+		{"application/json", "application/json", `{"text":"hello"}`},
+		{"", "application/json", `{"text":"hello"}`},
+	} {
+		request := httptest.NewRequest("GET", "/greeting", nil)
+		request.Header.Set("Accept", c.accept)
+		recorder := httptest.NewRecorder()
+		api.ServeHTTP(recorder, request)
+		if ct := recorder.Header().Get("Content-Type"); ct != c.contentType {
+			t.Errorf("Accept %q: Content-Type = %q, want %q", c.accept, ct, c.contentType)
+		}
+		if body := recorder.Body.String(); body != c.body {
+			t.Errorf("Accept %q: body = %q, want %q", c.accept, body, c.body)
+		}
+	}
+}
+
+func TestProtobufWithoutCodec(t *testing.T) {
+	api := NewAPI()
+	api.SetJSONOptions("", true)
+	api.AddResource(Greeter{}, "/greeting")
+
+	request := httptest.NewRequest("GET", "/greeting", nil)
+	request.Header.Set("Accept", "application/protobuf")
+	recorder := httptest.NewRecorder()
+	api.ServeHTTP(recorder, request)
+	if body := recorder.Body.String(); body != `{"text":"hello"}` {
+		t.Errorf("body = %q, want JSON", body)
+	}
+}
+
+func TestProtoUnmarshal(t *testing.T) {
+	api := NewAPI()
+	api.SetProtoCodec(marshalGreeting, unmarshalGreeting)
+	api.AddResource(Greeter{}, "/greeting")
+
+	request := httptest.NewRequest("POST", "/greeting", strings.NewReader("greeting:hi"))
+	request.Header.Set("Content-Type", "application/protobuf")
+	request.Header.Set("Accept", "application/protobuf")
+	recorder := httptest.NewRecorder()
+	api.ServeHTTP(recorder, request)
+	if recorder.Code != 200 || recorder.Body.String() != "greeting:hi to you too" {
+		t.Errorf("got %d %q", recorder.Code, recorder.Body.String())
+	}
+
+	request = httptest.NewRequest("POST", "/greeting", strings.NewReader(`{"text":"hi"}`))
+	request.Header.Set("Content-Type", "application/json")
+	recorder = httptest.NewRecorder()
+	api.ServeHTTP(recorder, request)
+	if recorder.Code != 400 {
+		t.Errorf("JSON body: got %d, want 400", recorder.Code)
+	}
+}
@@ -0,0 +1,72 @@
+package sleepy
+
+import (
+	"net/http"
+	"sync"
+	"time"
+)
+
+// A tokenBucket allows events at a steady rate with bursts up to its
+// capacity.
+type tokenBucket struct {
+	mu       sync.Mutex
+	rate     float64 // tokens added per second
+	capacity float64
+	tokens   float64
+	last     time.Time
+}
+
+// newTokenBucket returns a full bucket refilling at rate tokens per
+// second. It holds at most one second's worth of tokens, and never
+// fewer than one.
+func newTokenBucket(rate float64) *tokenBucket {
+	capacity := rate
+	if capacity < 1 {
+		capacity = 1
+	}
+	return &tokenBucket{rate: rate, capacity: capacity, tokens: capacity, last: time.Now()}
+}
+
+// take removes a token if one is available and reports whether it did.
+// If it did not, it also returns how long until a token will be.
+func (b *tokenBucket) take() (bool, time.Duration) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := time.Now()
+	b.tokens += now.Sub(b.last).Seconds() * b.rate
+	if b.tokens > b.capacity {
+		b.tokens = b.capacity
+	}
+	b.last = now
+
+	if b.tokens < 1 {
+		return false, time.Duration((1 - b.tokens) / b.rate * float64(time.Second))
+	}
+	b.tokens--
+	return true, 0
+}
+
+// SetGlobalRPS limits the whole API, across every resource including
+// those added later, to rps requests per second on average, with bursts
+// of up to one second's worth. Requests over the limit are answered
+// with a 429 Too Many Requests and a Retry-After header. An rps of zero
+// or less removes the limit.
+func (api *API) SetGlobalRPS(rps float64) {
+	if rps <= 0 {
+		api.rateLimiter = nil
+		return
+	}
+	api.rateLimiter = newTokenBucket(rps)
+}
+
+func (api *API) rateLimitHandler(next http.Handler) http.Handler {
+	limiter := api.rateLimiter
+	return http.HandlerFunc(func(rw http.ResponseWriter, request *http.Request) {
+		if ok, wait := limiter.take(); !ok {
+			writeRetryAfter(rw, http.StatusTooManyRequests, wait)
+			return
+		}
+		next.ServeHTTP(rw, request)
+	})
+}
@@ -0,0 +1,47 @@
+package sleepy
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestSetGlobalRPS(t *testing.T) {
+	api := NewAPI()
+	api.AddResource(new(Item), "/items")
+	api.SetGlobalRPS(3)
+	api.AddResource(new(Upload), "/uploads")
+
+	codes := make([]int, 0, 5)
+	for _, path := range []string{"/items", "/uploads", "/items", "/uploads", "/items"} {
+		recorder := httptest.NewRecorder()
+		api.ServeHTTP(recorder, httptest.NewRequest("POST", path, nil))
+		codes = append(codes, recorder.Code)
+		if recorder.Code == http.StatusTooManyRequests && recorder.Header().Get("Retry-After") != "1" {
+			t.Errorf("429 with Retry-After %q, want 1", recorder.Header().Get("Retry-After"))
+		}
+	}
+	for i, code := range codes {
+		limited := code == http.StatusTooManyRequests
+		if limited != (i >= 3) {
+			t.Errorf("request %d: got %d", i, code)
+		}
+	}
+
+	time.Sleep(400 * time.Millisecond)
+	recorder := httptest.NewRecorder()
+	api.ServeHTTP(recorder, httptest.NewRequest("GET", "/items", nil))
+	if recorder.Code != http.StatusOK {
+		t.Errorf("after refill: got %d", recorder.Code)
+	}
+
+	api.SetGlobalRPS(0)
+	for i := 0; i < 10; i++ {
+		recorder := httptest.NewRecorder()
+		api.ServeHTTP(recorder, httptest.NewRequest("GET", "/items", nil))
+		if recorder.Code != http.StatusOK {
+			t.Fatalf("limit removed: request %d got %d", i, recorder.Code)
+		}
+	}
+}
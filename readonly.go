@@ -0,0 +1,37 @@
+package sleepy
+
+import (
+	"net/http"
+	"time"
+)
+
+// readOnlyRetryAfter is how long clients turned away by maintenance mode
+// are told to wait before trying again.
+const readOnlyRetryAfter = time.Minute
+
+// SetReadOnly turns maintenance mode on or off. While it is on, requests
+// that may change something, such as POST, PUT, PATCH and DELETE, are
+// answered with a 503 Service Unavailable whose body is
+// {"error": "maintenance mode"} and whose Retry-After header asks the
+// client to wait a minute, and only safe requests (GET, HEAD, OPTIONS
+// and TRACE) reach the resources. It may be called while the API is
+// serving.
+func (api *API) SetReadOnly(readOnly bool) {
+	api.readOnly.Store(readOnly)
+}
+
+// readOnlyHandler rejects unsafe requests while the API is read-only.
+func (api *API) readOnlyHandler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, request *http.Request) {
+		if api.readOnly.Load() {
+			switch request.Method {
+			case GET, HEAD, "OPTIONS", "TRACE":
+			default:
+				setRetryAfter(rw.Header(), readOnlyRetryAfter)
+				api.respond(rw, request, http.StatusServiceUnavailable, map[string]string{"error": "maintenance mode"}, nil)
+				return
+			}
+		}
+		next.ServeHTTP(rw, request)
+	})
+}
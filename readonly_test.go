@@ -0,0 +1,42 @@
+package sleepy
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+func TestSetReadOnly(t *testing.T) {
+	api := NewAPI()
+	api.SetJSONOptions("", true)
+	api.AddResource(new(User), "/user")
+	api.SetReadOnly(true)
+
+	for _, test := range []struct {
+		method string
+		code   int
+		body   string
+	}{
+		{"GET", 200, `{"name":"doug"}`},
+		{"HEAD", 200, ""},
+		{"POST", 503, `{"error":"maintenance mode"}`},
+		{"PUT", 503, `{"error":"maintenance mode"}`},
+		{"DELETE", 503, `{"error":"maintenance mode"}`},
+		{"PATCH", 503, `{"error":"maintenance mode"}`},
+	} {
+		recorder := httptest.NewRecorder()
+		api.ServeHTTP(recorder, httptest.NewRequest(test.method, "/user?name=ann", nil))
+		if recorder.Code != test.code || recorder.Body.String() != test.body {
+			t.Errorf("%s: got %d %q, want %d %q", test.method, recorder.Code, recorder.Body.String(), test.code, test.body)
+		}
+		if retryAfter := recorder.Header().Get("Retry-After"); (test.code == 503) != (retryAfter == "60") {
+			t.Errorf("%s: got Retry-After %q", test.method, retryAfter)
+		}
+	}
+
+	api.SetReadOnly(false)
+	recorder := httptest.NewRecorder()
+	api.ServeHTTP(recorder, httptest.NewRequest("POST", "/user?name=ann", nil))
+	if recorder.Code != 201 {
+		t.Errorf("POST after maintenance: got %d, want 201", recorder.Code)
+	}
+}
@@ -0,0 +1,68 @@
+package sleepy
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"strings"
+)
+
+// RealIP returns the address of the client that originated request.
+//
+// That is the remote address of the connection unless the connection
+// comes from one of trustedProxies. In that case the X-Forwarded-For
+// chain is walked from the right, skipping trusted proxies, and the
+// first untrusted address is returned; if every hop is trusted, the
+// leftmost is. A trusted proxy that sends X-Real-IP instead of
+// X-Forwarded-For is believed. RealIP returns nil if no address can be
+// determined.
+func RealIP(request *http.Request, trustedProxies []*net.IPNet) net.IP {
+	host, _, err := net.SplitHostPort(request.RemoteAddr)
+	if err != nil {
+		host = request.RemoteAddr
+	}
+	ip := net.ParseIP(host)
+	if ip == nil || !containsIP(trustedProxies, ip) {
+		return ip
+	}
+
+	var hops []string
+	for _, header := range request.Header.Values("X-Forwarded-For") {
+		hops = append(hops, strings.Split(header, ",")...)
+	}
+	if len(hops) == 0 {
+		if real := net.ParseIP(strings.TrimSpace(request.Header.Get("X-Real-IP"))); real != nil {
+			return real
+		}
+	}
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop := net.ParseIP(strings.TrimSpace(hops[i]))
+		if hop == nil {
+			break
+		}
+		ip = hop
+		if !containsIP(trustedProxies, hop) {
+			break
+		}
+	}
+	return ip
+}
+
+// RealIPMiddleware returns middleware that determines each request's
+// client address with RealIP and stores it in the request context,
+// where RealIPFromContext retrieves it.
+func RealIPMiddleware(trustedProxies []*net.IPNet) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(rw http.ResponseWriter, request *http.Request) {
+			ip := RealIP(request, trustedProxies)
+			next.ServeHTTP(rw, request.WithContext(SetContextValue(request.Context(), realIPKey, ip)))
+		})
+	}
+}
+
+// RealIPFromContext returns the client address stored by
+// RealIPMiddleware, or nil if there is none.
+func RealIPFromContext(ctx context.Context) net.IP {
+	ip, _ := GetContextValue(ctx, realIPKey).(net.IP)
+	return ip
+}
@@ -0,0 +1,55 @@
+package sleepy
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRealIP(t *testing.T) {
+	trusted := mustParseNetworks([]string{"10.0.0.0/8"})
+
+	cases := []struct {
+		remote, forwarded, real string
+		expected                string
+	}{
+		{"203.0.113.5:1234", "", "", "203.0.113.5"},
+		{"203.0.113.5:1234", "198.51.100.1", "", "203.0.113.5"},
+		{"10.0.0.1:1234", "198.51.100.1", "", "198.51.100.1"},
+		{"10.0.0.1:1234", "198.51.100.9, 198.51.100.1, 10.0.0.2", "", "198.51.100.1"},
+		{"10.0.0.1:1234", "10.0.0.3, 10.0.0.2", "", "10.0.0.3"},
+		{"10.0.0.1:1234", "", "198.51.100.7", "198.51.100.7"},
+		{"10.0.0.1:1234", "", "", "10.0.0.1"},
+	}
+	for _, c := range cases {
+		request := httptest.NewRequest("GET", "/", nil)
+		request.RemoteAddr = c.remote
+		if c.forwarded != "" {
+			request.Header.Set("X-Forwarded-For", c.forwarded)
+		}
+		if c.real != "" {
+			request.Header.Set("X-Real-IP", c.real)
+		}
+		if got := RealIP(request, trusted); !got.Equal(net.ParseIP(c.expected)) {
+			t.Errorf("remote %s forwarded %q real %q: got %v, want %s", c.remote, c.forwarded, c.real, got, c.expected)
+		}
+	}
+}
+
+func TestRealIPMiddleware(t *testing.T) {
+	var seen net.IP
+	handler := RealIPMiddleware(mustParseNetworks([]string{"10.0.0.0/8"}))(
+		http.HandlerFunc(func(rw http.ResponseWriter, request *http.Request) {
+			seen = RealIPFromContext(request.Context())
+		}))
+
+	request := httptest.NewRequest("GET", "/", nil)
+	request.RemoteAddr = "10.0.0.1:1234"
+	request.Header.Set("X-Forwarded-For", "198.51.100.1")
+	handler.ServeHTTP(httptest.NewRecorder(), request)
+
+	if !seen.Equal(net.ParseIP("198.51.100.1")) {
+		t.Errorf("context held %v", seen)
+	}
+}
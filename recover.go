@@ -0,0 +1,89 @@
+package sleepy
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"runtime/debug"
+)
+
+// SetDebug turns debug mode on or off. In debug mode the API reveals
+// more about failures in its responses, such as the message of a panic
+// that caused a 500, and logs the headers of requests and responses, as
+// SetRedactedHeaders describes. Leave it off in production.
+func (api *API) SetDebug(debug bool) {
+	api.debug = debug
+}
+
+// SetPanicHandler sets fn to be called with the request, the recovered
+// value and the stack trace whenever a resource panics, once the 500 has
+// been sent, so that the panic can be counted or alerted on. fn runs on
+// the request's goroutine; one that raises slow alerts should hand them
+// off to a goroutine of its own. By default panics are logged with
+// log/slog's default logger at ERROR level, stack trace included. A nil
+// fn restores the default.
+func (api *API) SetPanicHandler(fn func(r *http.Request, recovered interface{}, stack []byte)) {
+	api.onPanic = fn
+}
+
+// logPanic is the default panic handler.
+func logPanic(request *http.Request, recovered interface{}, stack []byte) {
+	slog.Error("sleepy: panic serving request",
+		"method", request.Method,
+		"path", request.URL.Path,
+		"panic", fmt.Sprint(recovered),
+		"stack", string(stack))
+}
+
+// handlerPanic carries a panic, with the stack of the goroutine it
+// happened on, from a goroutine that ran a handler to the one that
+// recovers it.
+type handlerPanic struct {
+	value interface{}
+	stack []byte
+}
+
+// recoverHandler turns a panic anywhere below it into a 500 Internal
+// Server Error and reports it to the panic handler. The response body
+// is empty unless debug mode is on, in which case it carries the panic
+// message (but never the stack).
+func (api *API) recoverHandler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, request *http.Request) {
+		defer func() {
+			recovered := recover()
+			if recovered == nil {
+				return
+			}
+			stack := debug.Stack()
+			if carried, ok := recovered.(*handlerPanic); ok {
+				recovered, stack = carried.value, carried.stack
+			}
+			if recovered == http.ErrAbortHandler {
+				panic(recovered)
+			}
+
+			if !api.debug {
+				rw.WriteHeader(http.StatusInternalServerError)
+			} else {
+				body, _ := json.Marshal(map[string]string{"error": fmt.Sprintf("panic: %v", recovered)})
+				rw.Header().Set("Content-Type", jsonContentType)
+				rw.WriteHeader(http.StatusInternalServerError)
+				rw.Write(body)
+			}
+
+			api.reportPanic(request, recovered, stack)
+		}()
+		next.ServeHTTP(rw, request)
+	})
+}
+
+// reportPanic passes a panic recovered while serving request to the
+// panic handler.
+func (api *API) reportPanic(request *http.Request, recovered interface{}, stack []byte) {
+	onPanic := api.onPanic
+	if onPanic == nil {
+		onPanic = logPanic
+	}
+	onPanic(request, recovered, stack)
+}
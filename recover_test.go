@@ -0,0 +1,83 @@
+package sleepy
+
+import (
+	"bytes"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+type Broken struct{}
+
+func (broken Broken) Get(values url.Values, headers http.Header) (int, interface{}, http.Header) {
+	panic("database unreachable")
+}
+
+func TestPanicRecovery(t *testing.T) {
+	api := NewAPI()
+	api.AddResource(new(Broken), "/broken")
+
+	recorder := httptest.NewRecorder()
+	api.ServeHTTP(recorder, httptest.NewRequest("GET", "/broken", nil))
+	if recorder.Code != 500 || recorder.Body.Len() != 0 {
+		t.Errorf("debug off: got %d %q", recorder.Code, recorder.Body.String())
+	}
+
+	api.SetDebug(true)
+	recorder = httptest.NewRecorder()
+	api.ServeHTTP(recorder, httptest.NewRequest("GET", "/broken", nil))
+	if recorder.Code != 500 || recorder.Body.String() != `{"error":"panic: database unreachable"}` {
+		t.Errorf("debug on: got %d %q", recorder.Code, recorder.Body.String())
+	}
+	if ct := recorder.Header().Get("Content-Type"); ct != "application/json" {
+		t.Errorf("debug on: Content-Type %q", ct)
+	}
+}
+
+func TestPanicHandler(t *testing.T) {
+	api := NewAPI()
+	api.SetDefaultTimeout(time.Second)
+	api.AddResource(new(Broken), "/broken")
+
+	var path string
+	var recovered interface{}
+	var stack []byte
+	api.SetPanicHandler(func(r *http.Request, v interface{}, s []byte) {
+		path, recovered, stack = r.URL.Path, v, s
+	})
+
+	recorder := httptest.NewRecorder()
+	api.ServeHTTP(recorder, httptest.NewRequest("GET", "/broken", nil))
+	if recorder.Code != 500 {
+		t.Errorf("got %d, want 500", recorder.Code)
+	}
+	if path != "/broken" || recovered != "database unreachable" {
+		t.Errorf("handler called with %q, %v", path, recovered)
+	}
+	// The stack is the one the panic happened on, not the goroutine that
+	// applied the timeout.
+	if !strings.Contains(string(stack), "Broken.Get") {
+		t.Errorf("stack does not reach the resource:\n%s", stack)
+	}
+}
+
+func TestPanicHandlerDefault(t *testing.T) {
+	var out bytes.Buffer
+	defer slog.SetDefault(slog.Default())
+	slog.SetDefault(slog.New(slog.NewTextHandler(&out, nil)))
+
+	api := NewAPI()
+	api.AddResource(new(Broken), "/broken")
+	api.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/broken", nil))
+
+	logged := out.String()
+	for _, want := range []string{"level=ERROR", "path=/broken", `panic="database unreachable"`, "Broken.Get"} {
+		if !strings.Contains(logged, want) {
+			t.Errorf("log %q lacks %q", logged, want)
+		}
+	}
+}
@@ -0,0 +1,36 @@
+package sleepy
+
+import (
+	"net/http"
+	"net/url"
+)
+
+// A RedirectResource answers GET and HEAD requests by redirecting the
+// client to URL with the status Code, which should be one of the 3xx
+// redirect codes. Code defaults to 302 Found.
+type RedirectResource struct {
+	URL  string
+	Code int
+}
+
+func (redirect RedirectResource) Get(values url.Values, headers http.Header) (int, interface{}, http.Header) {
+	code := redirect.Code
+	if code == 0 {
+		code = http.StatusFound
+	}
+	return code, map[string]string{"location": redirect.URL}, http.Header{"Location": {redirect.URL}}
+}
+
+func (redirect RedirectResource) Head(values url.Values, headers http.Header) (int, interface{}, http.Header) {
+	return redirect.Get(values, headers)
+}
+
+// AddRedirect redirects requests for from to to, with a 301 Moved
+// Permanently if permanent is true and a 302 Found otherwise.
+func (api *API) AddRedirect(from, to string, permanent bool) {
+	code := http.StatusFound
+	if permanent {
+		code = http.StatusMovedPermanently
+	}
+	api.AddResource(RedirectResource{URL: to, Code: code}, from)
+}
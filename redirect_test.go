@@ -0,0 +1,34 @@
+package sleepy
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+func TestAddRedirect(t *testing.T) {
+	api := NewAPI()
+	api.AddRedirect("/old", "/new", true)
+	api.AddRedirect("/promo", "https://example.com/sale", false)
+	api.AddResource(RedirectResource{URL: "/elsewhere"}, "/default")
+
+	for _, test := range []struct {
+		path, location string
+		code           int
+	}{
+		{"/old", "/new", 301},
+		{"/promo", "https://example.com/sale", 302},
+		{"/default", "/elsewhere", 302},
+	} {
+		recorder := httptest.NewRecorder()
+		api.ServeHTTP(recorder, httptest.NewRequest("GET", test.path, nil))
+		if recorder.Code != test.code || recorder.Header().Get("Location") != test.location {
+			t.Errorf("%s: got %d to %q, want %d to %q", test.path, recorder.Code, recorder.Header().Get("Location"), test.code, test.location)
+		}
+	}
+
+	recorder := httptest.NewRecorder()
+	api.ServeHTTP(recorder, httptest.NewRequest("POST", "/old", nil))
+	if recorder.Code != 405 {
+		t.Errorf("POST: got %d, want 405", recorder.Code)
+	}
+}
@@ -0,0 +1,24 @@
+package sleepy
+
+import "fmt"
+
+// ReplaceResource serves resource at path in place of the resource
+// registered there, handling it the same way and keeping any options
+// the route was added with. Requests already being served finish with
+// the old resource; those that arrive afterwards reach the new one, so
+// the old resource is not closed. A new resource that implements
+// Initializer is initialized first. ReplaceResource returns an error if
+// no resource is registered at path or Init fails, in which case the
+// old resource is kept.
+func (api *API) ReplaceResource(path string, resource interface{}) error {
+	for _, r := range api.routes {
+		if r.path == path {
+			if err := api.initResource(resource); err != nil {
+				return fmt.Errorf("sleepy: initializing the resource at %s: %w", path, err)
+			}
+			r.target.Store(&routeTarget{resource: resource, handler: r.build(resource)})
+			return nil
+		}
+	}
+	return fmt.Errorf("sleepy: no resource is registered at %s", path)
+}
@@ -0,0 +1,69 @@
+package sleepy
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"testing"
+)
+
+type Release string
+
+func (release Release) Get(values url.Values, headers http.Header) (int, interface{}, http.Header) {
+	return 200, string(release), nil
+}
+
+func TestReplaceResource(t *testing.T) {
+	api := NewAPI()
+	api.AddResource(Release("v1"), "/release")
+
+	get := func() string {
+		recorder := httptest.NewRecorder()
+		api.ServeHTTP(recorder, httptest.NewRequest("GET", "/release", nil))
+		return recorder.Body.String()
+	}
+	if body := get(); body != `"v1"` {
+		t.Fatalf("before replacing: body = %s", body)
+	}
+
+	if err := api.ReplaceResource("/release", Release("v2")); err != nil {
+		t.Fatal(err)
+	}
+	if body := get(); body != `"v2"` {
+		t.Errorf("after replacing: body = %s", body)
+	}
+}
+
+func TestReplaceResourceKeepsHandling(t *testing.T) {
+	api := NewAPI()
+	api.AddResourceText(Version{1, 0}, "/version")
+
+	if err := api.ReplaceResource("/version", Version{2, 1}); err != nil {
+		t.Fatal(err)
+	}
+	recorder := httptest.NewRecorder()
+	api.ServeHTTP(recorder, httptest.NewRequest("GET", "/version", nil))
+	if body := recorder.Body.String(); body != "v2.1" {
+		t.Errorf("body = %q, want plain text v2.1", body)
+	}
+}
+
+func TestReplaceResourceRoutes(t *testing.T) {
+	api := NewAPI()
+	api.AddResource(Release("v1"), "/release")
+	if err := api.ReplaceResource("/release", new(User)); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{GET, POST, PUT, DELETE}
+	if methods := api.Routes()[0].Methods; !reflect.DeepEqual(methods, want) {
+		t.Errorf("methods = %v, want %v", methods, want)
+	}
+}
+
+func TestReplaceResourceUnregistered(t *testing.T) {
+	api := NewAPI()
+	if err := api.ReplaceResource("/missing", Release("v1")); err == nil {
+		t.Error("replacing an unregistered path succeeded")
+	}
+}
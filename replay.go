@@ -0,0 +1,62 @@
+package sleepy
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+)
+
+// replayBody is a request body held in memory, which the API rewinds
+// after parsing the form so the resource can read it again.
+type replayBody struct {
+	*bytes.Reader
+}
+
+func (b replayBody) Close() error {
+	return nil
+}
+
+// rewind moves the body back to its start.
+func (b replayBody) rewind() {
+	b.Seek(0, io.SeekStart)
+}
+
+// BodyReplay returns r marked so that the API keeps its body in memory
+// and rewinds it after parsing the request's form, so a resource can
+// still read the raw body, for instance to log it when it refuses the
+// request. The body is read once it has passed the API's size limit and
+// been decompressed, so a body over SetMaxBodyBytes is refused with a
+// 413 as usual, and one that cannot be read with a 400.
+func BodyReplay(r *http.Request) *http.Request {
+	if r.Body == nil || r.Body == http.NoBody {
+		return r
+	}
+	return r.WithContext(SetContextValue(r.Context(), replayKey, true))
+}
+
+// bufferReplay reads request's body into memory if BodyReplay asked for
+// it, so that it can be rewound after the form is parsed. It returns
+// the error from reading the body.
+func bufferReplay(request *http.Request) error {
+	if replay, _ := GetContextValue(request.Context(), replayKey).(bool); !replay || request.Body == nil {
+		return nil
+	}
+	body, err := io.ReadAll(request.Body)
+	request.Body.Close()
+	if err != nil {
+		return err
+	}
+	request.Body = replayBody{bytes.NewReader(body)}
+	return nil
+}
+
+// WithBodyReplay makes the route call BodyReplay on every request, so
+// that its resource can read the raw body through RequestFromContext
+// even after the form has been parsed.
+func WithBodyReplay() ResourceOption {
+	return WithMiddleware(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(rw http.ResponseWriter, request *http.Request) {
+			next.ServeHTTP(rw, BodyReplay(request))
+		})
+	})
+}
@@ -0,0 +1,81 @@
+package sleepy
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+type Inspector struct{}
+
+func (inspector Inspector) PostContext(ctx context.Context, values url.Values, headers http.Header) (int, interface{}, http.Header) {
+	raw, _ := io.ReadAll(RequestFromContext(ctx).Body)
+	return 400, map[string]string{"name": values.Get("name"), "raw": string(raw)}, nil
+}
+
+func TestBodyReplay(t *testing.T) {
+	api := NewAPI()
+	api.SetJSONOptions("", false)
+	api.AddResourceWithOptions(new(Inspector), "/replayed", WithBodyReplay())
+	api.AddResource(new(Inspector), "/consumed")
+
+	post := func(path string) string {
+		request := httptest.NewRequest("POST", path, strings.NewReader("name=a%20b&size=3"))
+		request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		recorder := httptest.NewRecorder()
+		api.ServeHTTP(recorder, request)
+		return recorder.Body.String()
+	}
+
+	if body := post("/replayed"); body != `{"name":"a b","raw":"name=a%20b&size=3"}` {
+		t.Errorf("with replay: got %s", body)
+	}
+	if body := post("/consumed"); body != `{"name":"a b","raw":""}` {
+		t.Errorf("without replay: got %s", body)
+	}
+}
+
+func TestBodyReplayLimitedAndCompressed(t *testing.T) {
+	api := NewAPI()
+	api.SetJSONOptions("", false)
+	api.SetMaxBodyBytes(64)
+	api.AddResourceWithOptions(new(Inspector), "/replayed", WithBodyReplay())
+
+	post := func(body io.Reader, gzipped bool) *httptest.ResponseRecorder {
+		request := httptest.NewRequest("POST", "/replayed", body)
+		request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		if gzipped {
+			request.Header.Set("Content-Encoding", "gzip")
+		}
+		recorder := httptest.NewRecorder()
+		api.ServeHTTP(recorder, request)
+		return recorder
+	}
+
+	if recorder := post(strings.NewReader("name=a%20b"), false); recorder.Body.String() != `{"name":"a b","raw":"name=a%20b"}` {
+		t.Errorf("limited: got %d %s", recorder.Code, recorder.Body.String())
+	}
+
+	var compressed bytes.Buffer
+	writer := gzip.NewWriter(&compressed)
+	io.WriteString(writer, "name=a%20b")
+	writer.Close()
+	if recorder := post(&compressed, true); recorder.Body.String() != `{"name":"a b","raw":"name=a%20b"}` {
+		t.Errorf("gzipped: got %d %s", recorder.Code, recorder.Body.String())
+	}
+
+	long := &countingReader{Reader: strings.NewReader("name=" + strings.Repeat("a", 1<<20))}
+	request := httptest.NewRequest("POST", "/replayed", long)
+	request.ContentLength = -1
+	recorder := httptest.NewRecorder()
+	api.ServeHTTP(recorder, request)
+	if recorder.Code != http.StatusRequestEntityTooLarge || long.read > 1<<10 {
+		t.Errorf("oversized: got %d after reading %d bytes, want 413 at the limit", recorder.Code, long.read)
+	}
+}
@@ -0,0 +1,171 @@
+package sleepy
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// LoadRoutes reads a routing table from r and adds the resources it
+// names, so that which resource is served where can be changed without
+// recompiling. The table maps each path to the name of a resource in
+// registry, either as a flat YAML mapping,
+//
+//	# Served by the users resources.
+//	/users: users
+//	/users/: user
+//
+// or as a JSON object, such as {"/users": "users"}. Only that much of
+// YAML is understood: one "path: name" pair to a line, either of which
+// may be quoted, with blank lines and # comments. Routes are added in
+// the order they are listed. If the table is malformed, lists a path
+// twice or names a resource missing from registry, LoadRoutes returns an
+// error without adding any of them.
+func (api *API) LoadRoutes(r io.Reader, registry map[string]interface{}) error {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return fmt.Errorf("sleepy: reading routes: %w", err)
+	}
+	var table []routeEntry
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '{' {
+		table, err = parseJSONRoutes(trimmed)
+	} else {
+		table, err = parseYAMLRoutes(string(data))
+	}
+	if err != nil {
+		return err
+	}
+
+	resources := make([]interface{}, len(table))
+	seen := make(map[string]bool)
+	for i, entry := range table {
+		if seen[entry.path] {
+			return fmt.Errorf("sleepy: reading routes: %s is listed twice", entry.path)
+		}
+		seen[entry.path] = true
+		resource, ok := registry[entry.name]
+		if !ok {
+			return fmt.Errorf("sleepy: reading the route for %s: no resource named %q", entry.path, entry.name)
+		}
+		resources[i] = resource
+	}
+
+	for i, entry := range table {
+		api.AddResource(resources[i], entry.path)
+	}
+	return nil
+}
+
+// A routeEntry is a line of a routing table: a path and the name of the
+// resource to serve there.
+type routeEntry struct {
+	path, name string
+}
+
+// parseJSONRoutes parses a routing table written as a JSON object,
+// keeping the order of its members.
+func parseJSONRoutes(data []byte) ([]routeEntry, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	if _, err := decoder.Token(); err != nil {
+		return nil, fmt.Errorf("sleepy: reading routes: %w", err)
+	}
+	var table []routeEntry
+	for decoder.More() {
+		token, err := decoder.Token()
+		if err != nil {
+			return nil, fmt.Errorf("sleepy: reading routes: %w", err)
+		}
+		path := token.(string)
+		var name string
+		if err := decoder.Decode(&name); err != nil {
+			return nil, fmt.Errorf("sleepy: reading the route for %s: %w", path, err)
+		}
+		table = append(table, routeEntry{path, name})
+	}
+	if _, err := decoder.Token(); err != nil {
+		return nil, fmt.Errorf("sleepy: reading routes: %w", err)
+	}
+	return table, nil
+}
+
+// parseYAMLRoutes parses a routing table written as a flat YAML
+// mapping.
+func parseYAMLRoutes(data string) ([]routeEntry, error) {
+	var table []routeEntry
+	for number, line := range strings.Split(data, "\n") {
+		line = strings.TrimRight(line, " \t\r")
+		if trimmed := strings.TrimSpace(line); trimmed == "" || trimmed[0] == '#' || (number == 0 && trimmed == "---") {
+			continue
+		}
+		if line[0] == ' ' || line[0] == '\t' {
+			return nil, fmt.Errorf("sleepy: reading routes: line %d: want a flat mapping of paths to resource names", number+1)
+		}
+		path, rest, err := yamlScalar(line, true)
+		if err != nil {
+			return nil, fmt.Errorf("sleepy: reading routes: line %d: %w", number+1, err)
+		}
+		if !strings.HasPrefix(rest, ":") {
+			return nil, fmt.Errorf("sleepy: reading routes: line %d: want \"path: name\"", number+1)
+		}
+		name, rest, err := yamlScalar(strings.TrimSpace(rest[1:]), false)
+		if err != nil {
+			return nil, fmt.Errorf("sleepy: reading routes: line %d: %w", number+1, err)
+		}
+		if rest = strings.TrimSpace(rest); rest != "" && rest[0] != '#' {
+			return nil, fmt.Errorf("sleepy: reading routes: line %d: unexpected %q", number+1, rest)
+		}
+		if path == "" || name == "" {
+			return nil, fmt.Errorf("sleepy: reading routes: line %d: want \"path: name\"", number+1)
+		}
+		table = append(table, routeEntry{path, name})
+	}
+	return table, nil
+}
+
+// yamlScalar reads a plain, single-quoted or double-quoted scalar from
+// the start of s and returns it with what follows. A plain key ends at
+// ": " or a final colon, and a plain value at " #".
+func yamlScalar(s string, key bool) (string, string, error) {
+	if s == "" {
+		return "", "", nil
+	}
+	switch s[0] {
+	case '"':
+		for i := 1; i < len(s); i++ {
+			switch s[i] {
+			case '\\':
+				i++
+			case '"':
+				value, err := strconv.Unquote(s[:i+1])
+				return value, s[i+1:], err
+			}
+		}
+		return "", "", fmt.Errorf("unterminated string %s", s)
+	case '\'':
+		for i := 1; i < len(s); i++ {
+			if s[i] != '\'' {
+				continue
+			}
+			if i+1 < len(s) && s[i+1] == '\'' {
+				i++
+				continue
+			}
+			return strings.ReplaceAll(s[1:i], "''", "'"), s[i+1:], nil
+		}
+		return "", "", fmt.Errorf("unterminated string %s", s)
+	}
+	end := len(s)
+	if key {
+		if i := strings.Index(s, ": "); i >= 0 {
+			end = i
+		} else if strings.HasSuffix(s, ":") {
+			end = len(s) - 1
+		}
+	} else if i := strings.Index(s, " #"); i >= 0 {
+		end = i
+	}
+	return strings.TrimSpace(s[:end]), s[end:], nil
+}
@@ -0,0 +1,93 @@
+package sleepy
+
+import (
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestLoadRoutes(t *testing.T) {
+	api := NewAPI()
+	registry := map[string]interface{}{
+		"items":   new(Item),
+		"uploads": new(Upload),
+	}
+	table := `{
+		"/items": "items",
+		"/uploads": "uploads",
+		"/things": "items"
+	}`
+	if err := api.LoadRoutes(strings.NewReader(table), registry); err != nil {
+		t.Fatal(err)
+	}
+
+	if want := []string{"/items", "/uploads", "/things"}; !reflect.DeepEqual(routePaths(api), want) {
+		t.Errorf("paths = %v, want %v", routePaths(api), want)
+	}
+	recorder := httptest.NewRecorder()
+	api.ServeHTTP(recorder, httptest.NewRequest("GET", "/things", nil))
+	if recorder.Code != 200 {
+		t.Errorf("GET /things: got %d", recorder.Code)
+	}
+}
+
+func TestLoadRoutesYAML(t *testing.T) {
+	api := NewAPI()
+	registry := map[string]interface{}{
+		"items":   new(Item),
+		"uploads": new(Upload),
+	}
+	table := `---
+# Everything the catalogue serves.
+/items: items
+
+"/uploads": 'uploads'  # quoted both ways
+/things: "items"
+`
+	if err := api.LoadRoutes(strings.NewReader(table), registry); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"/items", "/uploads", "/things"}; !reflect.DeepEqual(routePaths(api), want) {
+		t.Errorf("paths = %v, want %v", routePaths(api), want)
+	}
+	recorder := httptest.NewRecorder()
+	api.ServeHTTP(recorder, httptest.NewRequest("GET", "/things", nil))
+	if recorder.Code != 200 {
+		t.Errorf("GET /things: got %d", recorder.Code)
+	}
+}
+
+// routePaths returns the paths of api's routes, in order.
+func routePaths(api *API) []string {
+	var paths []string
+	for _, route := range api.Routes() {
+		paths = append(paths, route.Path)
+	}
+	return paths
+}
+
+func TestLoadRoutesErrors(t *testing.T) {
+	registry := map[string]interface{}{"items": new(Item)}
+	for _, table := range []string{
+		`["/items"]`,
+		`{"/items": "items", "/other": "missing"}`,
+		`{"/items": "items", "/items": "items"}`,
+		`{"/items": 3}`,
+		`{"/items": "items"`,
+		"/items: items\n/other: missing",
+		"/items: items\n/items: items",
+		"/items:\n  nested: items",
+		"/items items",
+		"/items: \"items",
+		"/items: items extra: words",
+	} {
+		api := NewAPI()
+		if err := api.LoadRoutes(strings.NewReader(table), registry); err == nil {
+			t.Errorf("%s: no error", table)
+		}
+		if routes := api.Routes(); len(routes) != 0 {
+			t.Errorf("%s: added %v", table, routes)
+		}
+	}
+}
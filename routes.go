@@ -0,0 +1,133 @@
+package sleepy
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"sync/atomic"
+	"time"
+)
+
+// methods lists the HTTP methods a resource can support, in the order
+// the API reports them.
+var methods = []string{GET, POST, PUT, DELETE, HEAD, PATCH}
+
+// route records a resource registered with the API.
+type route struct {
+	path     string
+	resource interface{}
+	timeout  time.Duration
+
+	// build makes the handler for a resource at the route, and target
+	// holds the resource being served and its handler, which
+	// ReplaceResource swaps.
+	build  func(interface{}) http.Handler
+	target *atomic.Pointer[routeTarget]
+
+	middleware       []func(http.Handler) http.Handler
+	methodMiddleware map[string][]func(http.Handler) http.Handler
+
+	summary     string
+	description string
+
+	// quiet keeps the route's requests out of the logs.
+	quiet bool
+}
+
+// routeTarget is a resource served at a route, with its handler.
+type routeTarget struct {
+	resource interface{}
+	handler  http.Handler
+}
+
+// current returns the resource the route serves now.
+func (r route) current() interface{} {
+	return r.target.Load().resource
+}
+
+// A Route describes a path registered with an API and the HTTP methods
+// the resource at that path supports, along with any documentation
+// given to RegisterWithDocs.
+type Route struct {
+	Path        string   `json:"path"`
+	Methods     []string `json:"methods"`
+	Summary     string   `json:"summary,omitempty"`
+	Description string   `json:"description,omitempty"`
+}
+
+// Routes returns every path registered with the API, in registration
+// order.
+func (api *API) Routes() []Route {
+	routes := make([]Route, 0, len(api.routes))
+	for _, r := range api.routes {
+		routes = append(routes, Route{
+			Path:        r.path,
+			Methods:     supportedMethods(r.current()),
+			Summary:     r.summary,
+			Description: r.description,
+		})
+	}
+	return routes
+}
+
+// A ResourceInfo describes a resource registered with an API: the path
+// it is served at, the resource itself and the HTTP methods it
+// supports.
+type ResourceInfo struct {
+	Path             string
+	Resource         interface{}
+	SupportedMethods []string
+}
+
+// Resources returns every resource registered with the API, with the
+// path it is served at, in registration order. A resource added at
+// several paths appears once for each. Unlike Routes, it gives the
+// resources themselves, for tools that inspect them further.
+func (api *API) Resources() []ResourceInfo {
+	resources := make([]ResourceInfo, 0, len(api.routes))
+	for _, r := range api.routes {
+		resource := r.current()
+		resources = append(resources, ResourceInfo{
+			Path:             r.path,
+			Resource:         resource,
+			SupportedMethods: supportedMethods(resource),
+		})
+	}
+	return resources
+}
+
+// RegisterWithDocs adds resource at path like AddResource, and records
+// a one-line summary and a longer description of it. The documentation
+// is reported by Routes and by generated API descriptions, so resources
+// need not implement anything extra to describe themselves.
+func (api *API) RegisterWithDocs(resource interface{}, path, summary, description string) {
+	api.mustRegisterRoute(route{
+		path:        path,
+		resource:    resource,
+		summary:     summary,
+		description: description,
+	}, api.resourceHandler)
+}
+
+// supportedMethods returns the HTTP methods resource supports.
+func supportedMethods(resource interface{}) []string {
+	supported := []string{}
+	for _, method := range methods {
+		if methodHandler(resource, method) != nil {
+			supported = append(supported, method)
+		}
+	}
+	return supported
+}
+
+// EnableDebugRoutes registers an endpoint at path that responds to GET
+// with the API's Routes encoded as JSON. The endpoint only exists once
+// this is called, and is then a route like any other, listed in its own
+// output.
+func (api *API) EnableDebugRoutes(path string) {
+	api.register(path, methodSet{
+		GET: func(ctx context.Context, values url.Values, header http.Header) (int, interface{}, http.Header) {
+			return http.StatusOK, api.Routes(), nil
+		},
+	}, api.resourceHandler)
+}
@@ -0,0 +1,84 @@
+package sleepy
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestEnableDebugRoutes(t *testing.T) {
+	api := NewAPI()
+	api.AddResource(new(Item), "/items")
+	api.AddResource(new(Upload), "/uploads")
+	api.AddRPCResource(Shop{}, "/users", "Users")
+	api.EnableDebugRoutes("/debug/routes")
+
+	recorder := httptest.NewRecorder()
+	api.ServeHTTP(recorder, httptest.NewRequest("GET", "/debug/routes", nil))
+
+	var routes []Route
+	if err := json.Unmarshal(recorder.Body.Bytes(), &routes); err != nil {
+		t.Fatal(err)
+	}
+	expected := []Route{
+		{Path: "/items", Methods: []string{GET}},
+		{Path: "/uploads", Methods: []string{POST}},
+		{Path: "/users", Methods: []string{GET, POST}},
+		{Path: "/debug/routes", Methods: []string{GET}},
+	}
+	if !reflect.DeepEqual(routes, expected) {
+		t.Errorf("routes = %v, want %v", routes, expected)
+	}
+}
+
+func TestDebugRoutesDisabled(t *testing.T) {
+	api := NewAPI()
+	api.AddResource(new(Item), "/items")
+
+	recorder := httptest.NewRecorder()
+	api.ServeHTTP(recorder, httptest.NewRequest("GET", "/debug/routes", nil))
+	if recorder.Code != 404 {
+		t.Errorf("got %d, want 404", recorder.Code)
+	}
+}
+
+func TestRegisterWithDocs(t *testing.T) {
+	api := NewAPI()
+	api.AddResource(new(Item), "/items")
+	api.RegisterWithDocs(new(Upload), "/uploads", "Upload a file", "Stores the uploaded file and returns its name.")
+
+	expected := []Route{
+		{Path: "/items", Methods: []string{GET}},
+		{Path: "/uploads", Methods: []string{POST}, Summary: "Upload a file", Description: "Stores the uploaded file and returns its name."},
+	}
+	if routes := api.Routes(); !reflect.DeepEqual(routes, expected) {
+		t.Errorf("routes = %v, want %v", routes, expected)
+	}
+
+	recorder := httptest.NewRecorder()
+	api.ServeHTTP(recorder, httptest.NewRequest("POST", "/uploads?name=a.txt", nil))
+	if recorder.Code != 201 {
+		t.Errorf("got %d, want 201", recorder.Code)
+	}
+}
+
+func TestResources(t *testing.T) {
+	api := NewAPI()
+	item, upload := new(Item), new(Upload)
+	api.AddResource(item, "/items", "/things")
+	api.AddResource(upload, "/uploads")
+
+	expected := []ResourceInfo{
+		{Path: "/items", Resource: item, SupportedMethods: []string{GET}},
+		{Path: "/things", Resource: item, SupportedMethods: []string{GET}},
+		{Path: "/uploads", Resource: upload, SupportedMethods: []string{POST}},
+	}
+	resources := api.Resources()
+	if !reflect.DeepEqual(resources, expected) {
+		t.Errorf("resources = %v, want %v", resources, expected)
+	}
+	if resources[0].Resource != item {
+		t.Error("the resource is not the one registered")
+	}
+}
@@ -0,0 +1,36 @@
+package sleepy
+
+import (
+	"net/http"
+	"net/url"
+	"reflect"
+	"strings"
+)
+
+// AddRPCResource adds the methods of resource named <Method><prefix> at
+// path, as an alternative to implementing the method interfaces. For
+// example, with the prefix "Users" a GET to path calls GetUsers and a
+// POST calls PostUsers, so one type can serve several paths:
+//
+//	api.AddRPCResource(store, "/users", "Users")
+//	api.AddRPCResource(store, "/orders", "Orders")
+//
+// The methods must have the same signature as GetSupported.Get. Methods
+// with a matching name but a different signature are ignored.
+func (api *API) AddRPCResource(resource interface{}, path, prefix string) {
+	value := reflect.ValueOf(resource)
+	set := make(methodSet)
+	for _, method := range methods {
+		name := method[:1] + strings.ToLower(method[1:]) + prefix
+		m := value.MethodByName(name)
+		if !m.IsValid() {
+			continue
+		}
+		fn, ok := m.Interface().(func(url.Values, http.Header) (int, interface{}, http.Header))
+		if !ok {
+			continue
+		}
+		set[method] = withoutContext(fn)
+	}
+	api.AddResource(set, path)
+}
@@ -0,0 +1,50 @@
+package sleepy
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+type Shop struct{}
+
+func (shop Shop) GetUsers(values url.Values, headers http.Header) (int, interface{}, http.Header) {
+	return 200, "users", nil
+}
+
+func (shop Shop) PostUsers(values url.Values, headers http.Header) (int, interface{}, http.Header) {
+	return 201, "new user", nil
+}
+
+func (shop Shop) GetOrders(values url.Values, headers http.Header) (int, interface{}, http.Header) {
+	return 200, "orders", nil
+}
+
+// DeleteOrders has the wrong signature and must not be dispatched.
+func (shop Shop) DeleteOrders() {}
+
+func TestAddRPCResource(t *testing.T) {
+	api := NewAPI()
+	api.AddRPCResource(Shop{}, "/users", "Users")
+	api.AddRPCResource(Shop{}, "/orders", "Orders")
+
+	cases := []struct {
+		method, path string
+		code         int
+		body         string
+	}{
+		{"GET", "/users", 200, `"users"`},
+		{"POST", "/users", 201, `"new user"`},
+		{"GET", "/orders", 200, `"orders"`},
+		{"POST", "/orders", 405, ""},
+		{"DELETE", "/orders", 405, ""},
+	}
+	for _, c := range cases {
+		recorder := httptest.NewRecorder()
+		api.ServeHTTP(recorder, httptest.NewRequest(c.method, c.path, nil))
+		if recorder.Code != c.code || recorder.Body.String() != c.body {
+			t.Errorf("%s %s: got %d %q, want %d %q", c.method, c.path, recorder.Code, recorder.Body.String(), c.code, c.body)
+		}
+	}
+}
@@ -0,0 +1,540 @@
+package sleepy
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"mime"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// A jsonSchema is a compiled JSON Schema. Only this subset of the
+// validation keywords is supported, each of which must have a value of
+// the listed kind:
+//
+//   - type: a type name, or an array of them, from null, boolean,
+//     object, array, number, integer and string
+//   - enum: an array; const: any value
+//   - properties: an object of schemas; additionalProperties: a boolean
+//     or a schema; required: an array of strings
+//   - items: a single schema applied to every element; tuple arrays
+//     are not supported
+//   - minItems, maxItems, minLength and maxLength: non-negative integers
+//   - minimum, maximum, exclusiveMinimum and exclusiveMaximum: numbers,
+//     exclusiveMinimum and exclusiveMaximum in their draft 6 form
+//   - pattern: a string holding a Go regular expression
+//
+// Annotations such as title and description are allowed. Any other
+// keyword, $ref, allOf or format say, and any keyword with a value of
+// the wrong kind, is an error when the schema is compiled rather than
+// a check silently skipped.
+type jsonSchema struct {
+	types                []string
+	enum                 []interface{}
+	constant             interface{}
+	hasConst             bool
+	properties           map[string]*jsonSchema
+	required             []string
+	additionalProperties *jsonSchema
+	noAdditional         bool
+	items                *jsonSchema
+	minItems, maxItems   *int
+	minimum, maximum     *float64
+	exclusiveMin         *float64
+	exclusiveMax         *float64
+	minLength, maxLength *int
+	pattern              *regexp.Regexp
+}
+
+// schemaKeywords are the keywords compileSchema understands: true for
+// those it validates, false for annotations that do not affect
+// validation.
+var schemaKeywords = map[string]bool{
+	"type": true, "enum": true, "const": true,
+	"properties": true, "required": true, "additionalProperties": true,
+	"items": true, "minItems": true, "maxItems": true,
+	"minimum": true, "maximum": true, "exclusiveMinimum": true, "exclusiveMaximum": true,
+	"minLength": true, "maxLength": true, "pattern": true,
+
+	"$schema": false, "$id": false, "$comment": false,
+	"title": false, "description": false, "default": false, "examples": false,
+	"readOnly": false, "writeOnly": false, "deprecated": false,
+}
+
+// schemaTypes are the values the type keyword may name.
+var schemaTypes = map[string]bool{
+	"null": true, "boolean": true, "object": true, "array": true,
+	"number": true, "integer": true, "string": true,
+}
+
+// compileSchema parses a JSON Schema document. It returns an error if
+// the schema uses a keyword outside schemaKeywords, or gives a keyword a
+// value it cannot check.
+func compileSchema(document []byte) (*jsonSchema, error) {
+	var raw interface{}
+	if err := json.Unmarshal(document, &raw); err != nil {
+		return nil, fmt.Errorf("sleepy: invalid JSON schema: %w", err)
+	}
+	return compileSchemaValue(raw, "#")
+}
+
+func compileSchemaValue(raw interface{}, location string) (*jsonSchema, error) {
+	if accept, ok := raw.(bool); ok {
+		// true accepts everything; false accepts nothing.
+		if accept {
+			return &jsonSchema{}, nil
+		}
+		return &jsonSchema{enum: []interface{}{}}, nil
+	}
+	object, ok := raw.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("sleepy: schema at %s is not an object", location)
+	}
+
+	keywords := make([]string, 0, len(object))
+	for keyword := range object {
+		keywords = append(keywords, keyword)
+	}
+	sort.Strings(keywords)
+	for _, keyword := range keywords {
+		if _, ok := schemaKeywords[keyword]; !ok {
+			return nil, fmt.Errorf("sleepy: schema at %s uses the unsupported keyword %q", location, keyword)
+		}
+	}
+
+	mistyped := func(keyword, want string) error {
+		return fmt.Errorf("sleepy: schema at %s has a %s that is not %s", location, keyword, want)
+	}
+
+	s := &jsonSchema{}
+	if t, ok := object["type"]; ok {
+		var names []interface{}
+		switch t := t.(type) {
+		case string:
+			names = []interface{}{t}
+		case []interface{}:
+			names = t
+		default:
+			return nil, mistyped("type", "a string or an array of strings")
+		}
+		for _, name := range names {
+			name, ok := name.(string)
+			if !ok || !schemaTypes[name] {
+				return nil, fmt.Errorf("sleepy: schema at %s has the unknown type %v", location, name)
+			}
+			s.types = append(s.types, name)
+		}
+	}
+	if enum, ok := object["enum"]; ok {
+		if s.enum, ok = enum.([]interface{}); !ok {
+			return nil, mistyped("enum", "an array")
+		}
+	}
+	if constant, ok := object["const"]; ok {
+		s.constant, s.hasConst = constant, true
+	}
+	if properties, ok := object["properties"]; ok {
+		properties, ok := properties.(map[string]interface{})
+		if !ok {
+			return nil, mistyped("properties", "an object")
+		}
+		s.properties = make(map[string]*jsonSchema, len(properties))
+		for name, property := range properties {
+			compiled, err := compileSchemaValue(property, location+"/properties/"+name)
+			if err != nil {
+				return nil, err
+			}
+			s.properties[name] = compiled
+		}
+	}
+	if required, ok := object["required"]; ok {
+		required, ok := required.([]interface{})
+		if !ok {
+			return nil, mistyped("required", "an array of strings")
+		}
+		for _, name := range required {
+			name, ok := name.(string)
+			if !ok {
+				return nil, mistyped("required", "an array of strings")
+			}
+			s.required = append(s.required, name)
+		}
+	}
+	if additional, ok := object["additionalProperties"]; ok {
+		switch additional := additional.(type) {
+		case bool:
+			s.noAdditional = !additional
+		case map[string]interface{}:
+			compiled, err := compileSchemaValue(additional, location+"/additionalProperties")
+			if err != nil {
+				return nil, err
+			}
+			s.additionalProperties = compiled
+		default:
+			return nil, mistyped("additionalProperties", "a boolean or a schema")
+		}
+	}
+	if items, ok := object["items"]; ok {
+		compiled, err := compileSchemaValue(items, location+"/items")
+		if err != nil {
+			return nil, err
+		}
+		s.items = compiled
+	}
+	if pattern, ok := object["pattern"]; ok {
+		pattern, ok := pattern.(string)
+		if !ok {
+			return nil, mistyped("pattern", "a string")
+		}
+		compiled, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("sleepy: schema at %s has an invalid pattern: %w", location, err)
+		}
+		s.pattern = compiled
+	}
+	for _, count := range []struct {
+		keyword string
+		target  **int
+	}{
+		{"minItems", &s.minItems}, {"maxItems", &s.maxItems},
+		{"minLength", &s.minLength}, {"maxLength", &s.maxLength},
+	} {
+		keyword, target := count.keyword, count.target
+		if value, ok := object[keyword]; ok {
+			n, ok := value.(float64)
+			if !ok || n < 0 || n != math.Trunc(n) || n > math.MaxInt32 {
+				return nil, mistyped(keyword, "a non-negative integer")
+			}
+			count := int(n)
+			*target = &count
+		}
+	}
+	for _, bound := range []struct {
+		keyword string
+		target  **float64
+	}{
+		{"minimum", &s.minimum}, {"maximum", &s.maximum},
+		{"exclusiveMinimum", &s.exclusiveMin}, {"exclusiveMaximum", &s.exclusiveMax},
+	} {
+		keyword, target := bound.keyword, bound.target
+		if value, ok := object[keyword]; ok {
+			n, ok := value.(float64)
+			if !ok {
+				return nil, mistyped(keyword, "a number")
+			}
+			*target = &n
+		}
+	}
+	return s, nil
+}
+
+// validateJSON decodes document and validates it against the schema,
+// returning one message per violation.
+func (s *jsonSchema) validateJSON(document []byte) []string {
+	var value interface{}
+	if err := json.Unmarshal(document, &value); err != nil {
+		return []string{"body is not valid JSON: " + err.Error()}
+	}
+	return s.validate(value, "")
+}
+
+// validate returns a message for every way value violates the schema.
+// path is the JSON Pointer to value within the document.
+func (s *jsonSchema) validate(value interface{}, path string) []string {
+	var errs []string
+	fail := func(format string, args ...interface{}) {
+		location := path
+		if location == "" {
+			location = "/"
+		}
+		errs = append(errs, location+": "+fmt.Sprintf(format, args...))
+	}
+
+	if len(s.types) > 0 && !matchesAnyType(value, s.types) {
+		fail("expected %s, got %s", strings.Join(s.types, " or "), jsonTypeOf(value))
+		return errs
+	}
+	if s.enum != nil && !containsJSON(s.enum, value) {
+		fail("value is not one of the allowed values")
+	}
+	if s.hasConst && !equalJSON(s.constant, value) {
+		fail("value does not match the required constant")
+	}
+
+	switch value := value.(type) {
+	case map[string]interface{}:
+		for _, name := range s.required {
+			if _, ok := value[name]; !ok {
+				fail("missing required property %q", name)
+			}
+		}
+		names := make([]string, 0, len(value))
+		for name := range value {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			childPath := path + "/" + strings.ReplaceAll(strings.ReplaceAll(name, "~", "~0"), "/", "~1")
+			if property, ok := s.properties[name]; ok {
+				errs = append(errs, property.validate(value[name], childPath)...)
+			} else if s.additionalProperties != nil {
+				errs = append(errs, s.additionalProperties.validate(value[name], childPath)...)
+			} else if s.noAdditional {
+				fail("unexpected property %q", name)
+			}
+		}
+	case []interface{}:
+		if s.minItems != nil && len(value) < *s.minItems {
+			fail("expected at least %d items, got %d", *s.minItems, len(value))
+		}
+		if s.maxItems != nil && len(value) > *s.maxItems {
+			fail("expected at most %d items, got %d", *s.maxItems, len(value))
+		}
+		if s.items != nil {
+			for i, item := range value {
+				errs = append(errs, s.items.validate(item, path+"/"+strconv.Itoa(i))...)
+			}
+		}
+	case string:
+		length := utf8.RuneCountInString(value)
+		if s.minLength != nil && length < *s.minLength {
+			fail("expected at least %d characters, got %d", *s.minLength, length)
+		}
+		if s.maxLength != nil && length > *s.maxLength {
+			fail("expected at most %d characters, got %d", *s.maxLength, length)
+		}
+		if s.pattern != nil && !s.pattern.MatchString(value) {
+			fail("value does not match pattern %q", s.pattern.String())
+		}
+	case float64:
+		if s.minimum != nil && value < *s.minimum {
+			fail("value %v is less than the minimum %v", value, *s.minimum)
+		}
+		if s.maximum != nil && value > *s.maximum {
+			fail("value %v is greater than the maximum %v", value, *s.maximum)
+		}
+		if s.exclusiveMin != nil && value <= *s.exclusiveMin {
+			fail("value %v must be greater than %v", value, *s.exclusiveMin)
+		}
+		if s.exclusiveMax != nil && value >= *s.exclusiveMax {
+			fail("value %v must be less than %v", value, *s.exclusiveMax)
+		}
+	}
+	return errs
+}
+
+func matchesAnyType(value interface{}, types []string) bool {
+	actual := jsonTypeOf(value)
+	for _, t := range types {
+		if t == actual || (t == "number" && actual == "integer") {
+			return true
+		}
+	}
+	return false
+}
+
+// jsonTypeOf names the JSON Schema type of a decoded JSON value.
+func jsonTypeOf(value interface{}) string {
+	switch value := value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case string:
+		return "string"
+	case float64:
+		if value == math.Trunc(value) {
+			return "integer"
+		}
+		return "number"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	}
+	return "unknown"
+}
+
+func containsJSON(values []interface{}, value interface{}) bool {
+	for _, candidate := range values {
+		if equalJSON(candidate, value) {
+			return true
+		}
+	}
+	return false
+}
+
+func equalJSON(a, b interface{}) bool {
+	ea, errA := json.Marshal(a)
+	eb, errB := json.Marshal(b)
+	return errA == nil && errB == nil && bytes.Equal(ea, eb)
+}
+
+// schemaError is the body of a response rejected by schema validation.
+type schemaError struct {
+	Error   string   `json:"error"`
+	Details []string `json:"details"`
+}
+
+// writeSchemaError responds with code and a JSON body listing errs.
+func writeSchemaError(rw http.ResponseWriter, code int, message string, errs []string) {
+	body, _ := json.Marshal(schemaError{Error: message, Details: errs})
+	rw.Header().Set("Content-Type", jsonContentType)
+	rw.WriteHeader(code)
+	rw.Write(body)
+}
+
+// JSONSchemaValidationMiddleware returns middleware that validates
+// request and response bodies against JSON Schema documents.
+//
+// A request whose JSON body does not conform to requestSchema is
+// rejected with a 400 Bad Request listing the violations, before the
+// handler runs. Used with API.Use, the body is checked once it reaches
+// its resource, after the API's size limit and decompression, so an
+// oversized body gets a 413 and a gzipped one is checked decompressed.
+// A body whose Content-Type is set to something other than JSON is not
+// checked. A
+// successful (2xx) response whose body does not conform to
+// responseSchema is replaced by a 500 Internal Server Error listing
+// them. Either schema may be nil to skip that check.
+//
+// Schemas may use the common validation keywords: type, enum, const,
+// properties, required, additionalProperties, items, minItems,
+// maxItems, minimum, maximum, exclusiveMinimum, exclusiveMaximum,
+// minLength, maxLength and pattern, along with annotations such as
+// title and description. JSONSchemaValidationMiddleware panics if
+// either schema cannot be compiled, which includes using any other
+// keyword.
+func JSONSchemaValidationMiddleware(requestSchema, responseSchema []byte) func(http.Handler) http.Handler {
+	mustCompile := func(document []byte) *jsonSchema {
+		if document == nil {
+			return nil
+		}
+		s, err := compileSchema(document)
+		if err != nil {
+			panic(err.Error())
+		}
+		return s
+	}
+	requestValidator := mustCompile(requestSchema)
+	responseValidator := mustCompile(responseSchema)
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(rw http.ResponseWriter, request *http.Request) {
+			if requestValidator != nil {
+				// Under an API, the body is checked once it has been
+				// limited and decompressed; elsewhere it is checked here.
+				if GetContextValue(request.Context(), apiKey) != nil {
+					request = withSchemaCheck(request, requestValidator, http.StatusBadRequest)
+				} else if !requestValidator.checkRequest(rw, request, http.StatusBadRequest) {
+					return
+				}
+			}
+
+			if responseValidator == nil {
+				next.ServeHTTP(rw, request)
+				return
+			}
+
+			buffered := newBufferedResponse()
+			next.ServeHTTP(buffered, request)
+			if buffered.status >= 200 && buffered.status < 300 {
+				if errs := responseValidator.validateJSON(buffered.body.Bytes()); len(errs) > 0 {
+					writeSchemaError(rw, http.StatusInternalServerError, "response body does not match schema", errs)
+					return
+				}
+			}
+			buffered.copyTo(rw)
+		})
+	}
+}
+
+// A schemaCheck is a schema a request's body must conform to, and the
+// status to reject it with if it does not.
+type schemaCheck struct {
+	schema *jsonSchema
+	code   int
+}
+
+// withSchemaCheck returns request with s added to the schemas its body
+// is checked against once it reaches its resource.
+func withSchemaCheck(request *http.Request, s *jsonSchema, code int) *http.Request {
+	checks, _ := GetContextValue(request.Context(), schemaKey).([]schemaCheck)
+	checks = append(checks[:len(checks):len(checks)], schemaCheck{s, code})
+	return request.WithContext(SetContextValue(request.Context(), schemaKey, checks))
+}
+
+// checkSchemas validates request's body against the schemas added with
+// withSchemaCheck. If it does not conform, or cannot be read, it
+// answers the request and returns false.
+func checkSchemas(rw http.ResponseWriter, request *http.Request) bool {
+	checks, _ := GetContextValue(request.Context(), schemaKey).([]schemaCheck)
+	for _, check := range checks {
+		if !check.schema.checkRequest(rw, request, check.code) {
+			return false
+		}
+	}
+	return true
+}
+
+// checkRequest validates request's body against s, leaving the body in
+// place for the handler. A body whose Content-Type is set to something
+// other than JSON is left alone. If the body does not conform, it
+// answers with code listing the violations and returns false.
+func (s *jsonSchema) checkRequest(rw http.ResponseWriter, request *http.Request, code int) bool {
+	if contentType := request.Header.Get("Content-Type"); contentType != "" {
+		mediaType, _, _ := mime.ParseMediaType(contentType)
+		if mediaType != jsonContentType && !strings.HasSuffix(mediaType, "+json") {
+			return true
+		}
+	}
+	var body []byte
+	if request.Body != nil {
+		var err error
+		body, err = io.ReadAll(request.Body)
+		request.Body.Close()
+		if err != nil {
+			rw.WriteHeader(bodyErrorStatus(err))
+			return false
+		}
+	}
+	if errs := s.validateJSON(body); len(errs) > 0 {
+		writeSchemaError(rw, code, "request body does not match schema", errs)
+		return false
+	}
+	request.Body = io.NopCloser(bytes.NewReader(body))
+	return true
+}
+
+// AddResourceWithSchema behaves like AddResource for a single path, but
+// the JSON body of every POST, PUT or PATCH request to resource is first
+// validated against the JSON Schema document schema. A body that does
+// not conform is rejected with a 422 Unprocessable Entity listing the
+// violations, and resource never sees it. As with
+// JSONSchemaValidationMiddleware, the body is checked after the API's
+// size limit and decompression, and bodies sent as anything but JSON,
+// such as form posts, are not checked. The supported keywords are those
+// of JSONSchemaValidationMiddleware. AddResourceWithSchema panics if
+// schema cannot be compiled.
+func (api *API) AddResourceWithSchema(resource interface{}, path string, schema []byte) {
+	validator, err := compileSchema(schema)
+	if err != nil {
+		panic(err.Error())
+	}
+	api.AddResourceWithOptions(resource, path, WithMiddleware(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(rw http.ResponseWriter, request *http.Request) {
+			switch request.Method {
+			case POST, PUT, PATCH:
+				request = withSchemaCheck(request, validator, http.StatusUnprocessableEntity)
+			}
+			next.ServeHTTP(rw, request)
+		})
+	}))
+}
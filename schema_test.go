@@ -0,0 +1,202 @@
+package sleepy
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const userSchema = `{
+	"type": "object",
+	"required": ["name", "age"],
+	"additionalProperties": false,
+	"properties": {
+		"name": {"type": "string", "minLength": 1},
+		"age": {"type": "integer", "minimum": 0},
+		"email": {"type": "string", "pattern": "@"},
+		"tags": {"type": "array", "items": {"enum": ["admin", "staff"]}, "maxItems": 2}
+	}
+}`
+
+func TestSchemaValidate(t *testing.T) {
+	s, err := compileSchema([]byte(userSchema))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		document string
+		errs     []string
+	}{
+		{`{"name":"doug","age":30,"tags":["admin"]}`, nil},
+		{`{"age":30}`, []string{`/: missing required property "name"`}},
+		{`{"name":"","age":1.5}`, []string{"/age: expected integer, got number", "/name: expected at least 1 characters, got 0"}},
+		{`{"name":"doug","age":-1,"email":"nope"}`, []string{`/age: value -1 is less than the minimum 0`, `/email: value does not match pattern "@"`}},
+		{`{"name":"doug","age":3,"tags":["a","b","c"]}`, []string{"/tags: expected at most 2 items, got 3", "/tags/0: value is not one of the allowed values", "/tags/1: value is not one of the allowed values", "/tags/2: value is not one of the allowed values"}},
+		{`{"name":"doug","age":3,"role":"x"}`, []string{`/: unexpected property "role"`}},
+		{`[]`, []string{"/: expected object, got array"}},
+		{`{`, []string{"body is not valid JSON: unexpected end of JSON input"}},
+	}
+	for _, c := range cases {
+		if errs := s.validateJSON([]byte(c.document)); !reflect.DeepEqual(errs, c.errs) {
+			t.Errorf("%s: got %q, want %q", c.document, errs, c.errs)
+		}
+	}
+}
+
+func TestSchemaUnsupportedKeyword(t *testing.T) {
+	if _, err := compileSchema([]byte(`{"title": "User", "description": "A user.", "type": "object"}`)); err != nil {
+		t.Errorf("annotations: %v", err)
+	}
+	for _, document := range []string{
+		`{"$ref": "#/definitions/user"}`,
+		`{"allOf": [{"type": "string"}]}`,
+		`{"type": "object", "properties": {"email": {"type": "string", "format": "email"}}}`,
+		`{"type": "array", "items": {"oneOf": [{"type": "string"}]}}`,
+	} {
+		if _, err := compileSchema([]byte(document)); err == nil || !strings.Contains(err.Error(), "unsupported keyword") {
+			t.Errorf("%s: got %v, want an unsupported keyword error", document, err)
+		}
+	}
+}
+
+func TestSchemaMistypedKeyword(t *testing.T) {
+	for _, document := range []string{
+		`{"type": "text"}`,
+		`{"type": ["string", 1]}`,
+		`{"enum": "admin"}`,
+		`{"required": "name"}`,
+		`{"required": ["name", 1]}`,
+		`{"properties": []}`,
+		`{"additionalProperties": "no"}`,
+		`{"pattern": 1}`,
+		`{"minLength": 1.5}`,
+		`{"maxItems": -1}`,
+		`{"maxLength": "10"}`,
+		`{"minimum": "0"}`,
+		`{"exclusiveMaximum": true}`,
+		`{"items": [{"type": "string"}]}`,
+	} {
+		if _, err := compileSchema([]byte(document)); err == nil {
+			t.Errorf("%s: compiled, want an error", document)
+		}
+	}
+}
+
+func TestJSONSchemaValidationMiddleware(t *testing.T) {
+	var response string
+	handler := JSONSchemaValidationMiddleware([]byte(userSchema), []byte(`{"type":"object","required":["id"]}`))(
+		http.HandlerFunc(func(rw http.ResponseWriter, request *http.Request) {
+			rw.WriteHeader(201)
+			rw.Write([]byte(response))
+		}))
+
+	post := func(body string) *httptest.ResponseRecorder {
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, httptest.NewRequest("POST", "/users", strings.NewReader(body)))
+		return recorder
+	}
+
+	response = `{"id":1}`
+	if recorder := post(`{"name":"doug","age":30}`); recorder.Code != 201 || recorder.Body.String() != response {
+		t.Errorf("valid: got %d %q", recorder.Code, recorder.Body.String())
+	}
+
+	recorder := post(`{"name":"doug"}`)
+	var rejected schemaError
+	json.Unmarshal(recorder.Body.Bytes(), &rejected)
+	if recorder.Code != 400 || len(rejected.Details) != 1 || rejected.Details[0] != `/: missing required property "age"` {
+		t.Errorf("invalid request: got %d %q", recorder.Code, recorder.Body.String())
+	}
+
+	response = `{"name":"doug"}`
+	if recorder := post(`{"name":"doug","age":30}`); recorder.Code != 500 || !strings.Contains(recorder.Body.String(), `missing required property \"id\"`) {
+		t.Errorf("invalid response: got %d %q", recorder.Code, recorder.Body.String())
+	}
+}
+
+func TestAddResourceWithSchema(t *testing.T) {
+	order := new(Order)
+	api := NewAPI()
+	api.AddResourceWithSchema(order, "/orders", []byte(`{"type":"object","required":["name"]}`))
+
+	post := func(body string) *httptest.ResponseRecorder {
+		request := httptest.NewRequest("POST", "/orders", strings.NewReader(body))
+		request.Header.Set("Content-Type", "application/json")
+		recorder := httptest.NewRecorder()
+		api.ServeHTTP(recorder, request)
+		return recorder
+	}
+
+	recorder := post(`{"id":7}`)
+	var rejected schemaError
+	json.Unmarshal(recorder.Body.Bytes(), &rejected)
+	if recorder.Code != 422 || !reflect.DeepEqual(rejected.Details, []string{`/: missing required property "name"`}) {
+		t.Errorf("missing name: got %d %q", recorder.Code, recorder.Body.String())
+	}
+	if order.decoded != nil {
+		t.Errorf("resource saw the rejected body: %v", order.decoded)
+	}
+
+	if recorder := post(`{"name":"widget"}`); recorder.Code != 200 || order.decoded["name"] != "widget" {
+		t.Errorf("valid: got %d with %v", recorder.Code, order.decoded)
+	}
+}
+
+func TestAddResourceWithSchemaBody(t *testing.T) {
+	api := NewAPI()
+	api.SetMaxBodyBytes(1024)
+	api.AddResourceWithSchema(new(Order), "/orders", []byte(`{"type":"object","required":["name"]}`))
+	api.AddResourceWithSchema(new(Upload), "/uploads", []byte(`{"type":"object","required":["name"]}`))
+
+	post := func(path, contentType string, body io.Reader, gzipped bool) int {
+		request := httptest.NewRequest("POST", path, body)
+		request.Header.Set("Content-Type", contentType)
+		if gzipped {
+			request.Header.Set("Content-Encoding", "gzip")
+		}
+		request.ContentLength = -1
+		recorder := httptest.NewRecorder()
+		api.ServeHTTP(recorder, request)
+		return recorder.Code
+	}
+
+	var compressed bytes.Buffer
+	writer := gzip.NewWriter(&compressed)
+	io.WriteString(writer, `{"name":"widget"}`)
+	writer.Close()
+	if code := post("/orders", "application/json", &compressed, true); code != 200 {
+		t.Errorf("gzipped valid body: got %d, want 200", code)
+	}
+	if code := post("/orders", "application/json", strings.NewReader(`{"name":"`+strings.Repeat("a", 2048)+`"}`), false); code != http.StatusRequestEntityTooLarge {
+		t.Errorf("oversized body: got %d, want 413", code)
+	}
+	if code := post("/uploads", "application/x-www-form-urlencoded", strings.NewReader("name=a.txt"), false); code != 201 {
+		t.Errorf("form post: got %d, want 201", code)
+	}
+}
+
+func TestJSONSchemaValidationMiddlewareUnderAPI(t *testing.T) {
+	api := NewAPI()
+	api.Use(JSONSchemaValidationMiddleware([]byte(`{"type":"object","required":["name"]}`), nil))
+	api.AddResource(new(Order), "/orders")
+
+	var compressed bytes.Buffer
+	writer := gzip.NewWriter(&compressed)
+	io.WriteString(writer, `{"name":"widget"}`)
+	writer.Close()
+	request := httptest.NewRequest("POST", "/orders", &compressed)
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("Content-Encoding", "gzip")
+	recorder := httptest.NewRecorder()
+	api.ServeHTTP(recorder, request)
+	if recorder.Code != 200 {
+		t.Errorf("gzipped valid body: got %d %s, want 200", recorder.Code, recorder.Body.String())
+	}
+}
@@ -0,0 +1,164 @@
+package sleepy
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// ErrInvalidCookie is returned by SecureCookieStore.Decode for a cookie
+// that was not encoded by the store under any of its secrets, or that
+// has been tampered with since.
+var ErrInvalidCookie = errors.New("sleepy: cookie is invalid or has been tampered with")
+
+// maxCookieSize is the size of the largest cookie browsers are required
+// to store.
+const maxCookieSize = 4096
+
+// A SecureCookieStore encodes values into cookies that clients can't
+// alter without it being noticed, for sessions, CSRF state and the
+// like. Each cookie holds the value as JSON, signed with HMAC-SHA256
+// along with the cookie's name, so that a value can't be moved from one
+// cookie to another. With encryption enabled the JSON is also sealed
+// with AES-GCM, so that clients can't read it either.
+//
+// The keys for signing and encryption are derived from secrets. The
+// first secret encodes new cookies and every one is tried in turn when
+// decoding, so that a secret can be rotated by putting its replacement
+// first and dropping it once the cookies it encoded have expired.
+type SecureCookieStore struct {
+	keys    []cookieKeys
+	encrypt bool
+}
+
+// cookieKeys are the keys derived from one secret.
+type cookieKeys struct {
+	sign []byte
+	aead cipher.AEAD
+}
+
+// NewSecureCookieStore returns a SecureCookieStore that signs cookies
+// with keys derived from the first of secrets and accepts those signed
+// under any of them. It panics if no secret is given.
+func NewSecureCookieStore(secrets ...[]byte) *SecureCookieStore {
+	if len(secrets) == 0 {
+		panic("sleepy: NewSecureCookieStore needs at least one secret")
+	}
+	store := new(SecureCookieStore)
+	for _, secret := range secrets {
+		block, _ := aes.NewCipher(deriveKey(secret, "encryption"))
+		aead, _ := cipher.NewGCM(block)
+		store.keys = append(store.keys, cookieKeys{sign: deriveKey(secret, "signing"), aead: aead})
+	}
+	return store
+}
+
+// deriveKey returns the 256-bit key for purpose derived from secret, so
+// that signing and encryption never share a key.
+func deriveKey(secret []byte, purpose string) []byte {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte("sleepy secure cookie " + purpose))
+	return mac.Sum(nil)
+}
+
+// EnableEncryption makes the store encrypt the values of the cookies it
+// encodes as well as signing them. Decode accepts cookies encoded
+// either way.
+func (store *SecureCookieStore) EnableEncryption() {
+	store.encrypt = true
+}
+
+// Encode returns a cookie named name holding value, encoded as JSON.
+// The cookie is an HttpOnly session cookie for the path "/" with
+// SameSite=Lax; set its other attributes, such as Secure and MaxAge,
+// before passing it to http.SetCookie. It returns an error if value
+// can't be encoded or the cookie would be too large for browsers to
+// keep.
+func (store *SecureCookieStore) Encode(name string, value interface{}) (*http.Cookie, error) {
+	payload, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	keys := store.keys[0]
+	flag := "s"
+	if store.encrypt {
+		nonce := make([]byte, keys.aead.NonceSize())
+		if _, err := rand.Read(nonce); err != nil {
+			return nil, err
+		}
+		payload = keys.aead.Seal(nonce, nonce, payload, []byte(name))
+		flag = "e"
+	}
+
+	signed := flag + "." + base64.RawURLEncoding.EncodeToString(payload)
+	encoded := signed + "." + base64.RawURLEncoding.EncodeToString(signCookie(keys.sign, name, signed))
+	if len(name)+len(encoded) > maxCookieSize {
+		return nil, fmt.Errorf("sleepy: cookie %s is %d bytes, over the limit of %d", name, len(name)+len(encoded), maxCookieSize)
+	}
+	return &http.Cookie{
+		Name:     name,
+		Value:    encoded,
+		Path:     "/",
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	}, nil
+}
+
+// Decode stores the value held by cookie, which must have been made by
+// Encode, in the value pointed to by dst, as json.Unmarshal does. It
+// returns ErrInvalidCookie if the cookie was not encoded under any of
+// the store's secrets or has been altered.
+func (store *SecureCookieStore) Decode(cookie *http.Cookie, dst interface{}) error {
+	cut := strings.LastIndexByte(cookie.Value, '.')
+	if cut < 0 {
+		return ErrInvalidCookie
+	}
+	signed := cookie.Value[:cut]
+	signature, err := base64.RawURLEncoding.DecodeString(cookie.Value[cut+1:])
+	if err != nil {
+		return ErrInvalidCookie
+	}
+	flag, encodedPayload, ok := strings.Cut(signed, ".")
+	if !ok || (flag != "s" && flag != "e") {
+		return ErrInvalidCookie
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(encodedPayload)
+	if err != nil {
+		return ErrInvalidCookie
+	}
+
+	for _, keys := range store.keys {
+		if !hmac.Equal(signature, signCookie(keys.sign, cookie.Name, signed)) {
+			continue
+		}
+		if flag == "e" {
+			size := keys.aead.NonceSize()
+			if len(payload) < size {
+				return ErrInvalidCookie
+			}
+			if payload, err = keys.aead.Open(nil, payload[:size], payload[size:], []byte(cookie.Name)); err != nil {
+				return ErrInvalidCookie
+			}
+		}
+		return json.Unmarshal(payload, dst)
+	}
+	return ErrInvalidCookie
+}
+
+// signCookie returns the signature of the value signed for the cookie
+// named name under key.
+func signCookie(key []byte, name, signed string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(name))
+	mac.Write([]byte{0})
+	mac.Write([]byte(signed))
+	return mac.Sum(nil)
+}
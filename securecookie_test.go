@@ -0,0 +1,76 @@
+package sleepy
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+type session struct {
+	User  string `json:"user"`
+	Admin bool   `json:"admin"`
+}
+
+func TestSecureCookieStore(t *testing.T) {
+	for _, encrypt := range []bool{false, true} {
+		store := NewSecureCookieStore([]byte("secret"))
+		if encrypt {
+			store.EnableEncryption()
+		}
+		cookie, err := store.Encode("session", session{User: "doug"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if cookie.Name != "session" || !cookie.HttpOnly {
+			t.Errorf("encrypt=%t: got cookie %v", encrypt, cookie)
+		}
+		if readable := strings.Contains(cookie.Value, "ZG91Zy"); readable == encrypt {
+			t.Errorf("encrypt=%t: value %q readable = %t", encrypt, cookie.Value, readable)
+		}
+
+		var got session
+		if err := store.Decode(cookie, &got); err != nil || got.User != "doug" {
+			t.Errorf("encrypt=%t: decoded %+v, %v", encrypt, got, err)
+		}
+
+		tampered := []*http.Cookie{
+			{Name: "session", Value: cookie.Value[:len(cookie.Value)-2] + "AA"},
+			{Name: "session", Value: strings.Replace(cookie.Value, ".", ".A", 1)},
+			{Name: "other", Value: cookie.Value},
+			{Name: "session", Value: "garbage"},
+		}
+		for _, c := range tampered {
+			if err := store.Decode(c, &got); !errors.Is(err, ErrInvalidCookie) {
+				t.Errorf("encrypt=%t: decoding %v: got %v, want ErrInvalidCookie", encrypt, c, err)
+			}
+		}
+	}
+}
+
+func TestSecureCookieRotation(t *testing.T) {
+	old := NewSecureCookieStore([]byte("old"))
+	old.EnableEncryption()
+	cookie, _ := old.Encode("session", session{User: "doug", Admin: true})
+
+	rotated := NewSecureCookieStore([]byte("new"), []byte("old"))
+	var got session
+	if err := rotated.Decode(cookie, &got); err != nil || !got.Admin {
+		t.Fatalf("rotated store decoded %+v, %v", got, err)
+	}
+
+	fresh, _ := rotated.Encode("session", got)
+	if err := old.Decode(fresh, &got); !errors.Is(err, ErrInvalidCookie) {
+		t.Errorf("a cookie from the new secret decoded under the old one: %v", err)
+	}
+	if err := NewSecureCookieStore([]byte("new")).Decode(cookie, &got); !errors.Is(err, ErrInvalidCookie) {
+		t.Errorf("a retired secret's cookie decoded: %v", err)
+	}
+}
+
+func TestSecureCookieTooLarge(t *testing.T) {
+	store := NewSecureCookieStore([]byte("secret"))
+	if _, err := store.Encode("session", strings.Repeat("x", maxCookieSize)); err == nil {
+		t.Error("encoded a cookie browsers would drop")
+	}
+}
@@ -0,0 +1,167 @@
+package sleepy
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+)
+
+// A ServerOption configures the http.Server an API creates to serve
+// requests.
+type ServerOption func(*http.Server)
+
+// WithReadTimeout sets the server's ReadTimeout, the longest it will
+// spend reading a request including its body.
+func WithReadTimeout(d time.Duration) ServerOption {
+	return func(server *http.Server) {
+		server.ReadTimeout = d
+	}
+}
+
+// WithReadHeaderTimeout sets the server's ReadHeaderTimeout, the
+// longest it will spend reading request headers.
+func WithReadHeaderTimeout(d time.Duration) ServerOption {
+	return func(server *http.Server) {
+		server.ReadHeaderTimeout = d
+	}
+}
+
+// WithWriteTimeout sets the server's WriteTimeout, the longest it will
+// spend writing a response.
+func WithWriteTimeout(d time.Duration) ServerOption {
+	return func(server *http.Server) {
+		server.WriteTimeout = d
+	}
+}
+
+// WithIdleTimeout sets the server's IdleTimeout, the longest it will
+// keep an idle keep-alive connection open.
+func WithIdleTimeout(d time.Duration) ServerOption {
+	return func(server *http.Server) {
+		server.IdleTimeout = d
+	}
+}
+
+// WithServerConfig copies the timeouts, limits, TLS configuration,
+// logger and connection hooks of cfg to the server. cfg's Addr and
+// Handler are ignored; the API always supplies its own.
+func WithServerConfig(cfg *http.Server) ServerOption {
+	return func(server *http.Server) {
+		server.ReadTimeout = cfg.ReadTimeout
+		server.ReadHeaderTimeout = cfg.ReadHeaderTimeout
+		server.WriteTimeout = cfg.WriteTimeout
+		server.IdleTimeout = cfg.IdleTimeout
+		server.MaxHeaderBytes = cfg.MaxHeaderBytes
+		server.TLSConfig = cfg.TLSConfig
+		server.TLSNextProto = cfg.TLSNextProto
+		server.ErrorLog = cfg.ErrorLog
+		server.ConnState = cfg.ConnState
+		server.BaseContext = cfg.BaseContext
+		server.ConnContext = cfg.ConnContext
+		server.DisableGeneralOptionsHandler = cfg.DisableGeneralOptionsHandler
+	}
+}
+
+// ConfigureServer applies options to every http.Server the API creates
+// from now on, such as the one behind Start. Options are applied in
+// order, so later ones win.
+func (api *API) ConfigureServer(options ...ServerOption) {
+	api.serverOptions = append(api.serverOptions, options...)
+}
+
+// UseServer makes Start serve the API with server instead of creating
+// a server of its own, so the API can share a server configured
+// elsewhere, for example with pprof or a gRPC gateway. Start sets the
+// server's Addr. If the server has no Handler the API becomes its
+// handler; otherwise the existing handler is kept, and should route
+// requests on to the API itself. Options given to ConfigureServer are
+// not applied to server.
+func (api *API) UseServer(server *http.Server) {
+	api.server = server
+}
+
+// newServer returns a server for the API listening on addr: the one
+// given to UseServer, or else a new one with the configured server
+// options applied.
+func (api *API) newServer(addr string) *http.Server {
+	if api.server != nil {
+		api.server.Addr = addr
+		if api.server.Handler == nil {
+			api.server.Handler = api
+		}
+		return api.server
+	}
+	return api.configuredServer(addr, api)
+}
+
+// configuredServer returns a new server for handler listening on addr,
+// with the configured server options applied.
+func (api *API) configuredServer(addr string, handler http.Handler) *http.Server {
+	server := &http.Server{Addr: addr, Handler: handler}
+	for _, option := range api.serverOptions {
+		option(server)
+	}
+	return server
+}
+
+// listen listens on addr for a server to serve on. It fails with an
+// error that names addr and wraps the one from net.Listen, so that a
+// port already in use can be recognized with errors.Is.
+func listen(addr string) (net.Listener, error) {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("sleepy: failed to listen on %s: %w", addr, err)
+	}
+	return listener, nil
+}
+
+// track records server as one that Stop should close.
+func (api *API) track(server *http.Server) {
+	api.serverMu.Lock()
+	api.running = append(api.running, server)
+	api.serverMu.Unlock()
+}
+
+// serveErrorBuffer is how many serve errors Errors holds for a reader
+// that falls behind.
+const serveErrorBuffer = 16
+
+// Errors returns a channel on which the API reports every error that
+// stops one of its listeners, other than http.ErrServerClosed after
+// Stop or Shutdown, whether it is started by Start, StartMulti or any
+// of their variants; for example so that a supervising goroutine can
+// exit the process when a listener of StartMulti fails to bind in the
+// background. The channel is never closed. Only errors that happen
+// after the first call are reported on it. It holds a few errors for a
+// slow reader; any beyond those are logged and dropped.
+func (api *API) Errors() <-chan error {
+	api.serverMu.Lock()
+	defer api.serverMu.Unlock()
+	if api.serveErrors == nil {
+		api.serveErrors = make(chan error, serveErrorBuffer)
+	}
+	return api.serveErrors
+}
+
+// serveError reports err, the error that stopped a listener, on the
+// channel Errors returns, if Errors has been called, and returns it.
+func (api *API) serveError(err error) error {
+	if err == nil || errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	api.serverMu.Lock()
+	errs := api.serveErrors
+	api.serverMu.Unlock()
+	if errs == nil {
+		// Nobody is listening, and the caller has err to handle.
+		return err
+	}
+	select {
+	case errs <- err:
+	default:
+		logf("sleepy: dropping serve error, as Errors is not being read: %v", err)
+	}
+	return err
+}
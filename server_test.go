@@ -0,0 +1,78 @@
+package sleepy
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestConfigureServer(t *testing.T) {
+	api := NewAPI()
+	api.ConfigureServer(
+		WithServerConfig(&http.Server{ReadTimeout: time.Minute, MaxHeaderBytes: 4096, Addr: ":1"}),
+		WithReadTimeout(5*time.Second),
+		WithWriteTimeout(10*time.Second),
+		WithIdleTimeout(time.Minute),
+	)
+
+	server := api.newServer(":3000")
+	if server.Addr != ":3000" || server.Handler != api {
+		t.Errorf("server has Addr %q and Handler %v", server.Addr, server.Handler)
+	}
+	if server.ReadTimeout != 5*time.Second || server.WriteTimeout != 10*time.Second || server.IdleTimeout != time.Minute {
+		t.Errorf("timeouts: read %v, write %v, idle %v", server.ReadTimeout, server.WriteTimeout, server.IdleTimeout)
+	}
+	if server.MaxHeaderBytes != 4096 {
+		t.Errorf("MaxHeaderBytes = %d", server.MaxHeaderBytes)
+	}
+}
+
+func TestUseServer(t *testing.T) {
+	shared := &http.Server{ReadTimeout: time.Second}
+
+	api := NewAPI()
+	api.ConfigureServer(WithReadTimeout(time.Minute))
+	api.UseServer(shared)
+
+	server := api.newServer(":3000")
+	if server != shared {
+		t.Fatal("newServer did not return the server given to UseServer")
+	}
+	if server.Addr != ":3000" || server.Handler != api || server.ReadTimeout != time.Second {
+		t.Errorf("server has Addr %q, Handler %v and ReadTimeout %v", server.Addr, server.Handler, server.ReadTimeout)
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/", api)
+	shared.Handler = mux
+	if server := api.newServer(":3000"); server.Handler != mux {
+		t.Errorf("existing Handler was replaced with %v", server.Handler)
+	}
+}
+
+func TestStartPortInUse(t *testing.T) {
+	listener, err := net.Listen("tcp", ":3021")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+
+	api := NewAPI()
+	api.AddResource(new(Item), "/items")
+	err = api.Start(3021)
+	if err == nil {
+		api.Stop()
+		t.Fatal("listening on a port in use succeeded")
+	}
+	if !strings.Contains(err.Error(), "sleepy: failed to listen on :3021") {
+		t.Errorf("error %q does not name the port", err)
+	}
+	var opErr *net.OpError
+	if !errors.As(err, &opErr) || !errors.Is(err, syscall.EADDRINUSE) {
+		t.Errorf("error %q does not unwrap to the listen error", err)
+	}
+}
@@ -0,0 +1,48 @@
+package sleepy
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// serverTimings holds the metrics a resource records for the
+// Server-Timing header of its response.
+type serverTimings struct {
+	mu      sync.Mutex
+	metrics []string
+}
+
+// AddServerTiming records that the part of serving the request named
+// name took d, from the context passed to a context-aware resource
+// method. The metrics recorded by the time the method returns are sent,
+// in the order they were added, in the response's Server-Timing header,
+// where browsers' developer tools show them:
+//
+//	Server-Timing: db;dur=12, render;dur=4
+//
+// Durations are given in milliseconds. AddServerTiming does nothing
+// given any other context.
+func AddServerTiming(ctx context.Context, name string, d time.Duration) {
+	t, _ := GetContextValue(ctx, timingsKey).(*serverTimings)
+	if t == nil {
+		return
+	}
+	ms := strconv.FormatFloat(float64(d)/float64(time.Millisecond), 'f', -1, 64)
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.metrics = append(t.metrics, name+";dur="+ms)
+}
+
+// write adds the Server-Timing header to rw, if any metrics were
+// recorded. It must be called before the response's header is written.
+func (t *serverTimings) write(rw http.ResponseWriter) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if len(t.metrics) > 0 {
+		rw.Header().Add("Server-Timing", strings.Join(t.metrics, ", "))
+	}
+}
@@ -0,0 +1,41 @@
+package sleepy
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+)
+
+type Dashboard struct{}
+
+func (dashboard Dashboard) GetContext(ctx context.Context, values url.Values, headers http.Header) (int, interface{}, http.Header) {
+	AddServerTiming(ctx, "db", 12*time.Millisecond)
+	AddServerTiming(ctx, "render", 4*time.Millisecond)
+	return 200, "ok", nil
+}
+
+func (dashboard Dashboard) Put(values url.Values, headers http.Header) (int, interface{}, http.Header) {
+	return 204, nil, nil
+}
+
+func TestServerTiming(t *testing.T) {
+	api := NewAPI()
+	api.AddResource(new(Dashboard), "/dashboard")
+
+	recorder := httptest.NewRecorder()
+	api.ServeHTTP(recorder, httptest.NewRequest("GET", "/dashboard", nil))
+	if timing := recorder.Header().Get("Server-Timing"); timing != "db;dur=12, render;dur=4" {
+		t.Errorf("Server-Timing = %q", timing)
+	}
+
+	recorder = httptest.NewRecorder()
+	api.ServeHTTP(recorder, httptest.NewRequest("PUT", "/dashboard", nil))
+	if timing, ok := recorder.Header()["Server-Timing"]; ok {
+		t.Errorf("Server-Timing = %q with no timings recorded", timing)
+	}
+
+	AddServerTiming(context.Background(), "db", time.Millisecond)
+}
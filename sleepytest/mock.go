@@ -0,0 +1,205 @@
+package sleepytest
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"sync"
+	"testing"
+
+	"github.com/dougblack/sleepy"
+)
+
+// A MockResource is a sleepy resource whose responses are programmed
+// by the test, for standing in for an API that the code under test
+// calls. It records every request it receives. Add it to an API at each
+// path it should answer:
+//
+//	mock := new(sleepytest.MockResource)
+//	mock.OnGET("/users").Return(200, userList)
+//	api := sleepy.NewAPI()
+//	api.AddResource(mock, "/users")
+//	test := sleepytest.New(t, api)
+//	// ... exercise the client against test.Server.URL ...
+//	mock.AssertCalled(t, "GET", "/users")
+//
+// A request for which no response has been programmed is answered with
+// a 404. The zero value is ready to use, and a MockResource is safe for
+// concurrent use.
+type MockResource struct {
+	mu        sync.Mutex
+	responses map[string]*MockResponse
+	calls     []MockCall
+}
+
+// A MockResponse is the response a MockResource gives to one method and
+// path. It may be changed while the mock is serving requests, which see
+// it either before or after the change.
+type MockResponse struct {
+	mu     *sync.Mutex // the owning mock's
+	code   int
+	data   interface{}
+	header http.Header
+}
+
+// Return sets the status code and the data, encoded as usual by the
+// API, of the response.
+func (response *MockResponse) Return(code int, data interface{}) *MockResponse {
+	response.mu.Lock()
+	defer response.mu.Unlock()
+	response.code = code
+	response.data = data
+	return response
+}
+
+// WithHeader adds a header to the response.
+func (response *MockResponse) WithHeader(name, value string) *MockResponse {
+	response.mu.Lock()
+	defer response.mu.Unlock()
+	response.header.Add(name, value)
+	return response
+}
+
+// A MockCall is a request received by a MockResource.
+type MockCall struct {
+	Method string
+	Path   string
+	Values url.Values
+	Header http.Header
+}
+
+// On programs the response to requests with the given method for path,
+// replacing any response programmed before. It answers 200 with no body
+// until Return is called.
+func (mock *MockResource) On(method, path string) *MockResponse {
+	mock.mu.Lock()
+	defer mock.mu.Unlock()
+	if mock.responses == nil {
+		mock.responses = make(map[string]*MockResponse)
+	}
+	response := &MockResponse{mu: &mock.mu, code: http.StatusOK, header: make(http.Header)}
+	mock.responses[method+" "+path] = response
+	return response
+}
+
+// OnGET programs the response to GET requests for path.
+func (mock *MockResource) OnGET(path string) *MockResponse {
+	return mock.On(http.MethodGet, path)
+}
+
+// OnHEAD programs the response to HEAD requests for path.
+func (mock *MockResource) OnHEAD(path string) *MockResponse {
+	return mock.On(http.MethodHead, path)
+}
+
+// OnPOST programs the response to POST requests for path.
+func (mock *MockResource) OnPOST(path string) *MockResponse {
+	return mock.On(http.MethodPost, path)
+}
+
+// OnPUT programs the response to PUT requests for path.
+func (mock *MockResource) OnPUT(path string) *MockResponse {
+	return mock.On(http.MethodPut, path)
+}
+
+// OnPATCH programs the response to PATCH requests for path.
+func (mock *MockResource) OnPATCH(path string) *MockResponse {
+	return mock.On(http.MethodPatch, path)
+}
+
+// OnDELETE programs the response to DELETE requests for path.
+func (mock *MockResource) OnDELETE(path string) *MockResponse {
+	return mock.On(http.MethodDelete, path)
+}
+
+// Calls returns the requests the mock has received, oldest first.
+func (mock *MockResource) Calls() []MockCall {
+	mock.mu.Lock()
+	defer mock.mu.Unlock()
+	return append([]MockCall(nil), mock.calls...)
+}
+
+// AssertCalled fails the test unless the mock has received a request
+// with the given method for path.
+func (mock *MockResource) AssertCalled(t testing.TB, method, path string) {
+	t.Helper()
+	if !mock.called(method, path) {
+		t.Errorf("%s %s was not called; calls: %v", method, path, mock.summary())
+	}
+}
+
+// AssertNotCalled fails the test if the mock has received a request
+// with the given method for path.
+func (mock *MockResource) AssertNotCalled(t testing.TB, method, path string) {
+	t.Helper()
+	if mock.called(method, path) {
+		t.Errorf("%s %s was called", method, path)
+	}
+}
+
+// called reports whether the mock has received a request with method
+// for path.
+func (mock *MockResource) called(method, path string) bool {
+	for _, call := range mock.Calls() {
+		if call.Method == method && call.Path == path {
+			return true
+		}
+	}
+	return false
+}
+
+// summary lists the calls the mock has received, for failure messages.
+func (mock *MockResource) summary() []string {
+	var calls []string
+	for _, call := range mock.Calls() {
+		calls = append(calls, call.Method+" "+call.Path)
+	}
+	return calls
+}
+
+// serve records a request and answers it with the programmed response.
+func (mock *MockResource) serve(ctx context.Context, method string, values url.Values, header http.Header) (int, interface{}, http.Header) {
+	path := ""
+	if request := sleepy.RequestFromContext(ctx); request != nil {
+		path = request.URL.Path
+	}
+
+	mock.mu.Lock()
+	defer mock.mu.Unlock()
+	mock.calls = append(mock.calls, MockCall{Method: method, Path: path, Values: values, Header: header})
+	response, ok := mock.responses[method+" "+path]
+	if !ok {
+		return http.StatusNotFound, map[string]string{"error": "no mock response for " + method + " " + path}, nil
+	}
+	return response.code, response.data, response.header.Clone()
+}
+
+// GetContext answers GET requests.
+func (mock *MockResource) GetContext(ctx context.Context, values url.Values, header http.Header) (int, interface{}, http.Header) {
+	return mock.serve(ctx, http.MethodGet, values, header)
+}
+
+// HeadContext answers HEAD requests.
+func (mock *MockResource) HeadContext(ctx context.Context, values url.Values, header http.Header) (int, interface{}, http.Header) {
+	return mock.serve(ctx, http.MethodHead, values, header)
+}
+
+// PostContext answers POST requests.
+func (mock *MockResource) PostContext(ctx context.Context, values url.Values, header http.Header) (int, interface{}, http.Header) {
+	return mock.serve(ctx, http.MethodPost, values, header)
+}
+
+// PutContext answers PUT requests.
+func (mock *MockResource) PutContext(ctx context.Context, values url.Values, header http.Header) (int, interface{}, http.Header) {
+	return mock.serve(ctx, http.MethodPut, values, header)
+}
+
+// PatchContext answers PATCH requests.
+func (mock *MockResource) PatchContext(ctx context.Context, values url.Values, header http.Header) (int, interface{}, http.Header) {
+	return mock.serve(ctx, http.MethodPatch, values, header)
+}
+
+// DeleteContext answers DELETE requests.
+func (mock *MockResource) DeleteContext(ctx context.Context, values url.Values, header http.Header) (int, interface{}, http.Header) {
+	return mock.serve(ctx, http.MethodDelete, values, header)
+}
@@ -0,0 +1,89 @@
+package sleepytest
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/dougblack/sleepy"
+)
+
+// fetchNames stands in for client code that calls a sleepy API.
+func fetchNames(base string) ([]string, error) {
+	response, err := http.Get(base + "/users")
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+	var users []struct{ Name string }
+	if err := json.NewDecoder(response.Body).Decode(&users); err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, user := range users {
+		names = append(names, user.Name)
+	}
+	return names, nil
+}
+
+func TestMockResource(t *testing.T) {
+	mock := new(MockResource)
+	mock.OnGET("/users").Return(200, []map[string]string{{"name": "ann"}, {"name": "bob"}}).WithHeader("X-Total", "2")
+	mock.OnPOST("/users").Return(201, map[string]string{"name": "cat"})
+
+	api := sleepy.NewAPI()
+	api.AddResource(mock, "/users", "/groups")
+	test := New(t, api)
+
+	names, err := fetchNames(test.Server.URL)
+	if err != nil || strings.Join(names, ",") != "ann,bob" {
+		t.Errorf("fetchNames returned %v, %v", names, err)
+	}
+	test.GET("/users").AssertStatus(t, 200).AssertHeader(t, "X-Total", "2")
+	test.POST("/users", url.Values{"name": {"cat"}}).
+		AssertStatus(t, 201).
+		AssertJSONBody(t, map[string]string{"name": "cat"})
+	test.GET("/groups").AssertStatus(t, 404)
+
+	mock.AssertCalled(t, "GET", "/users")
+	mock.AssertCalled(t, "GET", "/groups")
+	mock.AssertNotCalled(t, "DELETE", "/users")
+	calls := mock.Calls()
+	if len(calls) != 4 {
+		t.Fatalf("recorded %d calls, want 4", len(calls))
+	}
+	if post := calls[2]; post.Method != "POST" || post.Values.Get("name") != "cat" {
+		t.Errorf("recorded %+v for the POST", post)
+	}
+
+	recorder := &recordingT{TB: t}
+	mock.AssertCalled(recorder, "DELETE", "/users")
+	mock.AssertNotCalled(recorder, "GET", "/users")
+	if len(recorder.failures) != 2 {
+		t.Errorf("failures = %q, want 2", recorder.failures)
+	}
+}
+
+func TestMockResponseConcurrentReprogramming(t *testing.T) {
+	mock := new(MockResource)
+	response := mock.OnGET("/status")
+	api := sleepy.NewAPI()
+	api.AddResource(mock, "/status")
+	test := New(t, api)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 50; i++ {
+			response.Return(200+i%2, map[string]int{"i": i}).WithHeader("X-Step", "next")
+		}
+	}()
+	for i := 0; i < 50; i++ {
+		if code := test.GET("/status").StatusCode; code != 200 && code != 201 {
+			t.Errorf("got %d", code)
+		}
+	}
+	<-done
+}
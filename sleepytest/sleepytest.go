@@ -0,0 +1,200 @@
+// Package sleepytest provides helpers for testing APIs built with
+// sleepy: a TestAPI serves an API over a real HTTP server, and the
+// Responses it returns carry assertions for use in tests. A
+// MockResource stands in for an API when testing the code that calls
+// it.
+//
+//	func TestUsers(t *testing.T) {
+//		api := sleepy.NewAPI()
+//		api.AddResource(new(Users), "/users")
+//		test := sleepytest.New(t, api)
+//
+//		test.GET("/users").AssertStatus(t, 200)
+//		test.POST("/users", map[string]string{"name": "ann"}).
+//			AssertStatus(t, 201).
+//			AssertJSONBody(t, map[string]string{"name": "ann"})
+//	}
+package sleepytest
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// A TestAPI serves an API over HTTP for the length of a test.
+type TestAPI struct {
+	// Server is the server the API is served by.
+	Server *httptest.Server
+
+	t testing.TB
+}
+
+// New starts serving handler, usually a *sleepy.API, on a local port.
+// The server is closed when the test finishes.
+func New(t testing.TB, handler http.Handler) *TestAPI {
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+	return &TestAPI{Server: server, t: t}
+}
+
+// GET requests path, which may include a query string.
+func (api *TestAPI) GET(path string) *Response {
+	return api.Request(http.MethodGet, path, nil)
+}
+
+// HEAD requests path with the HEAD method.
+func (api *TestAPI) HEAD(path string) *Response {
+	return api.Request(http.MethodHead, path, nil)
+}
+
+// POST posts body to path. See Request for how body is sent.
+func (api *TestAPI) POST(path string, body interface{}) *Response {
+	return api.Request(http.MethodPost, path, body)
+}
+
+// PUT puts body at path. See Request for how body is sent.
+func (api *TestAPI) PUT(path string, body interface{}) *Response {
+	return api.Request(http.MethodPut, path, body)
+}
+
+// PATCH patches path with body. See Request for how body is sent.
+func (api *TestAPI) PATCH(path string, body interface{}) *Response {
+	return api.Request(http.MethodPatch, path, body)
+}
+
+// DELETE requests path with the DELETE method.
+func (api *TestAPI) DELETE(path string) *Response {
+	return api.Request(http.MethodDelete, path, nil)
+}
+
+// Request makes a request with the given method for path. A body of
+// url.Values is sent as a form, an io.Reader as it is, and anything
+// else other than nil encoded as JSON. The test fails at once if the
+// request cannot be made.
+func (api *TestAPI) Request(method, path string, body interface{}) *Response {
+	api.t.Helper()
+	var reader io.Reader
+	contentType := ""
+	switch body := body.(type) {
+	case nil:
+	case url.Values:
+		reader = strings.NewReader(body.Encode())
+		contentType = "application/x-www-form-urlencoded"
+	case io.Reader:
+		reader = body
+	default:
+		content, err := json.Marshal(body)
+		if err != nil {
+			api.t.Fatalf("encoding the body of %s %s: %v", method, path, err)
+		}
+		reader = bytes.NewReader(content)
+		contentType = "application/json"
+	}
+
+	request, err := http.NewRequest(method, api.Server.URL+path, reader)
+	if err != nil {
+		api.t.Fatalf("%s %s: %v", method, path, err)
+	}
+	if contentType != "" {
+		request.Header.Set("Content-Type", contentType)
+	}
+	return api.Do(request)
+}
+
+// Do sends request, which may be for a path relative to the server, and
+// reads the whole response. The test fails at once if it cannot be
+// sent.
+func (api *TestAPI) Do(request *http.Request) *Response {
+	api.t.Helper()
+	if request.URL.Host == "" {
+		base, _ := url.Parse(api.Server.URL)
+		request.URL = base.ResolveReference(request.URL)
+	}
+	response, err := api.Server.Client().Do(request)
+	if err != nil {
+		api.t.Fatalf("%s %s: %v", request.Method, request.URL.Path, err)
+	}
+	defer response.Body.Close()
+	body, err := io.ReadAll(response.Body)
+	if err != nil {
+		api.t.Fatalf("reading the response to %s %s: %v", request.Method, request.URL.Path, err)
+	}
+	return &Response{
+		StatusCode: response.StatusCode,
+		Header:     response.Header,
+		Body:       body,
+		request:    request.Method + " " + request.URL.RequestURI(),
+	}
+}
+
+// A Response is the response to a request made through a TestAPI, read
+// in full. Its assertion methods report failures on the test they are
+// given, naming the request, and return the Response so that
+// assertions can be chained.
+type Response struct {
+	StatusCode int
+	Header     http.Header
+	Body       []byte
+
+	request string
+}
+
+// AssertStatus fails the test unless the response's status is code.
+func (r *Response) AssertStatus(t testing.TB, code int) *Response {
+	t.Helper()
+	if r.StatusCode != code {
+		t.Errorf("%s: status %d, want %d; body %s", r.request, r.StatusCode, code, r.Body)
+	}
+	return r
+}
+
+// AssertHeader fails the test unless the response's header name has
+// value.
+func (r *Response) AssertHeader(t testing.TB, name, value string) *Response {
+	t.Helper()
+	if got := r.Header.Get(name); got != value {
+		t.Errorf("%s: %s is %q, want %q", r.request, name, got, value)
+	}
+	return r
+}
+
+// AssertJSONBody fails the test unless the body is JSON equal to
+// expected once both are decoded, so that formatting and the order of
+// object keys don't matter. expected may be a JSON string, or any value
+// which is first encoded as JSON.
+func (r *Response) AssertJSONBody(t testing.TB, expected interface{}) *Response {
+	t.Helper()
+	var want, got interface{}
+	var wantJSON []byte
+	if s, ok := expected.(string); ok {
+		wantJSON = []byte(s)
+	} else {
+		var err error
+		if wantJSON, err = json.Marshal(expected); err != nil {
+			t.Fatalf("encoding the expected body: %v", err)
+		}
+	}
+	if err := json.Unmarshal(wantJSON, &want); err != nil {
+		t.Fatalf("decoding the expected body: %v", err)
+	}
+	if err := json.Unmarshal(r.Body, &got); err != nil {
+		t.Errorf("%s: body %s is not JSON: %v", r.request, r.Body, err)
+		return r
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("%s: body %s, want %s", r.request, r.Body, wantJSON)
+	}
+	return r
+}
+
+// DecodeJSON decodes the body into v.
+func (r *Response) DecodeJSON(v interface{}) error {
+	return json.Unmarshal(r.Body, v)
+}
@@ -0,0 +1,77 @@
+package sleepytest
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/dougblack/sleepy"
+)
+
+type Users struct{}
+
+func (users Users) Get(values url.Values, headers http.Header) (int, interface{}, http.Header) {
+	return 200, []map[string]interface{}{{"name": "ann", "age": 31}}, http.Header{"X-Total": {"1"}}
+}
+
+func (users Users) Post(values url.Values, headers http.Header) (int, interface{}, http.Header) {
+	if values.Get("name") == "" {
+		return 400, map[string]string{"error": "name is required"}, nil
+	}
+	return 201, map[string]string{"name": values.Get("name")}, nil
+}
+
+func newTestAPI(t *testing.T) *TestAPI {
+	api := sleepy.NewAPI()
+	api.AddResource(new(Users), "/users")
+	return New(t, api)
+}
+
+func TestTestAPI(t *testing.T) {
+	test := newTestAPI(t)
+
+	test.GET("/users").
+		AssertStatus(t, 200).
+		AssertHeader(t, "X-Total", "1").
+		AssertJSONBody(t, `[{"age": 31, "name": "ann"}]`)
+	test.POST("/users", url.Values{"name": {"bob"}}).
+		AssertStatus(t, 201).
+		AssertJSONBody(t, map[string]string{"name": "bob"})
+	test.POST("/users", nil).AssertStatus(t, 400)
+	test.DELETE("/users").AssertStatus(t, 405)
+
+	var users []struct{ Name string }
+	if err := test.GET("/users").DecodeJSON(&users); err != nil || len(users) != 1 || users[0].Name != "ann" {
+		t.Errorf("decoded %v, %v", users, err)
+	}
+}
+
+// recordingT records the failures reported to it instead of failing.
+type recordingT struct {
+	testing.TB
+	failures []string
+}
+
+func (t *recordingT) Helper() {}
+
+func (t *recordingT) Errorf(format string, args ...interface{}) {
+	t.failures = append(t.failures, fmt.Sprintf(format, args...))
+}
+
+func TestAssertionsFail(t *testing.T) {
+	test := newTestAPI(t)
+	recorder := &recordingT{TB: t}
+
+	test.GET("/users").
+		AssertStatus(recorder, 404).
+		AssertHeader(recorder, "X-Total", "2").
+		AssertJSONBody(recorder, `[]`)
+	if len(recorder.failures) != 3 {
+		t.Fatalf("failures = %q, want 3", recorder.failures)
+	}
+	want := "GET /users: status 200, want 404; body "
+	if got := recorder.failures[0]; len(got) < len(want) || got[:len(want)] != want {
+		t.Errorf("failure %q does not start with %q", got, want)
+	}
+}
@@ -0,0 +1,147 @@
+package sleepy
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// An SSEEvent is a Server-Sent Event. A resource method streams events
+// to the client by returning a channel of them, a <-chan SSEEvent or a
+// chan SSEEvent, in place of its data:
+//
+//	func (feed Feed) GetContext(ctx context.Context, values url.Values, headers http.Header) (int, interface{}, http.Header) {
+//		events := make(chan sleepy.SSEEvent)
+//		go feed.publish(ctx, events)
+//		return 200, (<-chan sleepy.SSEEvent)(events), nil
+//	}
+//
+// The response has the Content-Type text/event-stream, and each event
+// is flushed to the client as soon as it is received. While no event
+// arrives a keep-alive comment is sent every 30 seconds, or at the
+// interval of the route's KeepAlive option, so that proxies keep the
+// connection open. The stream ends when the channel is closed, or is
+// cut off once it reaches the limit set with WithMaxResponseBodyBytes.
+// If the client goes away first, the request's context is canceled. In
+// every case the rest of the channel is drained in the background until
+// the channel is closed or the context is done, so the producer must
+// stop sending once the context is done, and may then close the
+// channel or simply abandon it.
+type SSEEvent struct {
+	// Event is the event's type, which clients listen for; left empty,
+	// it is "message". ID sets the client's last event ID, which it
+	// sends back in a Last-Event-ID header when it reconnects. Both are
+	// single lines: any carriage returns and line feeds in them are
+	// dropped, so that they cannot start fields or events of their own.
+	Event string
+	ID    string
+
+	// Data is the event's payload. It may span several lines, ended by
+	// "\r\n", "\r" or "\n".
+	Data string
+}
+
+// sseLineBreaks removes line breaks from a single-line field.
+var sseLineBreaks = strings.NewReplacer("\r", "", "\n", "")
+
+// sseNewlines turns every kind of line ending into "\n".
+var sseNewlines = strings.NewReplacer("\r\n", "\n", "\r", "\n")
+
+// writeTo writes the event to w in the text/event-stream format.
+func (event SSEEvent) writeTo(w io.Writer) error {
+	var b strings.Builder
+	if id := sseLineBreaks.Replace(event.ID); id != "" {
+		b.WriteString("id: " + id + "\n")
+	}
+	if name := sseLineBreaks.Replace(event.Event); name != "" {
+		b.WriteString("event: " + name + "\n")
+	}
+	for _, line := range strings.Split(sseNewlines.Replace(event.Data), "\n") {
+		b.WriteString("data: " + line + "\n")
+	}
+	b.WriteString("\n")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// ssePing writes a keep-alive comment to w.
+func ssePing(w io.Writer) error {
+	_, err := io.WriteString(w, ": ping\n\n")
+	return err
+}
+
+// streamSSE writes each event received from stream to the client,
+// ending the response when the channel is closed or the client goes
+// away.
+func (api *API) streamSSE(rw http.ResponseWriter, request *http.Request, code int, stream <-chan SSEEvent, header http.Header) {
+	addHeaders(rw, header)
+	rw.Header().Set("Content-Type", "text/event-stream")
+	rw.Header().Set("Cache-Control", "no-cache")
+	rw.WriteHeader(code)
+	controller := http.NewResponseController(rw)
+	controller.Flush()
+	defer func() {
+		// Drain the channel so the producer isn't left blocked, until
+		// it closes the channel or the server cancels the context.
+		go func() {
+			for {
+				select {
+				case _, ok := <-stream:
+					if !ok {
+						return
+					}
+				case <-request.Context().Done():
+					return
+				}
+			}
+		}()
+	}()
+	if request.Method == HEAD {
+		return
+	}
+
+	var w io.Writer = rw
+	if api.maxResponseBytes > 0 {
+		w = &cappedWriter{w: rw, limit: api.maxResponseBytes}
+	}
+	write := func(send func(io.Writer) error) bool {
+		err := send(w)
+		if err == errResponseTooLarge {
+			logf("sleepy: stream cut off at the response limit of %d bytes", api.maxResponseBytes)
+		}
+		return err == nil
+	}
+
+	interval := defaultKeepAlive
+	if keepAlive, ok := GetContextValue(request.Context(), keepAliveKey).(time.Duration); ok {
+		interval = keepAlive
+	}
+	timer := time.NewTimer(interval)
+	defer timer.Stop()
+	for {
+		select {
+		case event, ok := <-stream:
+			if !ok {
+				return
+			}
+			if !write(event.writeTo) {
+				return
+			}
+		case <-timer.C:
+			if !write(ssePing) {
+				return
+			}
+		case <-request.Context().Done():
+			return
+		}
+		controller.Flush()
+		if !timer.Stop() {
+			select {
+			case <-timer.C:
+			default:
+			}
+		}
+		timer.Reset(interval)
+	}
+}
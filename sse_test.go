@@ -0,0 +1,147 @@
+package sleepy
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+type LoadMonitor struct {
+	done chan struct{}
+}
+
+func (monitor LoadMonitor) GetContext(ctx context.Context, values url.Values, headers http.Header) (int, interface{}, http.Header) {
+	events := make(chan SSEEvent)
+	go func() {
+		defer close(events)
+		defer close(monitor.done)
+		for _, event := range []SSEEvent{
+			{Event: "load", ID: "1", Data: "0.5"},
+			{Data: "line one\nline two"},
+		} {
+			select {
+			case events <- event:
+			case <-ctx.Done():
+				return
+			}
+		}
+		<-ctx.Done()
+	}()
+	return 200, (<-chan SSEEvent)(events), nil
+}
+
+func TestSSEEvents(t *testing.T) {
+	monitor := LoadMonitor{done: make(chan struct{})}
+	api := NewAPI()
+	api.AddResourceWithOptions(monitor, "/load", KeepAlive(20*time.Millisecond))
+	server := httptest.NewServer(api)
+	defer server.Close()
+
+	response, err := http.Get(server.URL + "/load")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if contentType := response.Header.Get("Content-Type"); contentType != "text/event-stream" {
+		t.Errorf("got Content-Type %q", contentType)
+	}
+	reader := bufio.NewReader(response.Body)
+	read := func() string {
+		var event strings.Builder
+		for {
+			line, err := reader.ReadString('\n')
+			if err != nil || line == "\n" {
+				return event.String()
+			}
+			event.WriteString(line)
+		}
+	}
+	if event := read(); event != "id: 1\nevent: load\ndata: 0.5\n" {
+		t.Errorf("first event: got %q", event)
+	}
+	if event := read(); event != "data: line one\ndata: line two\n" {
+		t.Errorf("second event: got %q", event)
+	}
+	if event := read(); event != ": ping\n" {
+		t.Errorf("while quiet: got %q, want a ping", event)
+	}
+
+	response.Body.Close()
+	select {
+	case <-monitor.done:
+	case <-time.After(time.Second):
+		t.Error("producer was not told the client went away")
+	}
+}
+
+// Beacon sends events until the client goes away, and never closes its
+// channel.
+type Beacon struct {
+	done chan struct{}
+}
+
+func (beacon Beacon) GetContext(ctx context.Context, values url.Values, headers http.Header) (int, interface{}, http.Header) {
+	events := make(chan SSEEvent)
+	go func() {
+		defer close(beacon.done)
+		for {
+			select {
+			case events <- SSEEvent{Data: "tick"}:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return 200, (<-chan SSEEvent)(events), nil
+}
+
+func TestSSEResponseLimit(t *testing.T) {
+	var logged []string
+	logf = func(format string, args ...interface{}) {
+		logged = append(logged, fmt.Sprintf(format, args...))
+	}
+	defer func() { logf = log.Printf }()
+
+	beacon := Beacon{done: make(chan struct{})}
+	api := NewAPIWithOptions(WithMaxResponseBodyBytes(40))
+	api.AddResource(beacon, "/beacon")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	recorder := httptest.NewRecorder()
+	api.ServeHTTP(recorder, httptest.NewRequest("GET", "/beacon", nil).WithContext(ctx))
+	if body := recorder.Body.String(); body != strings.Repeat("data: tick\n\n", 3) {
+		t.Errorf("got %q, want three events", body)
+	}
+	if len(logged) != 1 || !strings.Contains(logged[0], "cut off at the response limit of 40 bytes") {
+		t.Errorf("logged %q", logged)
+	}
+
+	cancel()
+	select {
+	case <-beacon.done:
+	case <-time.After(time.Second):
+		t.Error("producer was left blocked on its channel")
+	}
+}
+
+func TestSSEEventLineBreaks(t *testing.T) {
+	var b strings.Builder
+	event := SSEEvent{
+		ID:    "7\nevent: spoofed",
+		Event: "update\r\ndata: injected",
+		Data:  "one\r\ntwo\rthree\nfour",
+	}
+	if err := event.writeTo(&b); err != nil {
+		t.Fatal(err)
+	}
+	want := "id: 7event: spoofed\nevent: updatedata: injected\ndata: one\ndata: two\ndata: three\ndata: four\n\n"
+	if b.String() != want {
+		t.Errorf("got %q, want %q", b.String(), want)
+	}
+}
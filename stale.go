@@ -0,0 +1,138 @@
+package sleepy
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// A CachedResponse is a response kept by a CacheStore, with the time it
+// was stored.
+type CachedResponse struct {
+	StoredResponse
+	Time time.Time
+
+	// Vary holds the request's values of the headers named by the
+	// response's Vary header, which a later request must match for the
+	// response to be served to it.
+	Vary http.Header
+}
+
+// A CacheStore keeps the latest successful response to each URL, for
+// StaleIfError to fall back on. Implementations must be safe for
+// concurrent use; a *MemoryStore[string, CachedResponse] is one.
+//
+// The store gets an entry for every distinct URL, query string included,
+// that is answered successfully, so a MemoryStore grows without bound
+// if clients can vary the URL freely. Such an API needs a store that
+// evicts, by age or by size; StaleIfError treats a missing entry like a
+// URL it has not seen.
+type CacheStore interface {
+	// Get returns the response stored under key, if there is one.
+	Get(key string) (CachedResponse, bool)
+
+	// Set stores response under key, replacing any response there.
+	Set(key string, response CachedResponse)
+}
+
+// StaleIfError returns middleware that keeps the latest 200 OK response
+// to each GET in store, keyed by its URL, and serves it in place of a
+// server error for as long as it is no older than maxStaleness. Stale
+// responses carry an Age header and the header
+//
+//	Warning: 110 - "Response is Stale"
+//
+// so clients can tell. A server error with no fresh enough response to
+// fall back on is passed through.
+//
+// Only responses that could be shared are kept. Requests with an
+// Authorization or Cookie header are passed through untouched, and
+// responses marked Cache-Control private or no-store, or Vary *, are
+// not stored. A response that varies on other request headers is only
+// served to requests that match it on every one of them.
+func StaleIfError(store CacheStore, maxStaleness time.Duration) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(rw http.ResponseWriter, request *http.Request) {
+			if request.Method != GET || request.Header.Get("Authorization") != "" || request.Header.Get("Cookie") != "" {
+				next.ServeHTTP(rw, request)
+				return
+			}
+			key := request.URL.RequestURI()
+
+			buffered := newBufferedResponse()
+			next.ServeHTTP(buffered, request)
+			if buffered.status == 0 {
+				buffered.status = http.StatusOK
+			}
+			switch {
+			case buffered.status == http.StatusOK:
+				if vary, ok := shareable(buffered.header, request); ok {
+					store.Set(key, CachedResponse{
+						StoredResponse: StoredResponse{
+							Status: buffered.status,
+							Header: buffered.header.Clone(),
+							Body:   append([]byte(nil), buffered.body.Bytes()...),
+						},
+						Time: time.Now(),
+						Vary: vary,
+					})
+				}
+			case buffered.status >= 500:
+				if cached, ok := store.Get(key); ok && time.Since(cached.Time) <= maxStaleness && matchesVary(cached, request) {
+					addHeaders(rw, cached.Header)
+					rw.Header().Set("Age", strconv.Itoa(int(time.Since(cached.Time)/time.Second)))
+					rw.Header().Add("Warning", `110 - "Response is Stale"`)
+					rw.WriteHeader(cached.Status)
+					rw.Write(cached.Body)
+					return
+				}
+			}
+			buffered.copyTo(rw)
+		})
+	}
+}
+
+// shareable reports whether a response with header, to request, may be
+// kept for other clients, and if so returns the request's values of the
+// headers the response varies on.
+func shareable(header http.Header, request *http.Request) (http.Header, bool) {
+	for _, directive := range headerTokens(header, "Cache-Control") {
+		if directive == "private" || directive == "no-store" || strings.HasPrefix(directive, "private=") {
+			return nil, false
+		}
+	}
+	vary := http.Header{}
+	for _, name := range headerTokens(header, "Vary") {
+		if name == "*" {
+			return nil, false
+		}
+		vary[http.CanonicalHeaderKey(name)] = request.Header.Values(name)
+	}
+	return vary, true
+}
+
+// matchesVary reports whether request has the same values as the one
+// cached was the response to, for every header cached varies on.
+func matchesVary(cached CachedResponse, request *http.Request) bool {
+	for name, values := range cached.Vary {
+		if strings.Join(request.Header.Values(name), ", ") != strings.Join(values, ", ") {
+			return false
+		}
+	}
+	return true
+}
+
+// headerTokens returns the comma-separated elements of every value of
+// the named header, trimmed and in lower case.
+func headerTokens(header http.Header, name string) []string {
+	var tokens []string
+	for _, value := range header.Values(name) {
+		for _, token := range strings.Split(value, ",") {
+			if token = strings.ToLower(strings.TrimSpace(token)); token != "" {
+				tokens = append(tokens, token)
+			}
+		}
+	}
+	return tokens
+}
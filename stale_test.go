@@ -0,0 +1,114 @@
+package sleepy
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+)
+
+type Quotes struct {
+	down *bool
+}
+
+func (quotes Quotes) Get(values url.Values, headers http.Header) (int, interface{}, http.Header) {
+	if *quotes.down {
+		return 503, map[string]string{"error": "upstream is down"}, nil
+	}
+	return 200, map[string]string{"symbol": values.Get("symbol"), "price": "42"}, nil
+}
+
+func TestStaleIfError(t *testing.T) {
+	down := false
+	store := new(MemoryStore[string, CachedResponse])
+	api := NewAPI()
+	api.SetJSONOptions("", true)
+	api.Use(StaleIfError(store, time.Minute))
+	api.AddResource(Quotes{&down}, "/quotes")
+
+	get := func(target string) *httptest.ResponseRecorder {
+		recorder := httptest.NewRecorder()
+		api.ServeHTTP(recorder, httptest.NewRequest("GET", target, nil))
+		return recorder
+	}
+
+	fresh := get("/quotes?symbol=ACME")
+	if fresh.Code != 200 || fresh.Header().Get("Warning") != "" {
+		t.Fatalf("fresh: got %d with Warning %q", fresh.Code, fresh.Header().Get("Warning"))
+	}
+
+	down = true
+	stale := get("/quotes?symbol=ACME")
+	if stale.Code != 200 || stale.Body.String() != fresh.Body.String() {
+		t.Errorf("stale: got %d %q, want the cached %q", stale.Code, stale.Body.String(), fresh.Body.String())
+	}
+	if warning := stale.Header().Get("Warning"); warning != `110 - "Response is Stale"` {
+		t.Errorf("stale: Warning = %q", warning)
+	}
+	if stale.Header().Get("Age") != "0" || stale.Header().Get("Content-Type") != "application/json" {
+		t.Errorf("stale: headers = %v", stale.Header())
+	}
+
+	if uncached := get("/quotes?symbol=INIT"); uncached.Code != 503 {
+		t.Errorf("nothing cached: got %d, want 503", uncached.Code)
+	}
+
+	cached, _ := store.Get("/quotes?symbol=ACME")
+	cached.Time = time.Now().Add(-2 * time.Minute)
+	store.Set("/quotes?symbol=ACME", cached)
+	if expired := get("/quotes?symbol=ACME"); expired.Code != 503 {
+		t.Errorf("too stale: got %d, want 503", expired.Code)
+	}
+}
+
+// Rates answers with the headers it is given until it is down.
+type Rates struct {
+	down   *bool
+	header http.Header
+}
+
+func (rates Rates) Get(values url.Values, headers http.Header) (int, interface{}, http.Header) {
+	if *rates.down {
+		return 503, nil, nil
+	}
+	return 200, map[string]string{"currency": headers.Get("Accept-Language")}, rates.header
+}
+
+func TestStaleIfErrorShared(t *testing.T) {
+	for _, test := range []struct {
+		name          string
+		header        http.Header
+		first, second http.Header
+		code          int
+	}{
+		{"private", http.Header{"Cache-Control": {"private, max-age=60"}}, nil, nil, 503},
+		{"no-store", http.Header{"Cache-Control": {"no-store"}}, nil, nil, 503},
+		{"vary star", http.Header{"Vary": {"*"}}, nil, nil, 503},
+		{"authorization", nil, http.Header{"Authorization": {"Bearer a"}}, http.Header{"Authorization": {"Bearer a"}}, 503},
+		{"cookie", nil, http.Header{"Cookie": {"session=a"}}, http.Header{"Cookie": {"session=a"}}, 503},
+		{"vary mismatch", http.Header{"Vary": {"Accept-Language"}}, http.Header{"Accept-Language": {"fr"}}, http.Header{"Accept-Language": {"de"}}, 503},
+		{"vary match", http.Header{"Vary": {"Accept-Language"}}, http.Header{"Accept-Language": {"fr"}}, http.Header{"Accept-Language": {"fr"}}, 200},
+		{"public", http.Header{"Cache-Control": {"public, max-age=60"}}, nil, nil, 200},
+	} {
+		down := false
+		api := NewAPI()
+		api.Use(StaleIfError(new(MemoryStore[string, CachedResponse]), time.Minute))
+		api.AddResource(Rates{&down, test.header}, "/rates")
+
+		get := func(header http.Header) int {
+			request := httptest.NewRequest("GET", "/rates", nil)
+			for name, values := range header {
+				request.Header[name] = values
+			}
+			recorder := httptest.NewRecorder()
+			api.ServeHTTP(recorder, request)
+			return recorder.Code
+		}
+		get(test.first)
+		down = true
+		if code := get(test.second); code != test.code {
+			t.Errorf("%s: got %d, want %d", test.name, code, test.code)
+		}
+	}
+}
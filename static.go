@@ -0,0 +1,40 @@
+package sleepy
+
+import (
+	"net/http"
+	"strings"
+	"time"
+)
+
+// staticCacheControl lets clients and proxies cache static files for a
+// day.
+const staticCacheControl = "public, max-age=86400"
+
+// ServeFile serves the file at file from path, with headers that let
+// clients cache it for a day. Conditional requests are answered with
+// 304 Not Modified when the file hasn't changed. The file is served
+// like a handler added with AddHandler.
+func (api *API) ServeFile(path, file string) {
+	api.AddHandler(path, http.HandlerFunc(func(rw http.ResponseWriter, request *http.Request) {
+		rw.Header().Set("Cache-Control", staticCacheControl)
+		http.ServeFile(rw, request, file)
+	}))
+}
+
+// ServeFavicon serves the icon at file from /favicon.ico.
+func (api *API) ServeFavicon(file string) {
+	api.ServeFile("/favicon.ico", file)
+}
+
+// ServeRobotsTxt serves content as /robots.txt. For example, to keep
+// crawlers away from the whole API:
+//
+//	api.ServeRobotsTxt("User-agent: *\nDisallow: /\n")
+func (api *API) ServeRobotsTxt(content string) {
+	modified := time.Now()
+	api.AddHandler("/robots.txt", http.HandlerFunc(func(rw http.ResponseWriter, request *http.Request) {
+		rw.Header().Set("Cache-Control", staticCacheControl)
+		rw.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		http.ServeContent(rw, request, "robots.txt", modified, strings.NewReader(content))
+	}))
+}
@@ -0,0 +1,49 @@
+package sleepy
+
+import (
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestServeStaticFiles(t *testing.T) {
+	icon := filepath.Join(t.TempDir(), "favicon.ico")
+	if err := os.WriteFile(icon, []byte("icon"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	api := NewAPI()
+	api.ServeFavicon(icon)
+	api.ServeRobotsTxt("User-agent: *\nDisallow: /\n")
+
+	recorder := httptest.NewRecorder()
+	api.ServeHTTP(recorder, httptest.NewRequest("GET", "/favicon.ico", nil))
+	if recorder.Code != 200 || recorder.Body.String() != "icon" {
+		t.Errorf("favicon: got %d %q", recorder.Code, recorder.Body.String())
+	}
+	if cc := recorder.Header().Get("Cache-Control"); cc != staticCacheControl {
+		t.Errorf("favicon: Cache-Control %q", cc)
+	}
+
+	request := httptest.NewRequest("GET", "/favicon.ico", nil)
+	request.Header.Set("If-Modified-Since", recorder.Header().Get("Last-Modified"))
+	recorder = httptest.NewRecorder()
+	api.ServeHTTP(recorder, request)
+	if recorder.Code != 304 {
+		t.Errorf("conditional favicon: got %d", recorder.Code)
+	}
+
+	recorder = httptest.NewRecorder()
+	api.ServeHTTP(recorder, httptest.NewRequest("GET", "/robots.txt", nil))
+	if recorder.Code != 200 || recorder.Body.String() != "User-agent: *\nDisallow: /\n" {
+		t.Errorf("robots.txt: got %d %q", recorder.Code, recorder.Body.String())
+	}
+	if ct := recorder.Header().Get("Content-Type"); ct != "text/plain; charset=utf-8" {
+		t.Errorf("robots.txt: Content-Type %q", ct)
+	}
+
+	if routes := api.Routes(); len(routes) != 2 || routes[0].Path != "/favicon.ico" || routes[1].Path != "/robots.txt" {
+		t.Errorf("routes = %v, want the favicon and robots.txt", routes)
+	}
+}
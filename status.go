@@ -0,0 +1,65 @@
+package sleepy
+
+import "net/http"
+
+// SetStatusValidation turns checking of the status codes resources
+// return on or off. With it on, the default, a response whose code lies
+// outside the range 100 to 599, including a code left unset at 0, is
+// logged and replaced by an empty 500 Internal Server Error. With it
+// off, the code is passed to the http.ResponseWriter as it is; the
+// standard one panics on codes below 100 or above 999.
+func (api *API) SetStatusValidation(enabled bool) {
+	api.validateStatus = enabled
+}
+
+// checkStatus reports whether code may be sent in response to request.
+// If status validation rejects it, a 500 is sent instead.
+func (api *API) checkStatus(rw http.ResponseWriter, request *http.Request, code int) bool {
+	if !api.validateStatus || (code >= 100 && code <= 599) {
+		return true
+	}
+	logf("sleepy: %s %s: invalid status code %d", request.Method, request.URL.Path, code)
+	rw.WriteHeader(http.StatusInternalServerError)
+	return false
+}
+
+// OK returns a 200 OK carrying data, in the form a resource method
+// returns, so it can end with
+//
+//	return sleepy.OK(items)
+func OK(data interface{}) (int, interface{}, http.Header) {
+	return http.StatusOK, data, nil
+}
+
+// Created returns a 201 Created carrying data.
+func Created(data interface{}) (int, interface{}, http.Header) {
+	return http.StatusCreated, data, nil
+}
+
+// CreatedAt returns a 201 Created carrying data, the resource a POST
+// created, with a Location header giving location, its URL:
+//
+//	return sleepy.CreatedAt("/users/"+strconv.Itoa(user.ID), user)
+//
+// URLFor builds location from the resource served there and its path
+// parameters.
+func CreatedAt(location string, data interface{}) (int, interface{}, http.Header) {
+	return http.StatusCreated, data, http.Header{"Location": {location}}
+}
+
+// NoContent returns a 204 No Content, which is sent without a body.
+func NoContent() (int, interface{}, http.Header) {
+	return http.StatusNoContent, nil, nil
+}
+
+// BadRequest returns a 400 Bad Request whose body reports err as
+// {"error": "..."}.
+func BadRequest(err error) (int, interface{}, http.Header) {
+	return http.StatusBadRequest, map[string]string{"error": err.Error()}, nil
+}
+
+// NotImplemented returns a 501 Not Implemented, for a method whose
+// implementation is still to come.
+func NotImplemented() (int, interface{}, http.Header) {
+	return http.StatusNotImplemented, map[string]string{"error": "not implemented"}, nil
+}
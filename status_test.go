@@ -0,0 +1,142 @@
+package sleepy
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+type User struct{}
+
+func (user User) Get(values url.Values, headers http.Header) (int, interface{}, http.Header) {
+	return OK(map[string]string{"name": "doug"})
+}
+
+func (user User) Post(values url.Values, headers http.Header) (int, interface{}, http.Header) {
+	if values.Get("name") == "" {
+		return BadRequest(errors.New("name is required"))
+	}
+	return Created(map[string]string{"name": values.Get("name")})
+}
+
+func (user User) Delete(values url.Values, headers http.Header) (int, interface{}, http.Header) {
+	return NoContent()
+}
+
+func (user User) Put(values url.Values, headers http.Header) (int, interface{}, http.Header) {
+	return NotImplemented()
+}
+
+func TestStatusHelpers(t *testing.T) {
+	api := NewAPI()
+	api.SetJSONOptions("", true)
+	api.AddResource(new(User), "/user")
+
+	for _, test := range []struct {
+		method, target string
+		code           int
+		body           string
+	}{
+		{"GET", "/user", 200, `{"name":"doug"}`},
+		{"POST", "/user?name=ben", 201, `{"name":"ben"}`},
+		{"POST", "/user", 400, `{"error":"name is required"}`},
+		{"DELETE", "/user", 204, ""},
+		{"PUT", "/user", 501, `{"error":"not implemented"}`},
+	} {
+		recorder := httptest.NewRecorder()
+		api.ServeHTTP(recorder, httptest.NewRequest(test.method, test.target, nil))
+		if recorder.Code != test.code || recorder.Body.String() != test.body {
+			t.Errorf("%s %s: got %d %q, want %d %q", test.method, test.target, recorder.Code, recorder.Body.String(), test.code, test.body)
+		}
+	}
+}
+
+type Signup struct{}
+
+func (signup Signup) Post(values url.Values, headers http.Header) (int, interface{}, http.Header) {
+	return CreatedAt("/users/5", map[string]interface{}{"id": 5, "name": values.Get("name")})
+}
+
+func TestCreatedAt(t *testing.T) {
+	api := NewAPI()
+	api.SetJSONOptions("", true)
+	api.AddResource(new(Signup), "/users")
+
+	recorder := httptest.NewRecorder()
+	api.ServeHTTP(recorder, httptest.NewRequest("POST", "/users?name=ben", nil))
+	if recorder.Code != 201 || recorder.Header().Get("Location") != "/users/5" {
+		t.Errorf("got %d with Location %q, want 201 with /users/5", recorder.Code, recorder.Header().Get("Location"))
+	}
+	if ct, body := recorder.Header().Get("Content-Type"), recorder.Body.String(); ct != "application/json" || body != `{"id":5,"name":"ben"}` {
+		t.Errorf("got %q %q", ct, body)
+	}
+}
+
+func TestStatusValidation(t *testing.T) {
+	var logged []string
+	logf = func(format string, args ...interface{}) {
+		logged = append(logged, fmt.Sprintf(format, args...))
+	}
+	defer func() { logf = log.Printf }()
+
+	api := NewAPI()
+	api.AddResourceFunc(GET, "/odd", func(values url.Values) (int, interface{}) {
+		return 700, map[string]string{"status": "odd"}
+	})
+
+	recorder := httptest.NewRecorder()
+	api.ServeHTTP(recorder, httptest.NewRequest("GET", "/odd", nil))
+	if recorder.Code != 500 || recorder.Body.Len() != 0 {
+		t.Errorf("got %d %q, want an empty 500", recorder.Code, recorder.Body.String())
+	}
+	if len(logged) != 1 || !strings.Contains(logged[0], "invalid status code 700") {
+		t.Errorf("logged %q", logged)
+	}
+
+	api.SetStatusValidation(false)
+	recorder = httptest.NewRecorder()
+	api.ServeHTTP(recorder, httptest.NewRequest("GET", "/odd", nil))
+	if recorder.Code != 700 {
+		t.Errorf("without validation: got %d, want 700", recorder.Code)
+	}
+}
+
+type Purge struct{}
+
+func (purge Purge) Delete(values url.Values, headers http.Header) (int, interface{}, http.Header) {
+	return 204, nil, http.Header{"X-Deleted-Count": {"3"}}
+}
+
+func (purge Purge) Post(values url.Values, headers http.Header) (int, interface{}, http.Header) {
+	return 202, nil, http.Header{"X-Queued": {"true"}}
+}
+
+func TestHeadersWithoutBody(t *testing.T) {
+	api := NewAPI()
+	api.AddResource(new(Purge), "/purge")
+
+	for _, test := range []struct {
+		method, header string
+		code           int
+	}{
+		{"DELETE", "X-Deleted-Count", 204},
+		{"POST", "X-Queued", 202},
+	} {
+		recorder := httptest.NewRecorder()
+		api.ServeHTTP(recorder, httptest.NewRequest(test.method, "/purge", nil))
+		if recorder.Code != test.code || recorder.Body.Len() != 0 {
+			t.Errorf("%s: got %d %q, want %d and no body", test.method, recorder.Code, recorder.Body.String(), test.code)
+		}
+		if recorder.Header().Get(test.header) == "" {
+			t.Errorf("%s: %s is missing", test.method, test.header)
+		}
+		if ct := recorder.Header().Get("Content-Type"); ct != "" {
+			t.Errorf("%s: Content-Type = %q, want none", test.method, ct)
+		}
+	}
+}
@@ -0,0 +1,46 @@
+package sleepy
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+)
+
+// streamNDJSON writes each value received from stream as one line of
+// JSON, ending the response when the channel is closed. Buffered lines
+// are flushed to the client whenever the channel has nothing ready, so
+// records reach the client as soon as the producer stalls.
+func (api *API) streamNDJSON(rw http.ResponseWriter, code int, stream <-chan interface{}, header http.Header) {
+	addHeaders(rw, header)
+	if rw.Header().Get("Content-Type") == "" {
+		rw.Header().Set("Content-Type", "application/x-ndjson")
+	}
+	rw.WriteHeader(code)
+
+	controller := http.NewResponseController(rw)
+	var w io.Writer = rw
+	if api.maxResponseBytes > 0 {
+		w = &cappedWriter{w: rw, limit: api.maxResponseBytes}
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetEscapeHTML(api.jsonEscapeHTML)
+	for value := range stream {
+		if rewrite, ok := api.jsonRewrite(); ok {
+			value = applyFieldNames(value, rewrite)
+		}
+		if err := encoder.Encode(value); err != nil {
+			// The status line has gone out, so the best we can do is
+			// stop the stream early.
+			if err == errResponseTooLarge {
+				logf("sleepy: stream cut off at the response limit of %d bytes", api.maxResponseBytes)
+			}
+			break
+		}
+		if len(stream) == 0 {
+			controller.Flush()
+		}
+	}
+	for range stream {
+		// Drain the channel so the producer isn't left blocked.
+	}
+}
@@ -0,0 +1,52 @@
+package sleepy
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+type Export struct{}
+
+func (export Export) Get(values url.Values, headers http.Header) (int, interface{}, http.Header) {
+	records := make(chan interface{})
+	go func() {
+		defer close(records)
+		for i := 1; i <= 3; i++ {
+			records <- map[string]int{"id": i}
+		}
+	}()
+	return 200, (<-chan interface{})(records), nil
+}
+
+func TestNDJSONStream(t *testing.T) {
+	api := NewAPI()
+	api.AddResource(new(Export), "/export")
+	server := httptest.NewServer(api)
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "/export")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if ct := resp.Header.Get("Content-Type"); ct != "application/x-ndjson" {
+		t.Errorf("Content-Type = %q", ct)
+	}
+
+	scanner := bufio.NewScanner(resp.Body)
+	var ids []int
+	for scanner.Scan() {
+		var record map[string]int
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			t.Fatalf("line %q: %v", scanner.Text(), err)
+		}
+		ids = append(ids, record["id"])
+	}
+	if len(ids) != 3 || ids[0] != 1 || ids[2] != 3 {
+		t.Errorf("got records %v, want 1 through 3", ids)
+	}
+}
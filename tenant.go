@@ -0,0 +1,66 @@
+package sleepy
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"strings"
+)
+
+// TenantMiddleware returns middleware for multi-tenant APIs that finds
+// the tenant each request is for with extract and stores it in the
+// request context, where GetTenant retrieves it so that resources can
+// scope what they read and write. A request for which extract returns
+// "" names no tenant and is answered with a 404 Not Found before it
+// reaches a resource. TenantFromSubdomain and TenantFromHeader provide
+// the usual extractors.
+func TenantMiddleware(extract func(r *http.Request) string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(rw http.ResponseWriter, request *http.Request) {
+			tenant := extract(request)
+			if tenant == "" {
+				rw.WriteHeader(http.StatusNotFound)
+				return
+			}
+			next.ServeHTTP(rw, request.WithContext(SetContextValue(request.Context(), tenantKey, tenant)))
+		})
+	}
+}
+
+// TenantFromSubdomain returns an extractor for TenantMiddleware that
+// takes the tenant from the subdomain of domain that a request is for,
+// so that "acme" is the tenant of a request to acme.api.example.com
+// when domain is "api.example.com". The tenant is lowercased. Requests
+// for domain itself, for another domain, or for a deeper subdomain
+// name no tenant.
+func TenantFromSubdomain(domain string) func(r *http.Request) string {
+	suffix := "." + strings.ToLower(strings.TrimSuffix(domain, "."))
+	return func(request *http.Request) string {
+		host, _, err := net.SplitHostPort(request.Host)
+		if err != nil {
+			host = request.Host
+		}
+		host = strings.ToLower(strings.TrimSuffix(host, "."))
+		tenant, ok := strings.CutSuffix(host, suffix)
+		if !ok || strings.Contains(tenant, ".") {
+			return ""
+		}
+		return tenant
+	}
+}
+
+// TenantFromHeader returns an extractor for TenantMiddleware that takes
+// the tenant from the request header name, such as one set by a gateway
+// in front of the API.
+func TenantFromHeader(name string) func(r *http.Request) string {
+	return func(request *http.Request) string {
+		return strings.TrimSpace(request.Header.Get(name))
+	}
+}
+
+// GetTenant returns the tenant of the request ctx belongs to, as found
+// by TenantMiddleware, or "" outside it.
+func GetTenant(ctx context.Context) string {
+	tenant, _ := GetContextValue(ctx, tenantKey).(string)
+	return tenant
+}
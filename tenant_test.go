@@ -0,0 +1,61 @@
+package sleepy
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+type Workspace struct{}
+
+func (workspace Workspace) GetContext(ctx context.Context, values url.Values, headers http.Header) (int, interface{}, http.Header) {
+	return 200, map[string]string{"tenant": GetTenant(ctx)}, nil
+}
+
+func TestTenantFromSubdomain(t *testing.T) {
+	api := NewAPI()
+	api.SetJSONOptions("", true)
+	api.Use(TenantMiddleware(TenantFromSubdomain("api.example.com")))
+	api.AddResource(new(Workspace), "/workspace")
+
+	for _, c := range []struct {
+		host string
+		code int
+		body string
+	}{
+		{"acme.api.example.com", 200, `{"tenant":"acme"}`},
+		{"Globex.API.example.com:8443", 200, `{"tenant":"globex"}`},
+		{"api.example.com", 404, ""},
+		{"eu.acme.api.example.com", 404, ""},
+		{"acme.example.org", 404, ""},
+	} {
+		request := httptest.NewRequest("GET", "/workspace", nil)
+		request.Host = c.host
+		recorder := httptest.NewRecorder()
+		api.ServeHTTP(recorder, request)
+		if recorder.Code != c.code || recorder.Body.String() != c.body {
+			t.Errorf("%s: got %d %q, want %d %q", c.host, recorder.Code, recorder.Body.String(), c.code, c.body)
+		}
+	}
+}
+
+func TestTenantFromHeader(t *testing.T) {
+	api := NewAPI()
+	api.SetJSONOptions("", true)
+	api.Use(TenantMiddleware(TenantFromHeader("X-Tenant")))
+	api.AddResource(new(Workspace), "/workspace")
+
+	request := httptest.NewRequest("GET", "/workspace", nil)
+	request.Header.Set("X-Tenant", "initech")
+	recorder := httptest.NewRecorder()
+	api.ServeHTTP(recorder, request)
+	if body := recorder.Body.String(); body != `{"tenant":"initech"}` {
+		t.Errorf("got %q", body)
+	}
+
+	if GetTenant(context.Background()) != "" {
+		t.Error("a tenant outside TenantMiddleware")
+	}
+}
@@ -0,0 +1,55 @@
+package sleepy
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+)
+
+// textContentType is the content type of plain-text responses.
+const textContentType = "text/plain; charset=utf-8"
+
+// plainText is response data that is sent as it is, as plain text.
+type plainText string
+
+// AddResourceText behaves like AddResource, except that whenever one of
+// resource's methods returns data that implements fmt.Stringer, the
+// response is the result of its String method, sent as plain text
+// rather than encoded as JSON. Other data is encoded as usual.
+func (api *API) AddResourceText(resource interface{}, paths ...string) {
+	for _, path := range paths {
+		api.register(path, resource, api.textHandler)
+	}
+}
+
+// textHandler returns the handler that serves resource the way
+// AddResourceText does.
+func (api *API) textHandler(resource interface{}) http.Handler {
+	set := make(methodSet)
+	for _, method := range methods {
+		if handler := methodHandler(resource, method); handler != nil {
+			set[method] = asText(handler)
+		}
+	}
+	return api.requestHandler(set)
+}
+
+// asText returns a handler that turns the Stringer data handler returns
+// into plain text.
+func asText(handler handlerFunc) handlerFunc {
+	return func(ctx context.Context, values url.Values, header http.Header) (int, interface{}, http.Header) {
+		code, data, header := handler(ctx, values, header)
+		if stringer, ok := data.(fmt.Stringer); ok {
+			data = plainText(stringer.String())
+		}
+		return code, data, header
+	}
+}
+
+// textEncoder writes plain text as it is.
+var textEncoder Encoder = EncoderFunc(func(w io.Writer, data interface{}) error {
+	_, err := io.WriteString(w, string(data.(plainText)))
+	return err
+})
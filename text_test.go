@@ -0,0 +1,47 @@
+package sleepy
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+type Version struct {
+	Major, Minor int
+}
+
+func (version Version) String() string {
+	return fmt.Sprintf("v%d.%d", version.Major, version.Minor)
+}
+
+func (version Version) Get(values url.Values, headers http.Header) (int, interface{}, http.Header) {
+	if values.Get("format") == "struct" {
+		return 200, struct{ Major int }{version.Major}, nil
+	}
+	return 200, version, nil
+}
+
+func TestAddResourceText(t *testing.T) {
+	api := NewAPI()
+	api.RegisterEncoder("application/xml", XMLEncoder)
+	api.AddResourceText(Version{1, 2}, "/version")
+	api.AddResource(Version{1, 2}, "/version.json")
+
+	get := func(target string) (string, string) {
+		recorder := httptest.NewRecorder()
+		api.ServeHTTP(recorder, httptest.NewRequest("GET", target, nil))
+		return recorder.Header().Get("Content-Type"), recorder.Body.String()
+	}
+
+	if ct, body := get("/version"); ct != "text/plain; charset=utf-8" || body != "v1.2" {
+		t.Errorf("text: got %q %q", ct, body)
+	}
+	if ct, body := get("/version?format=struct"); ct != "application/json" || body != "{\n  \"Major\": 1\n}" {
+		t.Errorf("not a Stringer: got %q %q", ct, body)
+	}
+	if ct, body := get("/version.json"); ct != "application/json" || body != "{\n  \"Major\": 1,\n  \"Minor\": 2\n}" {
+		t.Errorf("AddResource: got %q %q", ct, body)
+	}
+}
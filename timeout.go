@@ -0,0 +1,209 @@
+package sleepy
+
+import (
+	"context"
+	"net/http"
+	"runtime/debug"
+	"sync"
+	"time"
+)
+
+// timeoutRetryAfter is how long clients whose request ran out of time are
+// told to wait before trying again, long enough for a burst of slow
+// requests to clear.
+const timeoutRetryAfter = 5 * time.Second
+
+// SetDefaultTimeout limits how long any resource may take to respond.
+// A request still running after d is answered with a 503 Service
+// Unavailable whose body is {"error": "request timeout"}, encoded like
+// any other response, and whose Retry-After header asks the client to
+// wait five seconds, and the request's context is canceled; a panic
+// in the resource after that is passed to the panic handler. A resource
+// that has begun to stream its response by then, as NDJSON, Server-Sent
+// Events and chunked responses do, is left to finish it. Resources
+// added with AddResourceWithTimeout use their own limit instead. It
+// applies to resources added before and after the call; a d of zero
+// removes the limit.
+func (api *API) SetDefaultTimeout(d time.Duration) {
+	api.defaultTimeout = d
+}
+
+// AddResourceWithTimeout behaves like AddResource, but requests to
+// resource that take longer than timeout are answered with a 503
+// Service Unavailable, regardless of the API's default timeout.
+func (api *API) AddResourceWithTimeout(resource interface{}, timeout time.Duration, paths ...string) {
+	for _, path := range paths {
+		api.mustRegisterRoute(route{path: path, resource: resource, timeout: timeout}, api.resourceHandler)
+	}
+}
+
+// timeoutHandler applies the route's timeout, or the API's default if
+// the route has none, to each request.
+func (api *API) timeoutHandler(next http.Handler, timeout time.Duration) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, request *http.Request) {
+		d := timeout
+		if d <= 0 {
+			d = api.defaultTimeout
+		}
+		if d <= 0 {
+			next.ServeHTTP(rw, request)
+			return
+		}
+
+		ctx, cancel := context.WithCancel(request.Context())
+		defer cancel()
+		timer := time.NewTimer(d)
+		defer timer.Stop()
+
+		writer := &timeoutWriter{rw: rw, buffered: newBufferedResponse()}
+		done := make(chan struct{})
+		panicked := make(chan *handlerPanic, 1)
+		go func() {
+			defer func() {
+				recovered := recover()
+				if !writer.finish() {
+					// The client has had its 503, so all that is left to
+					// do with a panic is report it.
+					if recovered != nil && recovered != http.ErrAbortHandler {
+						api.reportPanic(request, recovered, debug.Stack())
+					}
+					return
+				}
+				if recovered != nil {
+					panicked <- &handlerPanic{value: recovered, stack: debug.Stack()}
+					return
+				}
+				close(done)
+			}()
+			next.ServeHTTP(writer, request.WithContext(ctx))
+		}()
+
+		select {
+		case recovered := <-panicked:
+			// Re-panic here, where recoverHandler can see it.
+			panic(recovered)
+		case <-done:
+			writer.copyTo()
+			return
+		case <-timer.C:
+		}
+		if writer.timeOut() {
+			cancel()
+			setRetryAfter(rw.Header(), timeoutRetryAfter)
+			api.respond(rw, request, http.StatusServiceUnavailable, map[string]string{"error": "request timeout"}, nil)
+			return
+		}
+		// The handler finished just in time, or is streaming, which is no
+		// longer subject to the timeout.
+		select {
+		case recovered := <-panicked:
+			panic(recovered)
+		case <-done:
+			writer.copyTo()
+		}
+	})
+}
+
+// timeoutWriter is the ResponseWriter of a handler running under a
+// timeout. It holds the response in a buffer of its own, so that if the
+// handler runs over nothing it writes afterwards can reach the client.
+// A handler that flushes, to stream its response, has its response
+// passed straight through from then on instead.
+type timeoutWriter struct {
+	rw       http.ResponseWriter
+	mu       sync.Mutex
+	buffered *bufferedResponse
+
+	// streaming is set once the handler has flushed, finished once it
+	// has returned and timedOut once the client has been sent a 503;
+	// finished and timedOut exclude each other.
+	streaming bool
+	finished  bool
+	timedOut  bool
+}
+
+func (w *timeoutWriter) Header() http.Header {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.streaming {
+		return w.rw.Header()
+	}
+	return w.buffered.Header()
+}
+
+func (w *timeoutWriter) WriteHeader(code int) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	switch {
+	case w.timedOut:
+	case w.streaming:
+		w.rw.WriteHeader(code)
+	default:
+		w.buffered.WriteHeader(code)
+	}
+}
+
+func (w *timeoutWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	switch {
+	case w.timedOut:
+		return 0, http.ErrHandlerTimeout
+	case w.streaming:
+		return w.rw.Write(p)
+	}
+	return w.buffered.Write(p)
+}
+
+// FlushError sends what has been written so far to the client and
+// passes the rest of the response straight through.
+func (w *timeoutWriter) FlushError() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.timedOut {
+		return http.ErrHandlerTimeout
+	}
+	if !w.streaming {
+		w.streaming = true
+		w.buffered.copyTo(w.rw)
+	}
+	return http.NewResponseController(w.rw).Flush()
+}
+
+func (w *timeoutWriter) Flush() {
+	w.FlushError()
+}
+
+// Unwrap returns the underlying ResponseWriter, for
+// http.ResponseController.
+func (w *timeoutWriter) Unwrap() http.ResponseWriter {
+	return w.rw
+}
+
+// finish records that the handler has returned, and reports whether it
+// did so before the client was sent a 503.
+func (w *timeoutWriter) finish() bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.finished = !w.timedOut
+	return w.finished
+}
+
+// timeOut records that the client is to be sent a 503, unless the
+// handler has finished or started streaming, and reports whether it is.
+func (w *timeoutWriter) timeOut() bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.timedOut = !w.finished && !w.streaming
+	return w.timedOut
+}
+
+// copyTo writes the handler's response to the client, unless it was
+// streamed there already.
+func (w *timeoutWriter) copyTo() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if !w.streaming {
+		w.buffered.copyTo(w.rw)
+	}
+}
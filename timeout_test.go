@@ -0,0 +1,139 @@
+package sleepy
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+)
+
+type Sleeper time.Duration
+
+func (sleeper Sleeper) Get(values url.Values, headers http.Header) (int, interface{}, http.Header) {
+	time.Sleep(time.Duration(sleeper))
+	return 200, "awake", nil
+}
+
+func TestTimeouts(t *testing.T) {
+	api := NewAPI()
+	api.SetDefaultTimeout(20 * time.Millisecond)
+	api.AddResource(Sleeper(200*time.Millisecond), "/default")
+	api.AddResourceWithTimeout(Sleeper(50*time.Millisecond), time.Second, "/patient")
+	api.AddResourceWithTimeout(Sleeper(200*time.Millisecond), 5*time.Millisecond, "/impatient")
+	api.AddResource(Sleeper(0), "/fast")
+
+	cases := []struct {
+		path string
+		code int
+	}{
+		{"/default", http.StatusServiceUnavailable},
+		{"/patient", http.StatusOK},
+		{"/impatient", http.StatusServiceUnavailable},
+		{"/fast", http.StatusOK},
+	}
+	for _, c := range cases {
+		recorder := httptest.NewRecorder()
+		api.ServeHTTP(recorder, httptest.NewRequest("GET", c.path, nil))
+		if recorder.Code != c.code {
+			t.Errorf("%s: got %d, want %d", c.path, recorder.Code, c.code)
+		}
+	}
+}
+
+func TestTimeoutBody(t *testing.T) {
+	api := NewAPI()
+	api.SetJSONOptions("", true)
+	api.AddResourceWithTimeout(Sleeper(200*time.Millisecond), 5*time.Millisecond, "/impatient")
+
+	recorder := httptest.NewRecorder()
+	api.ServeHTTP(recorder, httptest.NewRequest("GET", "/impatient", nil))
+	if recorder.Code != http.StatusServiceUnavailable {
+		t.Errorf("got %d, want 503", recorder.Code)
+	}
+	if ct := recorder.Header().Get("Content-Type"); ct != "application/json" {
+		t.Errorf("Content-Type = %q", ct)
+	}
+	if body := recorder.Body.String(); body != `{"error":"request timeout"}` {
+		t.Errorf("body = %q", body)
+	}
+	if retryAfter := recorder.Header().Get("Retry-After"); retryAfter != "5" {
+		t.Errorf("Retry-After = %q", retryAfter)
+	}
+}
+
+func TestTimeoutPanic(t *testing.T) {
+	api := NewAPI()
+	api.SetDefaultTimeout(time.Second)
+	api.AddResource(new(Broken), "/broken")
+
+	recorder := httptest.NewRecorder()
+	api.ServeHTTP(recorder, httptest.NewRequest("GET", "/broken", nil))
+	if recorder.Code != http.StatusInternalServerError {
+		t.Errorf("got %d, want 500", recorder.Code)
+	}
+}
+
+// Straggler panics once the request has run out of time.
+type Straggler struct{}
+
+func (straggler Straggler) GetContext(ctx context.Context, values url.Values, headers http.Header) (int, interface{}, http.Header) {
+	<-ctx.Done()
+	panic("too late")
+}
+
+func TestTimeoutPanicAfterDeadline(t *testing.T) {
+	panics := make(chan interface{}, 1)
+	api := NewAPI()
+	api.SetPanicHandler(func(r *http.Request, recovered interface{}, stack []byte) {
+		panics <- recovered
+	})
+	api.AddResourceWithTimeout(Straggler{}, 5*time.Millisecond, "/straggler")
+
+	recorder := httptest.NewRecorder()
+	api.ServeHTTP(recorder, httptest.NewRequest("GET", "/straggler", nil))
+	if recorder.Code != http.StatusServiceUnavailable {
+		t.Errorf("got %d, want 503", recorder.Code)
+	}
+	select {
+	case recovered := <-panics:
+		if recovered != "too late" {
+			t.Errorf("panic handler got %v", recovered)
+		}
+	case <-time.After(time.Second):
+		t.Error("the panic was not reported")
+	}
+}
+
+// Drip streams a record, then another after its route's timeout.
+type Drip struct{}
+
+func (drip Drip) Get(values url.Values, headers http.Header) (int, interface{}, http.Header) {
+	records := make(chan interface{})
+	go func() {
+		defer close(records)
+		records <- 1
+		time.Sleep(30 * time.Millisecond)
+		records <- 2
+	}()
+	return 200, (<-chan interface{})(records), nil
+}
+
+func TestTimeoutStream(t *testing.T) {
+	api := NewAPI()
+	api.AddResourceWithTimeout(Drip{}, 10*time.Millisecond, "/drip")
+	server := httptest.NewServer(api)
+	defer server.Close()
+
+	response, err := http.Get(server.URL + "/drip")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer response.Body.Close()
+	body, _ := io.ReadAll(response.Body)
+	if response.StatusCode != 200 || string(body) != "1\n2\n" {
+		t.Errorf("got %d %q, want the whole stream", response.StatusCode, body)
+	}
+}
@@ -0,0 +1,165 @@
+package sleepy
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	mathrand "math/rand"
+	"net/http"
+	"strings"
+)
+
+// traceHeaders lists the trace context headers that are propagated: the
+// W3C Trace Context headers and both forms of Zipkin's B3 headers.
+var traceHeaders = []string{
+	"Traceparent",
+	"Tracestate",
+	"B3",
+	"X-B3-Traceid",
+	"X-B3-Spanid",
+	"X-B3-Parentspanid",
+	"X-B3-Sampled",
+	"X-B3-Flags",
+}
+
+// TracePropagationMiddleware returns middleware that stores each
+// request's trace context headers in its context, where
+// OutboundTraceHeaders retrieves them, so that calls the resource makes
+// to other services join the same trace. A request that arrives
+// without trace context starts a new W3C trace, which is marked as
+// sampled for a sampleRate fraction of such requests; incoming traces
+// keep the sampling decision made upstream.
+func TracePropagationMiddleware(sampleRate float64) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(rw http.ResponseWriter, request *http.Request) {
+			trace := make(http.Header)
+			for _, name := range traceHeaders {
+				if values := request.Header.Values(name); len(values) > 0 {
+					trace[name] = append([]string(nil), values...)
+				}
+			}
+			var parent TraceParent
+			ok := true
+			if len(trace) == 0 {
+				parent = newTraceParent(mathrand.Float64() < sampleRate)
+				trace.Set("Traceparent", parent.String())
+			} else {
+				parent, ok = parseTraceparent(trace.Get("Traceparent"))
+			}
+			ctx := SetContextValue(request.Context(), traceKey, trace)
+			if ok {
+				ctx = SetContextValue(ctx, traceParentKey, parent)
+			}
+			next.ServeHTTP(rw, request.WithContext(ctx))
+		})
+	}
+}
+
+// OutboundTraceHeaders returns the trace context headers stored by
+// TracePropagationMiddleware, to be added to requests made to other
+// services on behalf of the request ctx belongs to. It returns an empty
+// header if there are none.
+func OutboundTraceHeaders(ctx context.Context) http.Header {
+	trace, ok := GetContextValue(ctx, traceKey).(http.Header)
+	if !ok {
+		return make(http.Header)
+	}
+	return trace.Clone()
+}
+
+// A TraceParent is the W3C Trace Context of a request: the trace it
+// belongs to, the span that made it, and whether the trace is sampled.
+type TraceParent struct {
+	TraceID  string // 32 lowercase hex digits
+	ParentID string // 16 lowercase hex digits
+	Sampled  bool
+
+	// local is set for a trace this service started rather than
+	// joined, whose ParentID names no span.
+	local bool
+}
+
+// String formats p as the value of a traceparent header.
+func (p TraceParent) String() string {
+	flags := "00"
+	if p.Sampled {
+		flags = "01"
+	}
+	return "00-" + p.TraceID + "-" + p.ParentID + "-" + flags
+}
+
+// TraceparentMiddleware returns middleware that reads each request's
+// W3C traceparent header, or starts a new trace when it has none or it
+// is malformed, and stores the result in the request context, where
+// TraceContext retrieves it. New traces are sampled for a sampleRate
+// fraction of requests. The traceparent is echoed on the response so
+// clients can find the trace.
+func TraceparentMiddleware(sampleRate float64) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(rw http.ResponseWriter, request *http.Request) {
+			parent, ok := parseTraceparent(request.Header.Get("Traceparent"))
+			if !ok {
+				parent = newTraceParent(mathrand.Float64() < sampleRate)
+			}
+			rw.Header().Set("Traceparent", parent.String())
+			next.ServeHTTP(rw, request.WithContext(SetContextValue(request.Context(), traceParentKey, parent)))
+		})
+	}
+}
+
+// TraceContext returns the trace context stored by
+// TraceparentMiddleware or TracePropagationMiddleware, and whether
+// there is one.
+func TraceContext(ctx context.Context) (TraceParent, bool) {
+	parent, ok := GetContextValue(ctx, traceParentKey).(TraceParent)
+	return parent, ok
+}
+
+// parseTraceparent parses a traceparent header value. Only version 00
+// of the format is understood, though later versions are read as far
+// as version 00 goes, as the specification asks.
+func parseTraceparent(value string) (TraceParent, bool) {
+	parts := strings.Split(strings.TrimSpace(value), "-")
+	if len(parts) < 4 || len(parts[0]) != 2 || parts[0] == "ff" || (parts[0] == "00" && len(parts) != 4) {
+		return TraceParent{}, false
+	}
+	version, traceID, parentID, flags := parts[0], parts[1], parts[2], parts[3]
+	if !isLowerHex(version) || !isLowerHex(traceID) || !isLowerHex(parentID) || !isLowerHex(flags) ||
+		len(traceID) != 32 || len(parentID) != 16 || len(flags) != 2 ||
+		strings.Trim(traceID, "0") == "" || strings.Trim(parentID, "0") == "" {
+		return TraceParent{}, false
+	}
+	sampled := hexValue(flags[1])&1 == 1
+	return TraceParent{TraceID: traceID, ParentID: parentID, Sampled: sampled}, true
+}
+
+// isLowerHex reports whether s is made only of lowercase hex digits.
+func isLowerHex(s string) bool {
+	for _, c := range s {
+		if (c < '0' || c > '9') && (c < 'a' || c > 'f') {
+			return false
+		}
+	}
+	return true
+}
+
+// hexValue returns the value of the lowercase hex digit c.
+func hexValue(c byte) byte {
+	if c >= 'a' {
+		return c - 'a' + 10
+	}
+	return c - '0'
+}
+
+// newTraceParent returns the trace context of a new trace, with random
+// trace and parent IDs.
+func newTraceParent(sampled bool) TraceParent {
+	var id [24]byte
+	rand.Read(id[:])
+	return TraceParent{
+		TraceID:  hex.EncodeToString(id[:16]),
+		ParentID: hex.EncodeToString(id[16:]),
+		Sampled:  sampled,
+		local:    true,
+	}
+}
@@ -0,0 +1,110 @@
+package sleepy
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"testing"
+)
+
+type Downstream struct {
+	outbound http.Header
+}
+
+func (downstream *Downstream) GetContext(ctx context.Context, values url.Values, headers http.Header) (int, interface{}, http.Header) {
+	downstream.outbound = OutboundTraceHeaders(ctx)
+	return 200, "ok", nil
+}
+
+var traceparentPattern = regexp.MustCompile(`^00-[0-9a-f]{32}-[0-9a-f]{16}-0[01]$`)
+
+func TestTracePropagation(t *testing.T) {
+	downstream := new(Downstream)
+	api := NewAPI()
+	api.Use(TracePropagationMiddleware(1))
+	api.AddResource(downstream, "/downstream")
+
+	request := httptest.NewRequest("GET", "/downstream", nil)
+	request.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	request.Header.Set("X-B3-TraceId", "80f198ee56343ba864fe8b2a57d3eff7")
+	request.Header.Set("Accept", "application/json")
+	api.ServeHTTP(httptest.NewRecorder(), request)
+
+	expected := http.Header{
+		"Traceparent":  {"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"},
+		"X-B3-Traceid": {"80f198ee56343ba864fe8b2a57d3eff7"},
+	}
+	if len(downstream.outbound) != len(expected) {
+		t.Errorf("outbound headers = %v, want %v", downstream.outbound, expected)
+	}
+	for name := range expected {
+		if downstream.outbound.Get(name) != expected.Get(name) {
+			t.Errorf("%s = %q, want %q", name, downstream.outbound.Get(name), expected.Get(name))
+		}
+	}
+
+	api.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/downstream", nil))
+	traceparent := downstream.outbound.Get("Traceparent")
+	if !traceparentPattern.MatchString(traceparent) || traceparent[len(traceparent)-2:] != "01" {
+		t.Errorf("new trace: traceparent = %q, want a sampled W3C traceparent", traceparent)
+	}
+
+	if headers := OutboundTraceHeaders(context.Background()); len(headers) != 0 {
+		t.Errorf("without the middleware: got %v", headers)
+	}
+}
+
+type Traced struct {
+	parent TraceParent
+	ok     bool
+}
+
+func (traced *Traced) GetContext(ctx context.Context, values url.Values, headers http.Header) (int, interface{}, http.Header) {
+	traced.parent, traced.ok = TraceContext(ctx)
+	return 200, "ok", nil
+}
+
+func TestTraceparentMiddleware(t *testing.T) {
+	traced := new(Traced)
+	api := NewAPI()
+	api.Use(TraceparentMiddleware(0))
+	api.AddResource(traced, "/traced")
+
+	get := func(traceparent string) string {
+		request := httptest.NewRequest("GET", "/traced", nil)
+		if traceparent != "" {
+			request.Header.Set("traceparent", traceparent)
+		}
+		recorder := httptest.NewRecorder()
+		api.ServeHTTP(recorder, request)
+		return recorder.Header().Get("Traceparent")
+	}
+
+	incoming := "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+	if echoed := get(incoming); echoed != incoming {
+		t.Errorf("echoed %q, want %q", echoed, incoming)
+	}
+	expected := TraceParent{TraceID: "4bf92f3577b34da6a3ce929d0e0e4736", ParentID: "00f067aa0ba902b7", Sampled: true}
+	if !traced.ok || traced.parent != expected {
+		t.Errorf("TraceContext = %+v, %v, want %+v", traced.parent, traced.ok, expected)
+	}
+
+	for _, traceparent := range []string{"", "00-00000000000000000000000000000000-00f067aa0ba902b7-01", "00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01", "garbage"} {
+		echoed := get(traceparent)
+		if !traceparentPattern.MatchString(echoed) || echoed == traceparent {
+			t.Errorf("incoming %q: echoed %q, want a new traceparent", traceparent, echoed)
+		}
+		if !traced.ok || traced.parent.String() != echoed || traced.parent.Sampled {
+			t.Errorf("incoming %q: TraceContext = %+v, %v", traceparent, traced.parent, traced.ok)
+		}
+	}
+
+	if _, ok := parseTraceparent("01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-future"); !ok {
+		t.Error("a later version of the format was rejected")
+	}
+	if _, ok := TraceContext(context.Background()); ok {
+		t.Error("trace context outside a request")
+	}
+}
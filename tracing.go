@@ -0,0 +1,105 @@
+package sleepy
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+)
+
+// A Span is the record of one traced request.
+type Span struct {
+	TraceID  string        `json:"trace_id"`
+	SpanID   string        `json:"span_id"`
+	ParentID string        `json:"parent_id,omitempty"`
+	Method   string        `json:"method"`
+	Path     string        `json:"path"`
+	Status   int           `json:"status"`
+	Start    time.Time     `json:"start"`
+	Duration time.Duration `json:"duration_ns"`
+}
+
+// A TraceExporter sends the spans of traced requests somewhere, such as
+// a tracing backend.
+type TraceExporter interface {
+	Export(Span) error
+}
+
+// debugTraceExporter writes spans to w as JSON, one per line.
+type debugTraceExporter struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// DebugTraceExporter returns a TraceExporter that writes each span to w
+// as a line of JSON, for looking at traces during development.
+func DebugTraceExporter(w io.Writer) TraceExporter {
+	return &debugTraceExporter{w: w}
+}
+
+func (e *debugTraceExporter) Export(span Span) error {
+	line, err := json.Marshal(span)
+	if err != nil {
+		return err
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	_, err = e.w.Write(append(line, '\n'))
+	return err
+}
+
+// TraceMiddleware returns middleware that traces requests, recording
+// each one's method, path, status and timing as a Span that it hands to
+// exporter. The span joins the trace in the request's context, if
+// TraceparentMiddleware or TracePropagationMiddleware put one there
+// first, and is recorded if that trace is sampled. A request without
+// one starts a new trace, and is recorded if sampler returns true, say
+// for one in a hundred requests. A nil sampler traces every such
+// request, and a nil exporter writes spans to standard error with
+// DebugTraceExporter. Export errors are logged.
+func TraceMiddleware(sampler func() bool, exporter TraceExporter) func(http.Handler) http.Handler {
+	if exporter == nil {
+		exporter = DebugTraceExporter(os.Stderr)
+	}
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(rw http.ResponseWriter, request *http.Request) {
+			parent, joined := TraceContext(request.Context())
+			if joined && !parent.Sampled || !joined && sampler != nil && !sampler() {
+				next.ServeHTTP(rw, request)
+				return
+			}
+
+			span := Span{SpanID: newSpanID(), Method: request.Method, Path: request.URL.Path, Start: time.Now()}
+			switch {
+			case !joined:
+				span.TraceID = newTraceParent(true).TraceID
+			case parent.local:
+				// The trace starts here, so the span has no parent.
+				span.TraceID = parent.TraceID
+			default:
+				span.TraceID, span.ParentID = parent.TraceID, parent.ParentID
+			}
+			recorder := wrapResponseWriter(rw)
+			next.ServeHTTP(recorder, request)
+			span.Duration = time.Since(span.Start)
+			span.Status = recorder.status
+			if span.Status == 0 {
+				span.Status = http.StatusOK
+			}
+			if err := exporter.Export(span); err != nil {
+				logf("sleepy: exporting the trace of %s %s: %v", request.Method, request.URL.Path, err)
+			}
+		})
+	}
+}
+
+// newSpanID returns a random 64-bit span ID in hex.
+func newSpanID() string {
+	var id [8]byte
+	rand.Read(id[:])
+	return hex.EncodeToString(id[:])
+}
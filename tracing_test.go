@@ -0,0 +1,68 @@
+package sleepy
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestTraceMiddleware(t *testing.T) {
+	var out bytes.Buffer
+	n := 0
+	everyOther := func() bool { n++; return n%2 == 1 }
+
+	api := NewAPI()
+	api.Use(TraceparentMiddleware(1))
+	api.Use(TraceMiddleware(everyOther, DebugTraceExporter(&out)))
+	api.AddResource(new(Item), "/items")
+
+	for _, traceparent := range []string{
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00",
+	} {
+		request := httptest.NewRequest("GET", "/items", nil)
+		request.Header.Set("Traceparent", traceparent)
+		api.ServeHTTP(httptest.NewRecorder(), request)
+	}
+	api.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("DELETE", "/items", nil))
+	if n != 0 {
+		t.Errorf("sampler called %d times for requests with a trace", n)
+	}
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("exported %d spans, want 3:\n%s", len(lines), out.String())
+	}
+	var span Span
+	if err := json.Unmarshal([]byte(lines[0]), &span); err != nil {
+		t.Fatal(err)
+	}
+	if span.TraceID != "4bf92f3577b34da6a3ce929d0e0e4736" || span.ParentID != "00f067aa0ba902b7" || len(span.SpanID) != 16 {
+		t.Errorf("span IDs = %+v, want to join the incoming trace", span)
+	}
+	if span.Method != "GET" || span.Path != "/items" || span.Status != 200 || span.Start.IsZero() || span.Duration <= 0 {
+		t.Errorf("span = %+v", span)
+	}
+
+	span = Span{}
+	if err := json.Unmarshal([]byte(lines[2]), &span); err != nil {
+		t.Fatal(err)
+	}
+	if span.Method != "DELETE" || span.Status != 405 || span.TraceID == "4bf92f3577b34da6a3ce929d0e0e4736" || span.ParentID != "" {
+		t.Errorf("span of the new trace = %+v", span)
+	}
+
+	out.Reset()
+	api = NewAPI()
+	api.Use(TraceMiddleware(everyOther, DebugTraceExporter(&out)))
+	api.AddResource(new(Item), "/items")
+	for i := 0; i < 4; i++ {
+		api.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/items", nil))
+	}
+	if spans := strings.Count(out.String(), "\n"); spans != 2 {
+		t.Errorf("exported %d spans of untraced requests, want 2", spans)
+	}
+}
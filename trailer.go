@@ -0,0 +1,63 @@
+package sleepy
+
+import (
+	"context"
+	"net/http"
+	"sync"
+)
+
+// trailers holds the trailers a resource sets for its response.
+type trailers struct {
+	mu       sync.Mutex
+	values   http.Header
+	declared map[string]bool
+}
+
+// SetTrailer sets the HTTP trailer name to value on the response to the
+// request being served, from the context passed to a context-aware
+// resource method. Trailers are sent after the body, which suits values
+// such as a checksum that are only known once a stream is complete.
+//
+// Trailers set before the method returns are declared in the
+// response's Trailer header, as clients expecting them may require, and
+// may be set again with their final value while a stream is being
+// sent. A trailer first set during a stream is still sent, undeclared.
+// SetTrailer does nothing given any other context.
+func SetTrailer(ctx context.Context, name, value string) {
+	t, _ := GetContextValue(ctx, trailersKey).(*trailers)
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.values == nil {
+		t.values = make(http.Header)
+	}
+	t.values.Set(name, value)
+}
+
+// declare announces the trailers set so far in the Trailer header. It
+// must be called before the response's header is written.
+func (t *trailers) declare(rw http.ResponseWriter) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for name := range t.values {
+		if t.declared == nil {
+			t.declared = make(map[string]bool)
+		}
+		t.declared[name] = true
+		rw.Header().Add("Trailer", name)
+	}
+}
+
+// write sets the trailers on rw once the body has been written.
+func (t *trailers) write(rw http.ResponseWriter) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for name, values := range t.values {
+		if !t.declared[name] {
+			name = http.TrailerPrefix + name
+		}
+		rw.Header()[name] = values
+	}
+}
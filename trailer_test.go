@@ -0,0 +1,68 @@
+package sleepy
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+type Digest struct{}
+
+func (digest Digest) GetContext(ctx context.Context, values url.Values, headers http.Header) (int, interface{}, http.Header) {
+	SetTrailer(ctx, "X-Checksum", "")
+	records := make(chan interface{})
+	go func() {
+		defer close(records)
+		sum := sha256.New()
+		encoder := json.NewEncoder(sum)
+		for i := 1; i <= 3; i++ {
+			record := map[string]int{"id": i}
+			encoder.Encode(record)
+			records <- record
+		}
+		SetTrailer(ctx, "X-Checksum", hex.EncodeToString(sum.Sum(nil)))
+		SetTrailer(ctx, "X-Count", "3")
+	}()
+	return 200, (<-chan interface{})(records), nil
+}
+
+func TestStreamTrailers(t *testing.T) {
+	api := NewAPI()
+	api.AddResource(new(Digest), "/digest")
+	server := httptest.NewServer(api)
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "/digest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	// The client takes the trailers declared in the header as the keys
+	// of resp.Trailer, and fills in their values as the body ends.
+	if _, declared := resp.Trailer["X-Checksum"]; !declared || len(resp.Trailer) != 1 {
+		t.Errorf("declared trailers %v, want X-Checksum", resp.Trailer)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sum := sha256.Sum256(body)
+	if checksum := resp.Trailer.Get("X-Checksum"); checksum != hex.EncodeToString(sum[:]) {
+		t.Errorf("X-Checksum = %q, want the digest of %q", checksum, body)
+	}
+	if count := resp.Trailer.Get("X-Count"); count != "3" {
+		t.Errorf("X-Count = %q, want 3", count)
+	}
+}
+
+func TestSetTrailerOtherContext(t *testing.T) {
+	// Outside a request there is nowhere to put the trailer.
+	SetTrailer(context.Background(), "X-Checksum", "abc")
+}
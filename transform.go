@@ -0,0 +1,12 @@
+package sleepy
+
+// SetResponseTransformer sets a function that every response body
+// passes through before it is encoded, given the status code and the
+// data the resource returned, and returning the data to encode in its
+// place. It suits changes that apply to all responses, such as adding
+// a type discriminator, without changing what each resource returns.
+// It is not called for responses without a body, for streams, or for
+// plain text.
+func (api *API) SetResponseTransformer(fn func(code int, data interface{}) interface{}) {
+	api.transform = fn
+}
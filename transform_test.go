@@ -0,0 +1,57 @@
+package sleepy
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+type Invoice struct {
+	Number string `json:"number"`
+}
+
+type Invoices struct{}
+
+func (invoices Invoices) Get(values url.Values, headers http.Header) (int, interface{}, http.Header) {
+	if values.Get("number") == "" {
+		return 400, map[string]string{"error": "number is required"}, nil
+	}
+	return 200, Invoice{Number: values.Get("number")}, nil
+}
+
+func (invoices Invoices) Delete(values url.Values, headers http.Header) (int, interface{}, http.Header) {
+	return 204, nil, nil
+}
+
+func TestResponseTransformer(t *testing.T) {
+	api := NewAPI()
+	api.SetJSONOptions("", true)
+	api.AddResource(new(Invoices), "/invoices")
+	var calls int
+	api.SetResponseTransformer(func(code int, data interface{}) interface{} {
+		calls++
+		kind := "invoice"
+		if code >= 400 {
+			kind = "error"
+		}
+		return map[string]interface{}{"type": kind, "data": data}
+	})
+
+	for _, test := range []struct {
+		method, target, expected string
+	}{
+		{"GET", "/invoices?number=7", `{"data":{"number":"7"},"type":"invoice"}`},
+		{"GET", "/invoices", `{"data":{"error":"number is required"},"type":"error"}`},
+		{"DELETE", "/invoices", ``},
+	} {
+		recorder := httptest.NewRecorder()
+		api.ServeHTTP(recorder, httptest.NewRequest(test.method, test.target, nil))
+		if body := recorder.Body.String(); body != test.expected {
+			t.Errorf("%s %s: body = %q, want %q", test.method, test.target, body, test.expected)
+		}
+	}
+	if calls != 2 {
+		t.Errorf("transformer called %d times, want 2", calls)
+	}
+}
@@ -0,0 +1,49 @@
+package sleepy
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// drainRetryAfter is how long clients turned away by a draining API are
+// told to wait; by then the request is likely to reach another instance
+// or a restarted one.
+const drainRetryAfter = 5 * time.Second
+
+// WriteUnavailable answers with a 503 Service Unavailable and a
+// Retry-After header telling the client how long to wait before trying
+// again, in whole seconds rounded up.
+func WriteUnavailable(rw http.ResponseWriter, retryAfter time.Duration) {
+	writeRetryAfter(rw, http.StatusServiceUnavailable, retryAfter)
+}
+
+// RetryAfter is data a resource returns to ask the client to try again
+// later, typically with a 429 Too Many Requests or a 503 Service
+// Unavailable. The response has the status Code, or the status the
+// resource returned if Code is zero, a Retry-After header of After in
+// whole seconds, and no body:
+//
+//	return 0, sleepy.RetryAfter{Code: 503, After: 30 * time.Second}, nil
+type RetryAfter struct {
+	Code  int
+	After time.Duration
+}
+
+// writeRetryAfter answers with code, which should be a status meaning
+// "try again later", and a Retry-After header of at least one second.
+func writeRetryAfter(rw http.ResponseWriter, code int, retryAfter time.Duration) {
+	setRetryAfter(rw.Header(), retryAfter)
+	rw.WriteHeader(code)
+}
+
+// setRetryAfter sets the Retry-After header of a response that is to
+// have a body, in whole seconds rounded up and at least one.
+func setRetryAfter(header http.Header, retryAfter time.Duration) {
+	seconds := int(math.Ceil(retryAfter.Seconds()))
+	if seconds < 1 {
+		seconds = 1
+	}
+	header.Set("Retry-After", strconv.Itoa(seconds))
+}
@@ -0,0 +1,52 @@
+package sleepy
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestWriteUnavailable(t *testing.T) {
+	for retryAfter, expected := range map[time.Duration]string{
+		0:                       "1",
+		300 * time.Millisecond:  "1",
+		2 * time.Second:         "2",
+		2500 * time.Millisecond: "3",
+	} {
+		recorder := httptest.NewRecorder()
+		WriteUnavailable(recorder, retryAfter)
+		if recorder.Code != 503 || recorder.Header().Get("Retry-After") != expected {
+			t.Errorf("%v: got %d with Retry-After %q, want 503 with %q", retryAfter, recorder.Code, recorder.Header().Get("Retry-After"), expected)
+		}
+	}
+}
+
+type Busy struct{}
+
+func (busy Busy) Get(values url.Values, headers http.Header) (int, interface{}, http.Header) {
+	if values.Get("limited") != "" {
+		return 429, RetryAfter{After: 2 * time.Second}, nil
+	}
+	return 0, RetryAfter{Code: 503, After: 30 * time.Second}, nil
+}
+
+func TestReturnedRetryAfter(t *testing.T) {
+	api := NewAPI()
+	api.AddResource(new(Busy), "/busy")
+
+	for target, expected := range map[string]struct {
+		code       int
+		retryAfter string
+	}{
+		"/busy":           {503, "30"},
+		"/busy?limited=1": {429, "2"},
+	} {
+		recorder := httptest.NewRecorder()
+		api.ServeHTTP(recorder, httptest.NewRequest("GET", target, nil))
+		if recorder.Code != expected.code || recorder.Header().Get("Retry-After") != expected.retryAfter || recorder.Body.Len() != 0 {
+			t.Errorf("%s: got %d with Retry-After %q and body %q", target, recorder.Code, recorder.Header().Get("Retry-After"), recorder.Body.String())
+		}
+	}
+}
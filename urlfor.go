@@ -0,0 +1,69 @@
+package sleepy
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// URLFor returns the path at which resource is registered, with each
+// path parameter, such as {id} in "/users/{id}", replaced by its value
+// in params, for building links such as a "self" link to related
+// resources. The method and host of a pattern such as
+// "GET example.com/users/{id}" are left out. Values are escaped as path
+// segments, except that those of a wildcard such as {path...} keep
+// their slashes. A resource added at several paths gets the first, and
+// a resource swapped in with ReplaceResource is found at the path it
+// replaced.
+//
+// It returns an error if resource is not registered or params lacks a
+// value for one of the path's parameters.
+func (api *API) URLFor(resource interface{}, params map[string]string) (string, error) {
+	for _, r := range api.routes {
+		if sameResource(r.current(), resource) {
+			path := patternPath(r.path)
+			if i := strings.IndexByte(path, '/'); i > 0 {
+				path = path[i:] // the host
+			}
+			return interpolatePath(path, params)
+		}
+	}
+	return "", fmt.Errorf("sleepy: no path is registered for %T", resource)
+}
+
+// interpolatePath replaces the parameters in pattern with their values
+// in params.
+func interpolatePath(pattern string, params map[string]string) (string, error) {
+	var path strings.Builder
+	rest := strings.TrimSuffix(pattern, "{$}")
+	for {
+		start := strings.IndexByte(rest, '{')
+		if start < 0 {
+			break
+		}
+		end := strings.IndexByte(rest[start:], '}')
+		if end < 0 {
+			break
+		}
+		end += start
+		path.WriteString(rest[:start])
+
+		name, wildcard := strings.CutSuffix(rest[start+1:end], "...")
+		value, ok := params[name]
+		if !ok {
+			return "", fmt.Errorf("sleepy: no value for the path parameter %s of %s", name, pattern)
+		}
+		if wildcard {
+			segments := strings.Split(value, "/")
+			for i, segment := range segments {
+				segments[i] = url.PathEscape(segment)
+			}
+			path.WriteString(strings.Join(segments, "/"))
+		} else {
+			path.WriteString(url.PathEscape(value))
+		}
+		rest = rest[end+1:]
+	}
+	path.WriteString(rest)
+	return path.String(), nil
+}
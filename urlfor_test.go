@@ -0,0 +1,63 @@
+package sleepy
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+)
+
+type Album struct{}
+
+func (album Album) Get(values url.Values, headers http.Header) (int, interface{}, http.Header) {
+	return 200, "album", nil
+}
+
+type Track struct {
+	number int
+}
+
+func (track *Track) Get(values url.Values, headers http.Header) (int, interface{}, http.Header) {
+	return 200, "track", nil
+}
+
+func TestURLFor(t *testing.T) {
+	track, files := new(Track), new(Track)
+	api := NewAPI()
+	api.AddResource(Album{}, "/artists/{artist}/albums/{album}", "/albums/{album}")
+	api.AddResource(track, "/tracks/{id}/{$}")
+	api.AddResource(files, "/files/{path...}")
+	api.AddResource(Album{}, "GET example.com/singles/{album}")
+	single := new(Track)
+	api.AddResource(single, "GET /singles/{album}/tracks/{id}")
+
+	for _, c := range []struct {
+		resource interface{}
+		params   map[string]string
+		url      string
+	}{
+		{Album{}, map[string]string{"artist": "AC/DC", "album": "Back in Black"}, "/artists/AC%2FDC/albums/Back%20in%20Black"},
+		{track, map[string]string{"id": "7"}, "/tracks/7/"},
+		{files, map[string]string{"path": "docs/a b.txt"}, "/files/docs/a%20b.txt"},
+		{single, map[string]string{"album": "Thunderstruck", "id": "1"}, "/singles/Thunderstruck/tracks/1"},
+	} {
+		got, err := api.URLFor(c.resource, c.params)
+		if err != nil {
+			t.Errorf("URLFor(%T): %v", c.resource, err)
+		} else if got != c.url {
+			t.Errorf("URLFor(%T) = %q, want %q", c.resource, got, c.url)
+		}
+	}
+
+	if _, err := api.URLFor(track, nil); err == nil {
+		t.Error("URLFor without the id succeeded")
+	}
+	if _, err := api.URLFor(new(Track), map[string]string{"id": "7"}); err == nil {
+		t.Error("URLFor of an unregistered resource succeeded")
+	}
+
+	other := new(Track)
+	api.ReplaceResource("/tracks/{id}/{$}", other)
+	if got, _ := api.URLFor(other, map[string]string{"id": "8"}); got != "/tracks/8/" {
+		t.Errorf("URLFor of a replacement = %q", got)
+	}
+}
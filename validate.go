@@ -0,0 +1,115 @@
+package sleepy
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+)
+
+// Validate checks the resources registered with the API without
+// serving any requests, for tests of large route configurations. It
+// reports every resource that supports no HTTP method, through the
+// method interfaces or otherwise, since all its requests would get a
+// 405. It also reports every path registered twice in ways the
+// ServeMux accepts but where one registration hides the other, such as
+// "/items" and "GET /items", or a path of a mounted API that the API it
+// is mounted on registers itself. Patterns for different methods on the
+// same path, "GET /items" and "POST /items" say, do not hide each other
+// and are not reported. Handlers added with AddHandler,
+// mounted APIs and RawHandler resources answer requests themselves and
+// are not checked for methods. Validate returns nil if it finds nothing
+// wrong, and otherwise the problems joined together in registration
+// order.
+func (api *API) Validate() error {
+	var errs []error
+	registered := make(map[string][]string)
+	for _, r := range api.routes {
+		key := routeKey(r.path)
+		if first, ok := overlapping(registered[key], r.path); ok {
+			errs = append(errs, fmt.Errorf("sleepy: %s and %s both match the path %s", first, r.path, key))
+		}
+		registered[key] = append(registered[key], r.path)
+
+		resource := r.current()
+		if answersItself(resource) {
+			continue
+		}
+		if len(supportedMethods(resource)) == 0 {
+			errs = append(errs, fmt.Errorf("sleepy: resource %T at %s implements no method interface", resource, r.path))
+		}
+	}
+
+	for _, r := range api.routes {
+		backend, ok := r.current().(*API)
+		if !ok {
+			continue
+		}
+		prefix := strings.TrimSuffix(r.path, "/")
+		for _, mounted := range backend.routes {
+			if path, ok := overlapping(registered[routeKey(prefix+patternPath(mounted.path))], mounted.path); ok {
+				errs = append(errs, fmt.Errorf("sleepy: %s hides %s of the API mounted at %s", path, mounted.path, prefix))
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// overlapping returns the first of patterns, which all match the same
+// path, that matches some of the same requests as pattern: one for the
+// same method, or either of them for any method.
+func overlapping(patterns []string, pattern string) (string, bool) {
+	method := patternMethod(pattern)
+	for _, other := range patterns {
+		if m := patternMethod(other); m == "" || method == "" || m == method {
+			return other, true
+		}
+	}
+	return "", false
+}
+
+// patternMethod returns the method of pattern, a ServeMux pattern, or
+// "" if it matches any method.
+func patternMethod(pattern string) string {
+	if method, _, ok := strings.Cut(pattern, " "); ok {
+		return method
+	}
+	return ""
+}
+
+// patternPath returns pattern, a ServeMux pattern, without its method.
+func patternPath(pattern string) string {
+	if _, path, ok := strings.Cut(pattern, " "); ok {
+		return strings.TrimLeft(path, " \t")
+	}
+	return pattern
+}
+
+// wildcard matches a wildcard in a ServeMux pattern.
+var wildcard = regexp.MustCompile(`\{[^}]*\}`)
+
+// routeKey returns the host and path that pattern matches, without its
+// method and with its wildcards unnamed, so that two patterns match the
+// same requests, bar their methods, if they have the same key.
+func routeKey(pattern string) string {
+	return wildcard.ReplaceAllStringFunc(patternPath(pattern), func(w string) string {
+		switch {
+		case w == "{$}":
+			return w
+		case strings.HasSuffix(w, "...}"):
+			return "{...}"
+		}
+		return "{}"
+	})
+}
+
+// answersItself reports whether resource handles requests without the
+// method interfaces.
+func answersItself(resource interface{}) bool {
+	switch resource.(type) {
+	case http.Handler, RawHandler:
+		return true
+	}
+	return false
+}
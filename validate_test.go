@@ -0,0 +1,61 @@
+package sleepy
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+)
+
+type Inert struct{}
+
+func TestValidate(t *testing.T) {
+	api := NewAPI()
+	api.AddResource(new(Item), "/items")
+	api.AddHandler("/health", http.NotFoundHandler())
+	if err := api.Validate(); err != nil {
+		t.Errorf("well-formed API: got %v, want nil", err)
+	}
+
+	api.AddResource(new(Inert), "/inert")
+	err := api.Validate()
+	if err == nil || !strings.Contains(err.Error(), "*sleepy.Inert at /inert implements no method interface") {
+		t.Errorf("got %v, want an error naming *sleepy.Inert", err)
+	}
+}
+
+func TestValidateDuplicatePath(t *testing.T) {
+	api := NewAPI()
+	api.AddResource(new(Item), "/items", "/items/{id}")
+	api.AddResource(new(Counter), "GET /items", "DELETE /items/{key}")
+	api.AddResource(new(Item), "example.com/items")
+	err := api.Validate()
+	for _, want := range []string{
+		"sleepy: /items and GET /items both match the path /items",
+		"sleepy: /items/{id} and DELETE /items/{key} both match the path /items/{}",
+	} {
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("got %v, want %q", err, want)
+		}
+	}
+	if err != nil && strings.Contains(err.Error(), "example.com") {
+		t.Errorf("a path on another host was reported: %v", err)
+	}
+
+	api = NewAPI()
+	api.AddResource(new(Item), "GET /items/{id}")
+	api.AddResource(new(Counter), "DELETE /items/{key}")
+	if err := api.Validate(); err != nil {
+		t.Errorf("one path for two methods: got %v, want nil", err)
+	}
+
+	billing := NewAPI()
+	billing.AddResource(new(Item), "/invoices")
+	billing.AddResource(new(Item), "/payments")
+	api = NewAPI()
+	api.Mount("/billing", billing)
+	api.AddResource(new(Counter), "/billing/invoices")
+	err = api.Validate()
+	if err == nil || err.Error() != "sleepy: /billing/invoices hides /invoices of the API mounted at /billing" {
+		t.Errorf("got %v, want the hidden mounted path reported", err)
+	}
+}
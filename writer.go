@@ -0,0 +1,192 @@
+package sleepy
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io"
+	"log"
+	"net"
+	"net/http"
+)
+
+// logf reports failures that happen where no response can tell the
+// client about them, such as panics in background handlers. Tests
+// replace it to observe them.
+var logf = log.Printf
+
+// responseWriter wraps an http.ResponseWriter and records the status
+// code and the number of body bytes written through it. The API wraps
+// every response in one, which the middleware and hooks that need the
+// status or size share through wrapResponseWriter rather than each
+// adding a wrapper of its own.
+type responseWriter struct {
+	http.ResponseWriter
+	status int
+	bytes  int
+}
+
+// wrapResponseWriter returns rw if it is already a responseWriter, and
+// otherwise a responseWriter wrapping it.
+func wrapResponseWriter(rw http.ResponseWriter) *responseWriter {
+	if writer, ok := rw.(*responseWriter); ok {
+		return writer
+	}
+	return &responseWriter{ResponseWriter: rw}
+}
+
+// ResponseStatus returns the status code of the response being written
+// to rw, for middleware added with Use or WithMiddleware to inspect
+// once the handler it wraps has returned. It is 200 if a body
+// has been written without a status, and 0 if nothing has been written
+// yet or rw is not a writer the API passed down.
+func ResponseStatus(rw http.ResponseWriter) int {
+	for {
+		switch writer := rw.(type) {
+		case *responseWriter:
+			return writer.status
+		case interface{ Unwrap() http.ResponseWriter }:
+			rw = writer.Unwrap()
+		default:
+			return 0
+		}
+	}
+}
+
+func (rw *responseWriter) WriteHeader(code int) {
+	if rw.status == 0 {
+		rw.status = code
+	}
+	rw.ResponseWriter.WriteHeader(code)
+}
+
+func (rw *responseWriter) Write(b []byte) (int, error) {
+	if rw.status == 0 {
+		rw.status = http.StatusOK
+	}
+	n, err := rw.ResponseWriter.Write(b)
+	rw.bytes += n
+	return n, err
+}
+
+// Unwrap returns the underlying ResponseWriter, so that
+// http.ResponseController can reach its optional interfaces.
+func (rw *responseWriter) Unwrap() http.ResponseWriter {
+	return rw.ResponseWriter
+}
+
+// Flush sends any buffered data to the client, if the underlying
+// ResponseWriter can, so that streams such as Server-Sent Events work
+// through the wrapper.
+func (rw *responseWriter) Flush() {
+	if rw.status == 0 {
+		rw.status = http.StatusOK
+	}
+	http.NewResponseController(rw.ResponseWriter).Flush()
+}
+
+// Hijack hands over the connection, for WebSockets and the like, if the
+// underlying ResponseWriter supports it.
+func (rw *responseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return http.NewResponseController(rw.ResponseWriter).Hijack()
+}
+
+// ReadFrom copies from r, through the underlying ResponseWriter's own
+// ReadFrom if it has one, so that files can still be sent with
+// sendfile.
+func (rw *responseWriter) ReadFrom(r io.Reader) (int64, error) {
+	if rw.status == 0 {
+		rw.status = http.StatusOK
+	}
+	var n int64
+	var err error
+	if from, ok := rw.ResponseWriter.(io.ReaderFrom); ok {
+		n, err = from.ReadFrom(r)
+	} else {
+		n, err = io.Copy(writerOnly{rw.ResponseWriter}, r)
+	}
+	rw.bytes += int(n)
+	return n, err
+}
+
+// writerOnly hides every method of a writer but Write, so that io.Copy
+// doesn't call back into a ReadFrom.
+type writerOnly struct {
+	io.Writer
+}
+
+// bufferedResponse is a ResponseWriter that holds the whole response in
+// memory, so it can be inspected before anything reaches the client.
+type bufferedResponse struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func newBufferedResponse() *bufferedResponse {
+	return &bufferedResponse{header: make(http.Header)}
+}
+
+func (b *bufferedResponse) Header() http.Header {
+	return b.header
+}
+
+func (b *bufferedResponse) WriteHeader(code int) {
+	if b.status == 0 {
+		b.status = code
+	}
+}
+
+func (b *bufferedResponse) Write(p []byte) (int, error) {
+	if b.status == 0 {
+		b.status = http.StatusOK
+	}
+	return b.body.Write(p)
+}
+
+// copyTo writes the buffered response to rw.
+func (b *bufferedResponse) copyTo(rw http.ResponseWriter) {
+	addHeaders(rw, b.header)
+	if b.status == 0 {
+		b.status = http.StatusOK
+	}
+	rw.WriteHeader(b.status)
+	rw.Write(b.body.Bytes())
+}
+
+// discardResponse is a ResponseWriter that throws away everything
+// written to it.
+type discardResponse struct {
+	header http.Header
+}
+
+func (d discardResponse) Header() http.Header {
+	return d.header
+}
+
+func (d discardResponse) WriteHeader(code int) {}
+
+func (d discardResponse) Write(p []byte) (int, error) {
+	return len(p), nil
+}
+
+// errResponseTooLarge is returned by a cappedWriter once its limit is
+// reached.
+var errResponseTooLarge = errors.New("sleepy: response body exceeds the limit")
+
+// cappedWriter passes writes through to w until limit bytes have been
+// written, and fails every write after that.
+type cappedWriter struct {
+	w       io.Writer
+	limit   int64
+	written int64
+}
+
+func (c *cappedWriter) Write(p []byte) (int, error) {
+	if c.written+int64(len(p)) > c.limit {
+		return 0, errResponseTooLarge
+	}
+	n, err := c.w.Write(p)
+	c.written += int64(n)
+	return n, err
+}
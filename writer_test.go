@@ -0,0 +1,55 @@
+package sleepy
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestResponseStatus(t *testing.T) {
+	var statuses []int
+	api := NewAPI()
+	api.AddResource(new(Item), "/items")
+	api.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(rw http.ResponseWriter, request *http.Request) {
+			next.ServeHTTP(rw, request)
+			statuses = append(statuses, ResponseStatus(rw))
+		})
+	})
+
+	api.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/items", nil))
+	api.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("DELETE", "/items", nil))
+	if len(statuses) != 2 || statuses[0] != 200 || statuses[1] != 405 {
+		t.Errorf("got statuses %v, want [200 405]", statuses)
+	}
+
+	if status := ResponseStatus(httptest.NewRecorder()); status != 0 {
+		t.Errorf("unwrapped writer: got %d, want 0", status)
+	}
+}
+
+func TestResponseWriterFlush(t *testing.T) {
+	api := NewAPI()
+	api.AddHandler("/flush", http.HandlerFunc(func(rw http.ResponseWriter, request *http.Request) {
+		flusher, ok := rw.(http.Flusher)
+		if !ok {
+			t.Error("wrapped writer is not an http.Flusher")
+			return
+		}
+		rw.Write([]byte("partial"))
+		flusher.Flush()
+	}))
+
+	recorder := httptest.NewRecorder()
+	api.ServeHTTP(recorder, httptest.NewRequest("GET", "/flush", nil))
+	if !recorder.Flushed || recorder.Body.String() != "partial" {
+		t.Errorf("got flushed %v with %q, want the flush to reach the recorder", recorder.Flushed, recorder.Body.String())
+	}
+}
+
+func TestWrapResponseWriterShared(t *testing.T) {
+	writer := wrapResponseWriter(httptest.NewRecorder())
+	if wrapResponseWriter(writer) != writer {
+		t.Error("rewrapping a responseWriter made a new wrapper")
+	}
+}
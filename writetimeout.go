@@ -0,0 +1,22 @@
+package sleepy
+
+import (
+	"net/http"
+	"time"
+)
+
+// WithoutWriteTimeout lifts the server's write timeout for responses
+// from the route, so that a long-lived stream such as NDJSON or
+// Server-Sent Events isn't cut off when it outlasts the WriteTimeout
+// that bounds every other response. Only the route's own connections
+// are affected. It has no effect on a route under a timeout, of its own
+// or the API's default, whose responses are buffered rather than
+// streamed.
+func WithoutWriteTimeout() ResourceOption {
+	return WithMiddleware(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(rw http.ResponseWriter, request *http.Request) {
+			http.NewResponseController(rw).SetWriteDeadline(time.Time{})
+			next.ServeHTTP(rw, request)
+		})
+	})
+}
@@ -0,0 +1,51 @@
+package sleepy
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+)
+
+type SlowFeed struct{}
+
+func (feed SlowFeed) Get(values url.Values, headers http.Header) (int, interface{}, http.Header) {
+	stream := make(chan interface{})
+	go func() {
+		defer close(stream)
+		for i := 1; i <= 3; i++ {
+			stream <- i
+			time.Sleep(40 * time.Millisecond)
+		}
+	}()
+	return 200, stream, nil
+}
+
+func TestWithoutWriteTimeout(t *testing.T) {
+	api := NewAPI()
+	api.AddResource(new(SlowFeed), "/bounded")
+	api.AddResourceWithOptions(new(SlowFeed), "/unbounded", WithoutWriteTimeout())
+	server := httptest.NewUnstartedServer(api)
+	server.Config.WriteTimeout = 50 * time.Millisecond
+	server.Start()
+	defer server.Close()
+
+	get := func(path string) (string, error) {
+		response, err := http.Get(server.URL + path)
+		if err != nil {
+			return "", err
+		}
+		defer response.Body.Close()
+		body, err := io.ReadAll(response.Body)
+		return string(body), err
+	}
+
+	if body, err := get("/unbounded"); err != nil || body != "1\n2\n3\n" {
+		t.Errorf("stream without a write timeout: got %q, %v", body, err)
+	}
+	if body, err := get("/bounded"); err == nil && body == "1\n2\n3\n" {
+		t.Error("stream outlasting the write timeout was not cut off")
+	}
+}